@@ -14,9 +14,13 @@ import (
 	"github.com/yourusername/health-competition-go/internal/config"
 	"github.com/yourusername/health-competition-go/internal/handlers"
 	"github.com/yourusername/health-competition-go/internal/middleware"
+	"github.com/yourusername/health-competition-go/internal/models"
 	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/connectors"
+	"github.com/yourusername/health-competition-go/pkg/payments"
 	"github.com/yourusername/health-competition-go/pkg/storage"
 	"github.com/yourusername/health-competition-go/pkg/utils"
+	"github.com/yourusername/health-competition-go/pkg/webhooks"
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
@@ -76,7 +80,7 @@ func main() {
 	redisClient := services.NewRedisClient(cfg.RedisURL)
 	cacheService := services.NewCacheService(redisClient)
 	leaderboardService := services.NewLeaderboardService(cacheService, redisClient)
-	fitnessService := services.NewFitnessService(cacheService, cfg.SupabaseURL)
+	fitnessService := services.NewFitnessService(db, cacheService, leaderboardService, cfg.SupabaseURL)
 
 	// Initialize Supabase Storage
 	supabaseStorage, err := storage.NewSupabaseStorage()
@@ -88,10 +92,53 @@ func main() {
 	// Initialize services that need database connection
 	var competitionService *services.CompetitionService
 	var userService *services.UserService
+	var withdrawalService *services.WithdrawalService
+	var notificationService *services.NotificationService
+	var templateService *services.TemplateService
+	var challengeService *services.ChallengeService
+	var divisionService *services.DivisionService
+	var connectorService *services.ConnectorService
+	var webhookService *services.WebhookService
+
+	// Background jobs stop when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
 
 	if db != nil {
-		competitionService = services.NewCompetitionService(db, cacheService)
+		paymentProvider, err := payments.NewPaymentProvider(cfg.PaymentProvider)
+		if err != nil {
+			log.Fatalf("Failed to initialize payment provider: %v", err)
+		}
+		notificationService = services.NewNotificationService(db, cacheService)
+		competitionService = services.NewCompetitionService(db, cacheService, leaderboardService, paymentProvider, notificationService, models.RefundPolicy{
+			BeforeStartPercent: cfg.LeaveRefundBeforeStartPercent,
+			AfterStartPercent:  cfg.LeaveRefundAfterStartPercent,
+		}, cfg.InviteLinkBaseURL)
 		userService = services.NewUserService(db, cacheService)
+
+		payoutProvider, err := payments.NewPayoutProvider(cfg.PayoutProvider)
+		if err != nil {
+			log.Fatalf("Failed to initialize payout provider: %v", err)
+		}
+		withdrawalService = services.NewWithdrawalService(db, payoutProvider, models.WithdrawalLimits{
+			MinAmount:    cfg.WithdrawalMinAmount,
+			DailyLimit:   cfg.WithdrawalDailyLimit,
+			MonthlyLimit: cfg.WithdrawalMonthlyLimit,
+		})
+
+		templateService = services.NewTemplateService(db, competitionService, notificationService, logger)
+
+		challengeService = services.NewChallengeService(db, leaderboardService, notificationService)
+		divisionService = services.NewDivisionService(db, competitionService, notificationService)
+
+		scheduler := services.NewCompetitionScheduler(db, leaderboardService, notificationService, templateService, challengeService, divisionService, withdrawalService, logger, cfg.SchedulerInterval)
+		go scheduler.Run(jobsCtx)
+
+		connectorService = services.NewConnectorService(db, cacheService, fitnessService, connectorProviders(cfg), logger, cfg.ConnectorSyncInterval)
+		go connectorService.Run(jobsCtx)
+
+		webhookService = services.NewWebhookService(db, redisClient, cacheService, fitnessService, webhookVendors(cfg), logger)
+		go webhookService.Run(jobsCtx)
 		logger.Info("Database services initialized")
 	} else {
 		logger.Info("Running without database services (API-only mode)")
@@ -106,16 +153,30 @@ func main() {
 	go wsHub.Run()
 
 	// Initialize handlers
-	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService, logger)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService, competitionService, logger)
 	fitnessHandler := handlers.NewFitnessHandler(fitnessService, logger)
-	wsHandler := handlers.NewWebSocketHandler(wsHub, leaderboardService, logger)
+	wsHandler := handlers.NewWebSocketHandler(wsHub, leaderboardService, competitionService, logger)
 
 	var competitionHandler *handlers.CompetitionHandler
 	var userHandler *handlers.UserHandler
+	var withdrawalHandler *handlers.WithdrawalHandler
+	var notificationHandler *handlers.NotificationHandler
+	var templateHandler *handlers.TemplateHandler
+	var challengeHandler *handlers.ChallengeHandler
+	var divisionHandler *handlers.DivisionHandler
+	var connectorHandler *handlers.ConnectorHandler
+	var webhookHandler *handlers.WebhookHandler
 
 	if competitionService != nil && userService != nil {
 		competitionHandler = handlers.NewCompetitionHandler(competitionService, logger)
 		userHandler = handlers.NewUserHandler(userService, logger, supabaseStorage)
+		withdrawalHandler = handlers.NewWithdrawalHandler(withdrawalService, logger)
+		notificationHandler = handlers.NewNotificationHandler(notificationService, logger)
+		templateHandler = handlers.NewTemplateHandler(templateService, logger)
+		challengeHandler = handlers.NewChallengeHandler(challengeService, logger)
+		divisionHandler = handlers.NewDivisionHandler(divisionService, logger)
+		connectorHandler = handlers.NewConnectorHandler(connectorService, logger)
+		webhookHandler = handlers.NewWebhookHandler(webhookService, logger)
 	}
 
 	// Setup router
@@ -140,20 +201,48 @@ func main() {
 	api := r.PathPrefix("/api/v1").Subrouter()
 	api.Use(middleware.AuthMiddleware(cfg.SupabaseJWTSecret))
 
+	// Admin routes (require the admin role in the JWT app_metadata)
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireAdmin)
+
 	// Leaderboard routes
 	api.HandleFunc("/leaderboard/{competitionId}", leaderboardHandler.GetLeaderboard).Methods("GET")
-	api.HandleFunc("/leaderboard/update", leaderboardHandler.UpdateScore).Methods("POST")
 
 	// Fitness routes
 	api.HandleFunc("/fitness/sync", fitnessHandler.SyncFitnessData).Methods("POST")
+	api.HandleFunc("/fitness/backfill", fitnessHandler.BackfillFitnessData).Methods("POST")
 	api.HandleFunc("/fitness/stats/{userId}", fitnessHandler.GetUserStats).Methods("GET")
 
+	// Activity imports, source merge settings and the anti-cheat review queue
+	// (require database)
+	if db != nil {
+		api.HandleFunc("/fitness/import", fitnessHandler.ImportActivity).Methods("POST")
+		api.HandleFunc("/fitness/import/apple-health", fitnessHandler.ImportAppleHealth).Methods("POST")
+		api.HandleFunc("/fitness/sources/settings", fitnessHandler.GetMergeSettings).Methods("GET")
+		api.HandleFunc("/fitness/sources/settings", fitnessHandler.UpdateMergeSettings).Methods("PUT")
+
+		admin.HandleFunc("/fitness/anomalies", fitnessHandler.GetAnomalyQueue).Methods("GET")
+		admin.HandleFunc("/fitness/anomalies/{id}/approve", fitnessHandler.ApproveAnomaly).Methods("POST")
+		admin.HandleFunc("/fitness/anomalies/{id}/reject", fitnessHandler.RejectAnomaly).Methods("POST")
+	}
+
 	// Competition routes (require database)
 	if competitionHandler != nil {
 		api.HandleFunc("/competitions", competitionHandler.GetCompetitions).Methods("GET")
 		api.HandleFunc("/competitions", competitionHandler.CreateCompetition).Methods("POST")
 		api.HandleFunc("/competitions/{id}", competitionHandler.GetCompetition).Methods("GET")
+		api.HandleFunc("/competitions/{id}", competitionHandler.UpdateCompetition).Methods("PUT")
+		api.HandleFunc("/competitions/{id}", competitionHandler.CancelCompetition).Methods("DELETE")
+		api.HandleFunc("/competitions/{id}/cancel", competitionHandler.CancelCompetition).Methods("POST")
 		api.HandleFunc("/competitions/{id}/join", competitionHandler.JoinCompetition).Methods("POST")
+		api.HandleFunc("/competitions/{id}/leave", competitionHandler.LeaveCompetition).Methods("POST")
+		api.HandleFunc("/competitions/{id}/participants", competitionHandler.GetParticipants).Methods("GET")
+		api.HandleFunc("/competitions/{id}/eligibility", competitionHandler.GetEligibility).Methods("GET")
+		api.HandleFunc("/competitions/{id}/invites", competitionHandler.CreateInvite).Methods("POST")
+		api.HandleFunc("/competitions/{id}/invites", competitionHandler.GetInvites).Methods("GET")
+		api.HandleFunc("/competitions/{id}/invites/{inviteId}", competitionHandler.RevokeInvite).Methods("DELETE")
+		api.HandleFunc("/invites/{code}", competitionHandler.GetInvite).Methods("GET")
+		api.HandleFunc("/invites/{code}/join", competitionHandler.JoinByInviteCode).Methods("POST")
 		api.HandleFunc("/users/{userId}/competitions", competitionHandler.GetUserCompetitions).Methods("GET")
 	}
 
@@ -167,6 +256,66 @@ func main() {
 		api.HandleFunc("/users/{userId}/transactions", userHandler.GetUserTransactions).Methods("GET")
 	}
 
+	// Withdrawal routes (require database)
+	if withdrawalHandler != nil {
+		api.HandleFunc("/withdrawals", withdrawalHandler.RequestWithdrawal).Methods("POST")
+		api.HandleFunc("/withdrawals", withdrawalHandler.GetMyWithdrawals).Methods("GET")
+
+		admin.HandleFunc("/withdrawals", withdrawalHandler.GetReviewQueue).Methods("GET")
+		admin.HandleFunc("/withdrawals/{id}/approve", withdrawalHandler.ApproveWithdrawal).Methods("POST")
+		admin.HandleFunc("/withdrawals/{id}/reject", withdrawalHandler.RejectWithdrawal).Methods("POST")
+	}
+
+	// Recurring competition template routes (require database)
+	if templateHandler != nil {
+		api.HandleFunc("/competition-templates", templateHandler.GetTemplates).Methods("GET")
+		api.HandleFunc("/competition-templates", templateHandler.CreateTemplate).Methods("POST")
+		api.HandleFunc("/competition-templates/{id}", templateHandler.GetTemplate).Methods("GET")
+		api.HandleFunc("/competition-templates/{id}", templateHandler.UpdateTemplate).Methods("PUT")
+		api.HandleFunc("/competition-templates/{id}/subscription", templateHandler.Subscribe).Methods("POST")
+		api.HandleFunc("/competition-templates/{id}/subscription", templateHandler.Unsubscribe).Methods("DELETE")
+	}
+
+	// Skill division routes (require database)
+	if divisionHandler != nil {
+		api.HandleFunc("/competitions/{id}/divisions", divisionHandler.GetDivisions).Methods("GET")
+		api.HandleFunc("/competitions/{id}/divisions/{division}/leaderboard", divisionHandler.GetDivisionLeaderboard).Methods("GET")
+		api.HandleFunc("/users/{userId}/divisions", divisionHandler.GetDivisionHistory).Methods("GET")
+	}
+
+	// Fitness provider connector routes (require database)
+	if connectorHandler != nil {
+		api.HandleFunc("/fitness/connectors", connectorHandler.GetConnectors).Methods("GET")
+		api.HandleFunc("/fitness/connectors/{provider}/authorize", connectorHandler.AuthorizeConnector).Methods("POST")
+		api.HandleFunc("/fitness/connectors/{provider}/connect", connectorHandler.ConnectProvider).Methods("POST")
+		api.HandleFunc("/fitness/connectors/{provider}", connectorHandler.DisconnectProvider).Methods("DELETE")
+	}
+
+	// Wearable webhook routes (require database). Vendors sign their pushes
+	// instead of sending a JWT, so the receiver sits outside /api/v1.
+	if webhookHandler != nil {
+		r.HandleFunc("/webhooks/{vendor}", webhookHandler.ReceiveWebhook).Methods("POST")
+		admin.HandleFunc("/wearables/{vendor}/{vendorUserId}", webhookHandler.LinkWearable).Methods("PUT")
+		admin.HandleFunc("/wearables/{vendor}/{vendorUserId}", webhookHandler.UnlinkWearable).Methods("DELETE")
+	}
+
+	// One-on-one challenge routes (require database)
+	if challengeHandler != nil {
+		api.HandleFunc("/challenges", challengeHandler.GetChallenges).Methods("GET")
+		api.HandleFunc("/challenges", challengeHandler.CreateChallenge).Methods("POST")
+		api.HandleFunc("/challenges/{id}", challengeHandler.GetChallenge).Methods("GET")
+		api.HandleFunc("/challenges/{id}/accept", challengeHandler.AcceptChallenge).Methods("POST")
+		api.HandleFunc("/challenges/{id}/decline", challengeHandler.DeclineChallenge).Methods("POST")
+		api.HandleFunc("/challenges/{id}/cancel", challengeHandler.CancelChallenge).Methods("POST")
+		api.HandleFunc("/challenges/{id}/leaderboard", challengeHandler.GetChallengeLeaderboard).Methods("GET")
+	}
+
+	// Notification routes (require database)
+	if notificationHandler != nil {
+		api.HandleFunc("/notifications", notificationHandler.GetNotifications).Methods("GET")
+		api.HandleFunc("/notifications/{id}/read", notificationHandler.MarkRead).Methods("POST")
+	}
+
 	// Serve static files (uploaded avatars)
 	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir("uploads"))))
 
@@ -208,6 +357,7 @@ func main() {
 	<-quit
 
 	logger.Info("Server shutting down...")
+	stopJobs()
 
 	// Close database connection if available
 	if db != nil {
@@ -225,6 +375,55 @@ func main() {
 	logger.Info("Server exited")
 }
 
+// connectorProviders returns the fitness providers that have client
+// credentials configured
+func connectorProviders(cfg *config.Config) map[string]connectors.Provider {
+	credentials := map[string][2]string{
+		"google_fit": {cfg.GoogleFitClientID, cfg.GoogleFitClientSecret},
+		"fitbit":     {cfg.FitbitClientID, cfg.FitbitClientSecret},
+		"strava":     {cfg.StravaClientID, cfg.StravaClientSecret},
+	}
+
+	providers := map[string]connectors.Provider{}
+	for name, creds := range credentials {
+		if creds[0] == "" {
+			continue
+		}
+		provider, err := connectors.New(name, connectors.Config{
+			ClientID:     creds[0],
+			ClientSecret: creds[1],
+			RedirectURL:  cfg.ConnectorRedirectURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize %s connector: %v", name, err)
+		}
+		providers[name] = provider
+	}
+	return providers
+}
+
+// webhookVendors returns the wearable vendors that have a webhook signing
+// secret configured
+func webhookVendors(cfg *config.Config) map[string]webhooks.Vendor {
+	secrets := map[string]string{
+		"garmin": cfg.GarminWebhookSecret,
+		"polar":  cfg.PolarWebhookSecret,
+	}
+
+	vendors := map[string]webhooks.Vendor{}
+	for name, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		vendor, err := webhooks.New(name, secret)
+		if err != nil {
+			log.Fatalf("Failed to initialize %s webhooks: %v", name, err)
+		}
+		vendors[name] = vendor
+	}
+	return vendors
+}
+
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
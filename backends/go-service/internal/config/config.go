@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +17,40 @@ type Config struct {
 	DatabaseURL        string
 	LogLevel           string
 	Environment        string
+
+	// Payments
+	PaymentProvider string
+	PayoutProvider  string
+
+	// Refund policy when leaving a competition (percent of the entry fee)
+	LeaveRefundBeforeStartPercent float64
+	LeaveRefundAfterStartPercent  float64
+
+	// Withdrawals
+	WithdrawalMinAmount    float64
+	WithdrawalDailyLimit   float64
+	WithdrawalMonthlyLimit float64
+
+	// Background jobs
+	SchedulerInterval time.Duration
+
+	// Prefix for shareable competition invite links, e.g. https://app.example.com/invite
+	InviteLinkBaseURL string
+
+	// Fitness provider connectors; a provider is offered once its client ID is set
+	GoogleFitClientID     string
+	GoogleFitClientSecret string
+	FitbitClientID        string
+	FitbitClientSecret    string
+	StravaClientID        string
+	StravaClientSecret    string
+	ConnectorRedirectURL  string
+	ConnectorSyncInterval time.Duration
+
+	// Wearable webhook signing secrets; a vendor's webhooks are accepted once
+	// its secret is set
+	GarminWebhookSecret string
+	PolarWebhookSecret  string
 }
 
 func Load() (*Config, error) {
@@ -30,6 +66,32 @@ func Load() (*Config, error) {
 		DatabaseURL:        getEnv("DATABASE_URL", ""),
 		LogLevel:           getEnv("LOG_LEVEL", "info"),
 		Environment:        getEnv("ENVIRONMENT", "development"),
+
+		PaymentProvider: getEnv("PAYMENT_PROVIDER", "fake"),
+		PayoutProvider:  getEnv("PAYOUT_PROVIDER", "fake"),
+
+		LeaveRefundBeforeStartPercent: getEnvFloat("LEAVE_REFUND_BEFORE_START_PERCENT", 100),
+		LeaveRefundAfterStartPercent:  getEnvFloat("LEAVE_REFUND_AFTER_START_PERCENT", 0),
+
+		WithdrawalMinAmount:    getEnvFloat("WITHDRAWAL_MIN_AMOUNT", 10),
+		WithdrawalDailyLimit:   getEnvFloat("WITHDRAWAL_DAILY_LIMIT", 500),
+		WithdrawalMonthlyLimit: getEnvFloat("WITHDRAWAL_MONTHLY_LIMIT", 2000),
+
+		SchedulerInterval: getEnvDuration("SCHEDULER_INTERVAL", time.Minute),
+
+		InviteLinkBaseURL: getEnv("INVITE_LINK_BASE_URL", ""),
+
+		GoogleFitClientID:     getEnv("GOOGLE_FIT_CLIENT_ID", ""),
+		GoogleFitClientSecret: getEnv("GOOGLE_FIT_CLIENT_SECRET", ""),
+		FitbitClientID:        getEnv("FITBIT_CLIENT_ID", ""),
+		FitbitClientSecret:    getEnv("FITBIT_CLIENT_SECRET", ""),
+		StravaClientID:        getEnv("STRAVA_CLIENT_ID", ""),
+		StravaClientSecret:    getEnv("STRAVA_CLIENT_SECRET", ""),
+		ConnectorRedirectURL:  getEnv("CONNECTOR_REDIRECT_URL", ""),
+		ConnectorSyncInterval: getEnvDuration("CONNECTOR_SYNC_INTERVAL", time.Hour),
+
+		GarminWebhookSecret: getEnv("WEBHOOK_SECRET_GARMIN", ""),
+		PolarWebhookSecret:  getEnv("WEBHOOK_SECRET_POLAR", ""),
 	}
 
 	return cfg, nil
@@ -42,3 +104,27 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yourusername/health-competition-go/internal/middleware"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type ChallengeHandler struct {
+	service *services.ChallengeService
+	logger  *utils.Logger
+}
+
+func NewChallengeHandler(service *services.ChallengeService, logger *utils.Logger) *ChallengeHandler {
+	return &ChallengeHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetChallenges handles GET /api/v1/challenges
+func (h *ChallengeHandler) GetChallenges(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	challenges, err := h.service.GetUserChallenges(r.Context(), userID, r.URL.Query().Get("status"))
+	if err != nil {
+		h.logger.Errorf("Failed to get challenges: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve challenges", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, challenges, http.StatusOK)
+}
+
+// CreateChallenge handles POST /api/v1/challenges
+func (h *ChallengeHandler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.ChallengerID = userID
+
+	challenge, err := h.service.CreateChallenge(r.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("Failed to create challenge: %v", err)
+		h.sendChallengeErrorResponse(w, err, "Failed to create challenge")
+		return
+	}
+
+	h.sendSuccessResponse(w, challenge, http.StatusCreated)
+}
+
+// GetChallenge handles GET /api/v1/challenges/:id
+func (h *ChallengeHandler) GetChallenge(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	challenge, err := h.service.GetChallenge(r.Context(), challengeID, viewerID, middleware.IsAdminFromContext(r.Context()))
+	if err != nil {
+		h.logger.Errorf("Failed to get challenge: %v", err)
+		h.sendChallengeErrorResponse(w, err, "Failed to retrieve challenge")
+		return
+	}
+
+	h.sendSuccessResponse(w, challenge, http.StatusOK)
+}
+
+// AcceptChallenge handles POST /api/v1/challenges/:id/accept
+func (h *ChallengeHandler) AcceptChallenge(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.AcceptChallenge, "Failed to accept challenge")
+}
+
+// DeclineChallenge handles POST /api/v1/challenges/:id/decline
+func (h *ChallengeHandler) DeclineChallenge(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.DeclineChallenge, "Failed to decline challenge")
+}
+
+// CancelChallenge handles POST /api/v1/challenges/:id/cancel
+func (h *ChallengeHandler) CancelChallenge(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.CancelChallenge, "Failed to cancel challenge")
+}
+
+// GetChallengeLeaderboard handles GET /api/v1/challenges/:id/leaderboard
+func (h *ChallengeHandler) GetChallengeLeaderboard(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	leaderboard, err := h.service.GetChallengeLeaderboard(r.Context(), challengeID, viewerID, middleware.IsAdminFromContext(r.Context()))
+	if err != nil {
+		h.logger.Errorf("Failed to get challenge leaderboard: %v", err)
+		h.sendChallengeErrorResponse(w, err, "Failed to retrieve leaderboard")
+		return
+	}
+
+	h.sendSuccessResponse(w, leaderboard, http.StatusOK)
+}
+
+// Helper methods
+
+// respond runs one of the accept/decline/cancel actions for the current user
+func (h *ChallengeHandler) respond(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, id, userID string) (*models.Challenge, error), fallback string) {
+	challengeID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	challenge, err := action(r.Context(), challengeID, userID)
+	if err != nil {
+		h.logger.Errorf("%s: %v", fallback, err)
+		h.sendChallengeErrorResponse(w, err, fallback)
+		return
+	}
+
+	h.sendSuccessResponse(w, challenge, http.StatusOK)
+}
+
+// sendChallengeErrorResponse maps challenge service errors to status codes
+func (h *ChallengeHandler) sendChallengeErrorResponse(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrChallengeNotFound):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidChallenge):
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrInsufficientBalance):
+		h.sendErrorResponse(w, err.Error(), http.StatusPaymentRequired)
+	case errors.Is(err, services.ErrChallengeNotPending):
+		h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+	default:
+		h.sendErrorResponse(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *ChallengeHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.SuccessResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ChallengeHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
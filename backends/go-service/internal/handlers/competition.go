@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/yourusername/health-competition-go/internal/middleware"
 	"github.com/yourusername/health-competition-go/internal/models"
 	"github.com/yourusername/health-competition-go/internal/services"
 	"github.com/yourusername/health-competition-go/pkg/utils"
@@ -25,36 +29,106 @@ func NewCompetitionHandler(service *services.CompetitionService, logger *utils.L
 }
 
 // GetCompetitions handles GET /api/v1/competitions
+//
+// Query parameters: status, q (full-text search), type, min_entry_fee,
+// max_entry_fee, from, to (RFC 3339 or YYYY-MM-DD), creator_id, has_spots,
+// sort (created_at, start_date, prize_pool, participants), order (asc, desc),
+// limit and offset.
 func (h *CompetitionHandler) GetCompetitions(w http.ResponseWriter, r *http.Request) {
-	status := r.URL.Query().Get("status") // active, upcoming, completed, all
-	if status == "" {
-		status = "all"
+	filter, err := parseCompetitionFilter(r)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	competitions, err := h.service.GetCompetitions(r.Context(), filter)
+	if err != nil {
+		h.logger.Errorf("Failed to get competitions: %v", err)
+		if errors.Is(err, services.ErrInvalidFilter) {
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.sendErrorResponse(w, "Failed to retrieve competitions", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, competitions, http.StatusOK)
+}
+
+// parseCompetitionFilter reads the GET /competitions query parameters
+func parseCompetitionFilter(r *http.Request) (*models.CompetitionFilter, error) {
+	q := r.URL.Query()
+
+	filter := &models.CompetitionFilter{
+		Status:    q.Get("status"), // active, upcoming, completed, all
+		Query:     q.Get("q"),
+		Type:      q.Get("type"),
+		CreatorID: q.Get("creator_id"),
+		Sort:      q.Get("sort"),
+		Order:     q.Get("order"),
+		Limit:     50,
 	}
+	if filter.Status == "" {
+		filter.Status = "all"
+	}
+
+	// Anonymous viewers only see public competitions
+	filter.ViewerID, _ = r.Context().Value("user_id").(string)
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 50
-	if limitStr != "" {
+	if limitStr := q.Get("limit"); limitStr != "" {
 		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
+			filter.Limit = parsedLimit
 		}
 	}
 
-	offsetStr := r.URL.Query().Get("offset")
-	offset := 0
-	if offsetStr != "" {
+	if offsetStr := q.Get("offset"); offsetStr != "" {
 		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+			filter.Offset = parsedOffset
 		}
 	}
 
-	competitions, err := h.service.GetCompetitions(r.Context(), status, limit, offset)
-	if err != nil {
-		h.logger.Errorf("Failed to get competitions: %v", err)
-		h.sendErrorResponse(w, "Failed to retrieve competitions", http.StatusInternalServerError)
-		return
+	for param, dest := range map[string]**float64{
+		"min_entry_fee": &filter.MinEntryFee,
+		"max_entry_fee": &filter.MaxEntryFee,
+	} {
+		if v := q.Get(param); v != "" {
+			fee, err := strconv.ParseFloat(v, 64)
+			if err != nil || fee < 0 {
+				return nil, fmt.Errorf("%s must be a non-negative number", param)
+			}
+			*dest = &fee
+		}
 	}
 
-	h.sendSuccessResponse(w, competitions, http.StatusOK)
+	for param, dest := range map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	} {
+		if v := q.Get(param); v != "" {
+			t, err := parseFilterTime(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", param)
+			}
+			*dest = &t
+		}
+	}
+
+	if v := q.Get("has_spots"); v != "" {
+		hasSpots, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("has_spots must be true or false")
+		}
+		filter.HasSpots = hasSpots
+	}
+
+	return filter, nil
+}
+
+func parseFilterTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
 }
 
 // GetCompetition handles GET /api/v1/competitions/:id
@@ -62,7 +136,9 @@ func (h *CompetitionHandler) GetCompetition(w http.ResponseWriter, r *http.Reque
 	vars := mux.Vars(r)
 	competitionID := vars["id"]
 
-	competition, err := h.service.GetCompetitionByID(r.Context(), competitionID)
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	competition, err := h.service.GetCompetitionByID(r.Context(), competitionID, viewerID, middleware.IsAdminFromContext(r.Context()))
 	if err != nil {
 		h.logger.Errorf("Failed to get competition: %v", err)
 		h.sendErrorResponse(w, "Competition not found", http.StatusNotFound)
@@ -72,6 +148,80 @@ func (h *CompetitionHandler) GetCompetition(w http.ResponseWriter, r *http.Reque
 	h.sendSuccessResponse(w, competition, http.StatusOK)
 }
 
+// maxParticipantPageSize caps the limit accepted by GetParticipants
+const maxParticipantPageSize = 100
+
+// GetParticipants handles GET /api/v1/competitions/:id/participants
+//
+// Query parameters: limit (default 50, max 100) and offset.
+func (h *CompetitionHandler) GetParticipants(w http.ResponseWriter, r *http.Request) {
+	competitionID := mux.Vars(r)["id"]
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	q := r.URL.Query()
+	limit, offset := 50, 0
+	if limitStr := q.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+	if limit > maxParticipantPageSize {
+		limit = maxParticipantPageSize
+	}
+	if offsetStr := q.Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	page, err := h.service.GetParticipants(r.Context(), competitionID, viewerID, middleware.IsAdminFromContext(r.Context()), limit, offset)
+	if err != nil {
+		h.logger.Errorf("Failed to get participants: %v", err)
+		if errors.Is(err, services.ErrCompetitionNotFound) {
+			h.sendErrorResponse(w, "Competition not found", http.StatusNotFound)
+			return
+		}
+		h.sendErrorResponse(w, "Failed to retrieve participants", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, page, http.StatusOK)
+}
+
+// GetEligibility handles GET /api/v1/competitions/:id/eligibility
+//
+// Reports whether the current user, or the participant given by user_id, can
+// currently win a prize.
+func (h *CompetitionHandler) GetEligibility(w http.ResponseWriter, r *http.Request) {
+	competitionID := mux.Vars(r)["id"]
+
+	viewerID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		userID = viewerID
+	}
+
+	status, err := h.service.GetEligibility(r.Context(), competitionID, userID, viewerID, middleware.IsAdminFromContext(r.Context()))
+	if err != nil {
+		h.logger.Errorf("Failed to get eligibility: %v", err)
+		switch {
+		case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrNotParticipant):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, services.ErrNotCompetitionOwner):
+			h.sendErrorResponse(w, err.Error(), http.StatusForbidden)
+		default:
+			h.sendErrorResponse(w, "Failed to retrieve eligibility", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, status, http.StatusOK)
+}
+
 // CreateCompetition handles POST /api/v1/competitions
 func (h *CompetitionHandler) CreateCompetition(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateCompetitionRequest
@@ -95,6 +245,12 @@ func (h *CompetitionHandler) CreateCompetition(w http.ResponseWriter, r *http.Re
 	req.CreatorID = userID
 
 	competition, err := h.service.CreateCompetition(r.Context(), &req)
+	if errors.Is(err, services.ErrInvalidCompetition) || errors.Is(err, services.ErrInvalidGoal) ||
+		errors.Is(err, services.ErrInvalidDivisions) || errors.Is(err, services.ErrInvalidTimeZone) ||
+		errors.Is(err, services.ErrInvalidEligibility) {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		h.logger.Errorf("Failed to create competition: %v", err)
 		h.sendErrorResponse(w, "Failed to create competition", http.StatusInternalServerError)
@@ -104,6 +260,67 @@ func (h *CompetitionHandler) CreateCompetition(w http.ResponseWriter, r *http.Re
 	h.sendSuccessResponse(w, competition, http.StatusCreated)
 }
 
+// UpdateCompetition handles PUT /api/v1/competitions/:id
+func (h *CompetitionHandler) UpdateCompetition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	competitionID := vars["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.UpdateCompetitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	competition, err := h.service.UpdateCompetition(r.Context(), competitionID, userID, middleware.IsAdminFromContext(r.Context()), &req)
+	if err != nil {
+		h.logger.Errorf("Failed to update competition: %v", err)
+		h.sendModifyErrorResponse(w, err, "Failed to update competition")
+		return
+	}
+
+	h.sendSuccessResponse(w, competition, http.StatusOK)
+}
+
+// CancelCompetition handles DELETE /api/v1/competitions/:id and
+// POST /api/v1/competitions/:id/cancel
+func (h *CompetitionHandler) CancelCompetition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	competitionID := vars["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CancelCompetitionRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.service.CancelCompetition(r.Context(), competitionID, userID, middleware.IsAdminFromContext(r.Context()), req.Reason)
+	if err != nil {
+		h.logger.Errorf("Failed to cancel competition: %v", err)
+		h.sendModifyErrorResponse(w, err, "Failed to cancel competition")
+		return
+	}
+
+	if result.FailedRefunds > 0 {
+		h.logger.Warnf("Competition %s cancelled with %d failed refunds", competitionID, result.FailedRefunds)
+	}
+
+	h.sendSuccessResponse(w, result, http.StatusOK)
+}
+
 // JoinCompetition handles POST /api/v1/competitions/:id/join
 func (h *CompetitionHandler) JoinCompetition(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -115,14 +332,143 @@ func (h *CompetitionHandler) JoinCompetition(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err := h.service.JoinCompetition(r.Context(), competitionID, userID)
+	result, err := h.service.JoinCompetition(r.Context(), competitionID, userID)
 	if err != nil {
 		h.logger.Errorf("Failed to join competition: %v", err)
-		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		h.sendJoinErrorResponse(w, err)
 		return
 	}
 
-	h.sendSuccessResponse(w, map[string]string{"message": "Successfully joined competition"}, http.StatusOK)
+	h.sendSuccessResponse(w, result, http.StatusOK)
+}
+
+// GetInvite handles GET /api/v1/invites/:code
+func (h *CompetitionHandler) GetInvite(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+
+	competition, err := h.service.GetCompetitionByInviteCode(r.Context(), code)
+	if err != nil {
+		h.logger.Errorf("Failed to get invite: %v", err)
+		h.sendJoinErrorResponse(w, err)
+		return
+	}
+
+	h.sendSuccessResponse(w, competition, http.StatusOK)
+}
+
+// JoinByInviteCode handles POST /api/v1/invites/:code/join
+func (h *CompetitionHandler) JoinByInviteCode(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := h.service.JoinCompetitionByCode(r.Context(), code, userID)
+	if err != nil {
+		h.logger.Errorf("Failed to join competition by invite: %v", err)
+		h.sendJoinErrorResponse(w, err)
+		return
+	}
+
+	h.sendSuccessResponse(w, result, http.StatusOK)
+}
+
+// CreateInvite handles POST /api/v1/competitions/:id/invites
+func (h *CompetitionHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	competitionID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateInviteRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	invite, err := h.service.CreateInvite(r.Context(), competitionID, userID, middleware.IsAdminFromContext(r.Context()), &req)
+	if err != nil {
+		h.logger.Errorf("Failed to create invite: %v", err)
+		h.sendModifyErrorResponse(w, err, "Failed to create invite")
+		return
+	}
+
+	h.sendSuccessResponse(w, invite, http.StatusCreated)
+}
+
+// GetInvites handles GET /api/v1/competitions/:id/invites
+func (h *CompetitionHandler) GetInvites(w http.ResponseWriter, r *http.Request) {
+	competitionID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	invites, err := h.service.GetInvites(r.Context(), competitionID, userID, middleware.IsAdminFromContext(r.Context()))
+	if err != nil {
+		h.logger.Errorf("Failed to get invites: %v", err)
+		h.sendModifyErrorResponse(w, err, "Failed to retrieve invites")
+		return
+	}
+
+	h.sendSuccessResponse(w, invites, http.StatusOK)
+}
+
+// RevokeInvite handles DELETE /api/v1/competitions/:id/invites/:inviteId
+func (h *CompetitionHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.RevokeInvite(r.Context(), vars["id"], vars["inviteId"], userID, middleware.IsAdminFromContext(r.Context())); err != nil {
+		h.logger.Errorf("Failed to revoke invite: %v", err)
+		h.sendModifyErrorResponse(w, err, "Failed to revoke invite")
+		return
+	}
+
+	h.sendSuccessResponse(w, map[string]string{"status": "revoked"}, http.StatusOK)
+}
+
+// LeaveCompetition handles POST /api/v1/competitions/:id/leave
+func (h *CompetitionHandler) LeaveCompetition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	competitionID := vars["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := h.service.LeaveCompetition(r.Context(), competitionID, userID)
+	if err != nil {
+		h.logger.Errorf("Failed to leave competition: %v", err)
+		switch {
+		case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrNotParticipant):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, services.ErrCompetitionClosed):
+			h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			h.sendErrorResponse(w, "Failed to leave competition", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, result, http.StatusOK)
 }
 
 // GetUserCompetitions handles GET /api/v1/users/:userId/competitions
@@ -146,6 +492,42 @@ func (h *CompetitionHandler) GetUserCompetitions(w http.ResponseWriter, r *http.
 }
 
 // Helper methods
+
+// sendJoinErrorResponse maps errors from joining a competition to status codes
+func (h *CompetitionHandler) sendJoinErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrInviteNotFound):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrAlreadyJoined):
+		h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrPaymentFailed):
+		h.sendErrorResponse(w, err.Error(), http.StatusPaymentRequired)
+	case errors.Is(err, services.ErrInviteRequired), errors.Is(err, services.ErrNotEligible):
+		h.sendErrorResponse(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInviteExpired), errors.Is(err, services.ErrInviteExhausted):
+		h.sendErrorResponse(w, err.Error(), http.StatusGone)
+	default:
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// sendModifyErrorResponse maps errors from update, cancel and invite
+// management to status codes
+func (h *CompetitionHandler) sendModifyErrorResponse(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrInviteNotFound):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrNotCompetitionOwner):
+		h.sendErrorResponse(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrCompetitionClosed):
+		h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidCompetitionUpdate), errors.Is(err, services.ErrInvalidInvite):
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.sendErrorResponse(w, fallback, http.StatusInternalServerError)
+	}
+}
+
 func (h *CompetitionHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
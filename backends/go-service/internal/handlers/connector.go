@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/connectors"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type ConnectorHandler struct {
+	service *services.ConnectorService
+	logger  *utils.Logger
+}
+
+func NewConnectorHandler(service *services.ConnectorService, logger *utils.Logger) *ConnectorHandler {
+	return &ConnectorHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetConnectors handles GET /api/v1/fitness/connectors
+func (h *ConnectorHandler) GetConnectors(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := h.service.GetConnectors(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Failed to get fitness connectors: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve fitness connectors", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, result, http.StatusOK)
+}
+
+// AuthorizeConnector handles POST /api/v1/fitness/connectors/:provider/authorize
+func (h *ConnectorHandler) AuthorizeConnector(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	authorization, err := h.service.AuthorizeConnector(r.Context(), userID, mux.Vars(r)["provider"])
+	if err != nil {
+		h.logger.Errorf("Failed to authorize fitness connector: %v", err)
+		h.sendConnectorErrorResponse(w, err, "Failed to start connecting the provider")
+		return
+	}
+
+	h.sendSuccessResponse(w, authorization, http.StatusOK)
+}
+
+// ConnectProvider handles POST /api/v1/fitness/connectors/:provider/connect
+func (h *ConnectorHandler) ConnectProvider(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ConnectProviderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" || req.State == "" {
+		h.sendErrorResponse(w, "Code and state are required", http.StatusBadRequest)
+		return
+	}
+
+	connection, err := h.service.ConnectProvider(r.Context(), userID, mux.Vars(r)["provider"], &req)
+	if err != nil {
+		h.logger.Errorf("Failed to connect fitness provider: %v", err)
+		h.sendConnectorErrorResponse(w, err, "Failed to connect the provider")
+		return
+	}
+
+	h.sendSuccessResponse(w, connection, http.StatusCreated)
+}
+
+// DisconnectProvider handles DELETE /api/v1/fitness/connectors/:provider
+func (h *ConnectorHandler) DisconnectProvider(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.DisconnectProvider(r.Context(), userID, mux.Vars(r)["provider"]); err != nil {
+		h.logger.Errorf("Failed to disconnect fitness provider: %v", err)
+		h.sendConnectorErrorResponse(w, err, "Failed to disconnect the provider")
+		return
+	}
+
+	h.sendSuccessResponse(w, map[string]string{"message": "Provider disconnected"}, http.StatusOK)
+}
+
+func (h *ConnectorHandler) sendConnectorErrorResponse(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUnknownConnector), errors.Is(err, services.ErrConnectionNotFound):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidOAuthState):
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, connectors.ErrUnauthorized):
+		h.sendErrorResponse(w, "The provider rejected the authorization code", http.StatusBadRequest)
+	default:
+		h.sendErrorResponse(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *ConnectorHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.SuccessResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ConnectorHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/health-competition-go/internal/middleware"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type DivisionHandler struct {
+	service *services.DivisionService
+	logger  *utils.Logger
+}
+
+func NewDivisionHandler(service *services.DivisionService, logger *utils.Logger) *DivisionHandler {
+	return &DivisionHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetDivisions handles GET /api/v1/competitions/:id/divisions
+func (h *DivisionHandler) GetDivisions(w http.ResponseWriter, r *http.Request) {
+	competitionID := mux.Vars(r)["id"]
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	divisions, err := h.service.GetDivisions(r.Context(), competitionID, viewerID, middleware.IsAdminFromContext(r.Context()))
+	if err != nil {
+		h.logger.Errorf("Failed to get divisions: %v", err)
+		h.sendDivisionErrorResponse(w, err, "Failed to retrieve divisions")
+		return
+	}
+
+	h.sendSuccessResponse(w, divisions, http.StatusOK)
+}
+
+// GetDivisionLeaderboard handles GET /api/v1/competitions/:id/divisions/:division/leaderboard
+func (h *DivisionHandler) GetDivisionLeaderboard(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	division, err := strconv.Atoi(vars["division"])
+	if err != nil {
+		h.sendErrorResponse(w, "Division must be a number", http.StatusBadRequest)
+		return
+	}
+
+	leaderboard, err := h.service.GetDivisionLeaderboard(r.Context(), vars["id"], division, viewerID, middleware.IsAdminFromContext(r.Context()))
+	if err != nil {
+		h.logger.Errorf("Failed to get division leaderboard: %v", err)
+		h.sendDivisionErrorResponse(w, err, "Failed to retrieve leaderboard")
+		return
+	}
+
+	h.sendSuccessResponse(w, leaderboard, http.StatusOK)
+}
+
+// GetDivisionHistory handles GET /api/v1/users/:userId/divisions
+func (h *DivisionHandler) GetDivisionHistory(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+
+	history, err := h.service.GetDivisionHistory(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Failed to get division history: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve division history", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, history, http.StatusOK)
+}
+
+// Helper methods
+
+// sendDivisionErrorResponse maps division service errors to status codes
+func (h *DivisionHandler) sendDivisionErrorResponse(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrDivisionNotFound),
+		errors.Is(err, services.ErrNoDivisions):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	default:
+		h.sendErrorResponse(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *DivisionHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.SuccessResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *DivisionHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/yourusername/health-competition-go/internal/models"
 	"github.com/yourusername/health-competition-go/internal/services"
@@ -23,17 +28,25 @@ func NewFitnessHandler(service *services.FitnessService, logger *utils.Logger) *
 	}
 }
 
-// SyncFitnessData handles POST /api/v1/fitness/sync
+// SyncFitnessData handles POST /api/v1/fitness/sync. The data is always the
+// caller's own; a user_id in the body is ignored.
 func (h *FitnessHandler) SyncFitnessData(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
 	var req models.FitnessSyncRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.UserID = userID
 
 	// Validate request
-	if req.UserID == "" || req.CompetitionID == "" {
-		h.sendErrorResponse(w, "User ID and Competition ID are required", http.StatusBadRequest)
+	if req.CompetitionID == "" {
+		h.sendErrorResponse(w, "Competition ID is required", http.StatusBadRequest)
 		return
 	}
 
@@ -41,14 +54,182 @@ func (h *FitnessHandler) SyncFitnessData(w http.ResponseWriter, r *http.Request)
 		req.Source = "google_fit"
 	}
 
-	err := h.service.SyncFitnessData(r.Context(), &req)
+	result, err := h.service.SyncFitnessData(r.Context(), &req)
 	if err != nil {
 		h.logger.Errorf("Failed to sync fitness data: %v", err)
-		h.sendErrorResponse(w, "Failed to sync fitness data", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, services.ErrInvalidSync):
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrNotParticipant):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		default:
+			h.sendErrorResponse(w, "Failed to sync fitness data", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// A retried sync ID succeeds without changing anything
+	h.sendSuccessResponse(w, result, http.StatusOK)
+}
+
+// BackfillFitnessData handles POST /api/v1/fitness/backfill. Like a sync, the
+// data is always the caller's own.
+func (h *FitnessHandler) BackfillFitnessData(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.FitnessBackfillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = userID
+
+	if req.CompetitionID == "" {
+		h.sendErrorResponse(w, "Competition ID is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.BackfillFitnessData(r.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("Failed to backfill fitness data: %v", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidBackfill):
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrNotParticipant):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		default:
+			h.sendErrorResponse(w, "Failed to backfill fitness data", http.StatusInternalServerError)
+		}
 		return
 	}
 
-	h.sendSuccessResponse(w, map[string]string{"message": "Fitness data synced successfully"}, http.StatusOK)
+	// Rejected records are reported per record rather than failing the request
+	h.sendSuccessResponse(w, result, http.StatusOK)
+}
+
+// maxActivityFileSize bounds uploaded activity files
+const maxActivityFileSize = 25 << 20
+
+// ImportActivity handles POST /api/v1/fitness/import, a multipart form with
+// the activity file in "file" and the competition in "competition_id"
+func (h *FitnessHandler) ImportActivity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxActivityFileSize+1<<20)
+	if err := r.ParseMultipartForm(maxActivityFileSize); err != nil {
+		h.sendErrorResponse(w, "File too large or invalid", http.StatusBadRequest)
+		return
+	}
+
+	competitionID := r.FormValue("competition_id")
+	if competitionID == "" {
+		h.sendErrorResponse(w, "Competition ID is required", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.sendErrorResponse(w, "No file uploaded", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		h.sendErrorResponse(w, "Failed to read uploaded file", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.ImportActivity(r.Context(), userID, competitionID, header.Filename, data)
+	if err != nil {
+		h.logger.Errorf("Failed to import activity: %v", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidImport):
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrDuplicateImport):
+			h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrNotParticipant):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		default:
+			h.sendErrorResponse(w, "Failed to import activity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, result, http.StatusCreated)
+}
+
+// maxHealthExportSize bounds uploaded Apple Health exports, which are spooled
+// to disk rather than held in memory
+const maxHealthExportSize = 2 << 30
+
+// healthImportTimeout replaces the server's read and write deadlines for an
+// Apple Health import, long enough to upload and import an export of up to
+// maxHealthExportSize
+const healthImportTimeout = 10 * time.Minute
+
+// ImportAppleHealth handles POST /api/v1/fitness/import/apple-health, a
+// multipart form with export.zip or export.xml in "file" and the competition
+// in "competition_id"
+func (h *FitnessHandler) ImportAppleHealth(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	deadline := time.Now().Add(healthImportTimeout)
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		h.logger.Warnf("Failed to extend the read deadline for an Apple Health import: %v", err)
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		h.logger.Warnf("Failed to extend the write deadline for an Apple Health import: %v", err)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxHealthExportSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		h.sendErrorResponse(w, "File too large or invalid", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	competitionID := r.FormValue("competition_id")
+	if competitionID == "" {
+		h.sendErrorResponse(w, "Competition ID is required", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.sendErrorResponse(w, "No file uploaded", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	result, err := h.service.ImportAppleHealth(r.Context(), userID, competitionID, file, header.Size)
+	if err != nil {
+		h.logger.Errorf("Failed to import Apple Health export: %v", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidImport):
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrCompetitionNotFound), errors.Is(err, services.ErrNotParticipant):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		default:
+			h.sendErrorResponse(w, "Failed to import Apple Health export", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, result, http.StatusOK)
 }
 
 // GetUserStats handles GET /api/v1/fitness/stats/:userId
@@ -72,6 +253,128 @@ func (h *FitnessHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
 	h.sendSuccessResponse(w, stats, http.StatusOK)
 }
 
+// GetMergeSettings handles GET /api/v1/fitness/sources/settings
+func (h *FitnessHandler) GetMergeSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	settings, err := h.service.GetMergeSettings(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Failed to get source merge settings: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve source merge settings", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, settings, http.StatusOK)
+}
+
+// UpdateMergeSettings handles PUT /api/v1/fitness/sources/settings
+func (h *FitnessHandler) UpdateMergeSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.SourceMergeSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetMergeSettings(r.Context(), userID, &req); err != nil {
+		h.logger.Errorf("Failed to update source merge settings: %v", err)
+		if errors.Is(err, services.ErrInvalidMergeSettings) {
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.sendErrorResponse(w, "Failed to update source merge settings", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, req, http.StatusOK)
+}
+
+// GetAnomalyQueue handles GET /api/v1/admin/fitness/anomalies
+func (h *FitnessHandler) GetAnomalyQueue(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	anomalies, err := h.service.GetAnomalies(r.Context(), status, r.URL.Query().Get("competition_id"), limit, offset)
+	if err != nil {
+		h.logger.Errorf("Failed to get anomaly queue: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve fitness anomalies", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, anomalies, http.StatusOK)
+}
+
+// ApproveAnomaly handles POST /api/v1/admin/fitness/anomalies/:id/approve
+func (h *FitnessHandler) ApproveAnomaly(w http.ResponseWriter, r *http.Request) {
+	h.reviewAnomaly(w, r, h.service.ApproveAnomaly)
+}
+
+// RejectAnomaly handles POST /api/v1/admin/fitness/anomalies/:id/reject
+func (h *FitnessHandler) RejectAnomaly(w http.ResponseWriter, r *http.Request) {
+	h.reviewAnomaly(w, r, h.service.RejectAnomaly)
+}
+
+// reviewAnomaly applies an admin decision to a fitness anomaly
+func (h *FitnessHandler) reviewAnomaly(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, anomalyID, adminID, note string) (*models.FitnessAnomaly, error)) {
+	vars := mux.Vars(r)
+	anomalyID := vars["id"]
+
+	adminID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReviewAnomalyRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	anomaly, err := decide(r.Context(), anomalyID, adminID, req.Note)
+	if err != nil {
+		h.logger.Errorf("Failed to review fitness anomaly %s: %v", anomalyID, err)
+		switch {
+		case errors.Is(err, services.ErrAnomalyNotFound):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, services.ErrAnomalyNotPending):
+			h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			h.sendErrorResponse(w, "Failed to review fitness anomaly", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, anomaly, http.StatusOK)
+}
+
 // Helper methods
 func (h *FitnessHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/yourusername/health-competition-go/internal/middleware"
 	"github.com/yourusername/health-competition-go/internal/models"
 	"github.com/yourusername/health-competition-go/internal/services"
 	"github.com/yourusername/health-competition-go/pkg/utils"
@@ -13,14 +16,18 @@ import (
 )
 
 type LeaderboardHandler struct {
-	service *services.LeaderboardService
-	logger  *utils.Logger
+	service      *services.LeaderboardService
+	competitions *services.CompetitionService
+	logger       *utils.Logger
 }
 
-func NewLeaderboardHandler(service *services.LeaderboardService, logger *utils.Logger) *LeaderboardHandler {
+// NewLeaderboardHandler creates a leaderboard handler. competitions is nil
+// without a database, when there are no private competitions to hide.
+func NewLeaderboardHandler(service *services.LeaderboardService, competitions *services.CompetitionService, logger *utils.Logger) *LeaderboardHandler {
 	return &LeaderboardHandler{
-		service: service,
-		logger:  logger,
+		service:      service,
+		competitions: competitions,
+		logger:       logger,
 	}
 }
 
@@ -29,6 +36,10 @@ func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Reque
 	vars := mux.Vars(r)
 	competitionID := vars["competitionId"]
 
+	if !h.checkVisible(w, r, competitionID) {
+		return
+	}
+
 	// Get limit from query params (default 100)
 	limitStr := r.URL.Query().Get("limit")
 	limit := 100
@@ -48,35 +59,15 @@ func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Reque
 	h.sendSuccessResponse(w, leaderboard, http.StatusOK)
 }
 
-// UpdateScore handles POST /api/v1/leaderboard/update
-func (h *LeaderboardHandler) UpdateScore(w http.ResponseWriter, r *http.Request) {
-	var req models.ScoreUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Validate request
-	if req.UserID == "" || req.CompetitionID == "" {
-		h.sendErrorResponse(w, "User ID and Competition ID are required", http.StatusBadRequest)
-		return
-	}
-
-	err := h.service.UpdateScore(r.Context(), &req)
-	if err != nil {
-		h.logger.Errorf("Failed to update score: %v", err)
-		h.sendErrorResponse(w, "Failed to update score", http.StatusInternalServerError)
-		return
-	}
-
-	h.sendSuccessResponse(w, map[string]string{"message": "Score updated successfully"}, http.StatusOK)
-}
-
 // CalculatePrizes handles POST /api/v1/prizes/calculate/:competitionId
 func (h *LeaderboardHandler) CalculatePrizes(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	competitionID := vars["competitionId"]
 
+	if !h.checkVisible(w, r, competitionID) {
+		return
+	}
+
 	// Get prize pool from request body
 	var reqBody struct {
 		PrizePool float64 `json:"prize_pool"`
@@ -119,6 +110,35 @@ func (h *LeaderboardHandler) DistributePrizes(w http.ResponseWriter, r *http.Req
 	h.sendSuccessResponse(w, response, http.StatusOK)
 }
 
+// checkVisible responds 404 unless the viewer may see the competition's
+// standings, and reports whether they may
+func (h *LeaderboardHandler) checkVisible(w http.ResponseWriter, r *http.Request, competitionID string) bool {
+	viewerID, _ := r.Context().Value("user_id").(string)
+	err := competitionVisible(r.Context(), h.competitions, competitionID, viewerID, middleware.IsAdminFromContext(r.Context()))
+	if errors.Is(err, services.ErrCompetitionNotFound) {
+		h.sendErrorResponse(w, "Competition not found", http.StatusNotFound)
+		return false
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to get competition: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve leaderboard", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// competitionVisible checks that a competition exists and that the viewer may
+// see it: private competitions are hidden from everyone but their
+// participants, their creator and admins. With no competition service there
+// are no private competitions.
+func competitionVisible(ctx context.Context, competitions *services.CompetitionService, competitionID, viewerID string, isAdmin bool) error {
+	if competitions == nil {
+		return nil
+	}
+	_, err := competitions.GetCompetitionByID(ctx, competitionID, viewerID, isAdmin)
+	return err
+}
+
 // Helper methods
 func (h *LeaderboardHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type NotificationHandler struct {
+	service *services.NotificationService
+	logger  *utils.Logger
+}
+
+func NewNotificationHandler(service *services.NotificationService, logger *utils.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetNotifications handles GET /api/v1/notifications
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	notifications, err := h.service.GetUserNotifications(r.Context(), userID, limit)
+	if err != nil {
+		h.logger.Errorf("Failed to get notifications: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve notifications", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, notifications, http.StatusOK)
+}
+
+// MarkRead handles POST /api/v1/notifications/:id/read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	notificationID := vars["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), userID, notificationID); err != nil {
+		h.logger.Errorf("Failed to mark notification read: %v", err)
+		h.sendErrorResponse(w, "Failed to update notification", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, map[string]string{"message": "Notification marked as read"}, http.StatusOK)
+}
+
+// Helper methods
+func (h *NotificationHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.SuccessResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *NotificationHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
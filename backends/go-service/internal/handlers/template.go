@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yourusername/health-competition-go/internal/middleware"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type TemplateHandler struct {
+	service *services.TemplateService
+	logger  *utils.Logger
+}
+
+func NewTemplateHandler(service *services.TemplateService, logger *utils.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetTemplates handles GET /api/v1/competition-templates
+func (h *TemplateHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	templates, err := h.service.GetTemplates(r.Context(), viewerID)
+	if err != nil {
+		h.logger.Errorf("Failed to get templates: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve templates", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, templates, http.StatusOK)
+}
+
+// GetTemplate handles GET /api/v1/competition-templates/:id
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID := mux.Vars(r)["id"]
+	viewerID, _ := r.Context().Value("user_id").(string)
+
+	template, err := h.service.GetTemplate(r.Context(), templateID, viewerID)
+	if err != nil {
+		h.logger.Errorf("Failed to get template: %v", err)
+		h.sendTemplateErrorResponse(w, err, "Failed to retrieve template")
+		return
+	}
+
+	h.sendSuccessResponse(w, template, http.StatusOK)
+}
+
+// CreateTemplate handles POST /api/v1/competition-templates
+func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.CreatorID = userID
+
+	template, err := h.service.CreateTemplate(r.Context(), &req)
+	if err != nil {
+		h.logger.Errorf("Failed to create template: %v", err)
+		h.sendTemplateErrorResponse(w, err, "Failed to create template")
+		return
+	}
+
+	h.sendSuccessResponse(w, template, http.StatusCreated)
+}
+
+// UpdateTemplate handles PUT /api/v1/competition-templates/:id
+func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.UpdateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(r.Context(), templateID, userID, middleware.IsAdminFromContext(r.Context()), &req)
+	if err != nil {
+		h.logger.Errorf("Failed to update template: %v", err)
+		h.sendTemplateErrorResponse(w, err, "Failed to update template")
+		return
+	}
+
+	h.sendSuccessResponse(w, template, http.StatusOK)
+}
+
+// Subscribe handles POST /api/v1/competition-templates/:id/subscription
+func (h *TemplateHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	templateID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Subscribe(r.Context(), templateID, userID); err != nil {
+		h.logger.Errorf("Failed to subscribe to template: %v", err)
+		h.sendTemplateErrorResponse(w, err, "Failed to subscribe to template")
+		return
+	}
+
+	h.sendSuccessResponse(w, map[string]string{"message": "You will be entered into future competitions"}, http.StatusOK)
+}
+
+// Unsubscribe handles DELETE /api/v1/competition-templates/:id/subscription
+func (h *TemplateHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	templateID := mux.Vars(r)["id"]
+
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Unsubscribe(r.Context(), templateID, userID); err != nil {
+		h.logger.Errorf("Failed to unsubscribe from template: %v", err)
+		h.sendTemplateErrorResponse(w, err, "Failed to unsubscribe from template")
+		return
+	}
+
+	h.sendSuccessResponse(w, map[string]string{"message": "You will no longer be entered automatically"}, http.StatusOK)
+}
+
+// Helper methods
+
+// sendTemplateErrorResponse maps template service errors to status codes
+func (h *TemplateHandler) sendTemplateErrorResponse(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrTemplateNotFound):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrNotCompetitionOwner):
+		h.sendErrorResponse(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidTemplate):
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.sendErrorResponse(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *TemplateHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.SuccessResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TemplateHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
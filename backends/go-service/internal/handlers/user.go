@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -72,6 +73,10 @@ func (h *UserHandler) UpdateUserProfile(w http.ResponseWriter, r *http.Request)
 	profile, err := h.service.UpdateUserProfile(r.Context(), userID, &req)
 	if err != nil {
 		h.logger.Errorf("Failed to update user profile: %v", err)
+		if errors.Is(err, services.ErrInvalidProfileVisibility) {
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.sendErrorResponse(w, "Failed to update profile", http.StatusInternalServerError)
 		return
 	}
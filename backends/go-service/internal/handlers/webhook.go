@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+	"github.com/yourusername/health-competition-go/pkg/webhooks"
+
+	"github.com/gorilla/mux"
+)
+
+// maxWebhookSize caps a vendor's push notification
+const maxWebhookSize = 1 << 20
+
+type WebhookHandler struct {
+	service *services.WebhookService
+	logger  *utils.Logger
+}
+
+func NewWebhookHandler(service *services.WebhookService, logger *utils.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ReceiveWebhook handles POST /webhooks/:vendor. Vendors authenticate with
+// the signature on the body rather than a user's JWT.
+func (h *WebhookHandler) ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+	if err != nil {
+		h.sendErrorResponse(w, "Webhook body is too large or unreadable", http.StatusBadRequest)
+		return
+	}
+
+	vendor := mux.Vars(r)["vendor"]
+	queued, err := h.service.Receive(r.Context(), vendor, r.Header, body)
+	if err != nil {
+		h.logger.Warnf("Rejected %s webhook: %v", vendor, err)
+		h.sendWebhookErrorResponse(w, err, "Failed to accept the webhook")
+		return
+	}
+
+	h.sendSuccessResponse(w, map[string]int{"queued": queued}, http.StatusAccepted)
+}
+
+// LinkWearable handles PUT /api/v1/admin/wearables/:vendor/:vendorUserId
+func (h *WebhookHandler) LinkWearable(w http.ResponseWriter, r *http.Request) {
+	var req models.LinkWearableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		h.sendErrorResponse(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	account, err := h.service.LinkWearable(r.Context(), vars["vendor"], vars["vendorUserId"], req.UserID)
+	if err != nil {
+		h.logger.Errorf("Failed to link wearable account: %v", err)
+		h.sendWebhookErrorResponse(w, err, "Failed to link the wearable account")
+		return
+	}
+
+	h.sendSuccessResponse(w, account, http.StatusOK)
+}
+
+// UnlinkWearable handles DELETE /api/v1/admin/wearables/:vendor/:vendorUserId
+func (h *WebhookHandler) UnlinkWearable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.service.UnlinkWearable(r.Context(), vars["vendor"], vars["vendorUserId"]); err != nil {
+		h.logger.Errorf("Failed to unlink wearable account: %v", err)
+		h.sendWebhookErrorResponse(w, err, "Failed to unlink the wearable account")
+		return
+	}
+
+	h.sendSuccessResponse(w, map[string]string{"message": "Wearable account unlinked"}, http.StatusOK)
+}
+
+func (h *WebhookHandler) sendWebhookErrorResponse(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUnknownWebhookVendor), errors.Is(err, services.ErrWearableNotLinked):
+		h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, webhooks.ErrInvalidSignature):
+		h.sendErrorResponse(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, webhooks.ErrInvalidPayload):
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.sendErrorResponse(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func (h *WebhookHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.SuccessResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WebhookHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
@@ -124,13 +125,17 @@ func (h *Hub) BroadcastToCompetition(competitionID string, message []byte) {
 type WebSocketHandler struct {
 	hub            *Hub
 	leaderboardSvc *services.LeaderboardService
+	competitions   *services.CompetitionService
 	logger         *utils.Logger
 }
 
-func NewWebSocketHandler(hub *Hub, leaderboardSvc *services.LeaderboardService, logger *utils.Logger) *WebSocketHandler {
+// NewWebSocketHandler creates the leaderboard WebSocket handler. competitions
+// is nil without a database, when there are no private competitions to hide.
+func NewWebSocketHandler(hub *Hub, leaderboardSvc *services.LeaderboardService, competitions *services.CompetitionService, logger *utils.Logger) *WebSocketHandler {
 	return &WebSocketHandler{
 		hub:            hub,
 		leaderboardSvc: leaderboardSvc,
+		competitions:   competitions,
 		logger:         logger,
 	}
 }
@@ -155,6 +160,17 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Private competitions only stream to participants and their creator
+	if err := competitionVisible(r.Context(), h.competitions, competitionID, userID, false); err != nil {
+		if errors.Is(err, services.ErrCompetitionNotFound) {
+			http.Error(w, "Competition not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Errorf("Failed to get competition: %v", err)
+		http.Error(w, "Failed to retrieve competition", http.StatusInternalServerError)
+		return
+	}
+
 	// Upgrade connection
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
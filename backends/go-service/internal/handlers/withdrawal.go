@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/internal/services"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type WithdrawalHandler struct {
+	service *services.WithdrawalService
+	logger  *utils.Logger
+}
+
+func NewWithdrawalHandler(service *services.WithdrawalService, logger *utils.Logger) *WithdrawalHandler {
+	return &WithdrawalHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RequestWithdrawal handles POST /api/v1/withdrawals
+func (h *WithdrawalHandler) RequestWithdrawal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateWithdrawalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	withdrawal, err := h.service.RequestWithdrawal(r.Context(), userID, &req)
+	if err != nil {
+		h.logger.Errorf("Failed to request withdrawal: %v", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidWithdrawal):
+			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrInsufficientBalance), errors.Is(err, services.ErrWithdrawalLimitExceeded):
+			h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			h.sendErrorResponse(w, "Failed to request withdrawal", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, withdrawal, http.StatusCreated)
+}
+
+// GetMyWithdrawals handles GET /api/v1/withdrawals
+func (h *WithdrawalHandler) GetMyWithdrawals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	withdrawals, err := h.service.GetUserWithdrawals(r.Context(), userID)
+	if err != nil {
+		h.logger.Errorf("Failed to get withdrawals: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve withdrawals", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, withdrawals, http.StatusOK)
+}
+
+// GetReviewQueue handles GET /api/v1/admin/withdrawals
+func (h *WithdrawalHandler) GetReviewQueue(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	withdrawals, err := h.service.GetWithdrawals(r.Context(), status, limit, offset)
+	if err != nil {
+		h.logger.Errorf("Failed to get withdrawal queue: %v", err)
+		h.sendErrorResponse(w, "Failed to retrieve withdrawals", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, withdrawals, http.StatusOK)
+}
+
+// ApproveWithdrawal handles POST /api/v1/admin/withdrawals/:id/approve
+func (h *WithdrawalHandler) ApproveWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.ApproveWithdrawal)
+}
+
+// RejectWithdrawal handles POST /api/v1/admin/withdrawals/:id/reject
+func (h *WithdrawalHandler) RejectWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.RejectWithdrawal)
+}
+
+// review applies an admin decision to a withdrawal
+func (h *WithdrawalHandler) review(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, withdrawalID, adminID, note string) (*models.Withdrawal, error)) {
+	vars := mux.Vars(r)
+	withdrawalID := vars["id"]
+
+	adminID, ok := r.Context().Value("user_id").(string)
+	if !ok {
+		h.sendErrorResponse(w, "User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReviewWithdrawalRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	withdrawal, err := decide(r.Context(), withdrawalID, adminID, req.Note)
+	if err != nil {
+		h.logger.Errorf("Failed to review withdrawal %s: %v", withdrawalID, err)
+		switch {
+		case errors.Is(err, services.ErrWithdrawalNotFound):
+			h.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, services.ErrWithdrawalNotPending):
+			h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, services.ErrPayoutFailed):
+			h.sendErrorResponse(w, err.Error(), http.StatusBadGateway)
+		default:
+			h.sendErrorResponse(w, "Failed to review withdrawal", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.sendSuccessResponse(w, withdrawal, http.StatusOK)
+}
+
+// Helper methods
+func (h *WithdrawalHandler) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.SuccessResponse{
+		Success: true,
+		Data:    data,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *WithdrawalHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+		Code:    statusCode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -11,7 +11,10 @@ import (
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey  contextKey = "userID"
+	IsAdminKey contextKey = "isAdmin"
+)
 
 // AuthMiddleware validates JWT tokens from Supabase
 func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
@@ -67,11 +70,40 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 			// Add user ID to context with multiple keys for compatibility
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
 			ctx = context.WithValue(ctx, "user_id", userID)
+			ctx = context.WithValue(ctx, IsAdminKey, isAdminClaims(claims))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// RequireAdmin rejects requests whose token does not carry the admin role.
+// It must run after AuthMiddleware.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAdminFromContext(r.Context()) {
+			http.Error(w, "Admin access required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsAdminFromContext reports whether the authenticated user is an admin
+func IsAdminFromContext(ctx context.Context) bool {
+	isAdmin, _ := ctx.Value(IsAdminKey).(bool)
+	return isAdmin
+}
+
+// isAdminClaims checks the Supabase app_metadata role set by the service role
+func isAdminClaims(claims jwt.MapClaims) bool {
+	appMetadata, ok := claims["app_metadata"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	role, _ := appMetadata["role"].(string)
+	return role == "admin"
+}
+
 // GetUserIDFromContext extracts the user ID from the request context
 func GetUserIDFromContext(ctx context.Context) (string, error) {
 	userID, ok := ctx.Value(UserIDKey).(string)
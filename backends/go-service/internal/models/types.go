@@ -10,18 +10,86 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Competition visibility levels
+const (
+	VisibilityPublic   = "public"   // listed for everyone
+	VisibilityUnlisted = "unlisted" // joinable by anyone with the ID, not listed
+	VisibilityPrivate  = "private"  // joinable only with an invite code
+)
+
+// Competition modes
+const (
+	ModeScore = "score" // highest score at the end date wins
+	ModeGoal  = "goal"  // first to reach the goal target wins
+)
+
+// CompetitionGoal defines the target of a goal-mode competition
+type CompetitionGoal struct {
+	Metric string  `json:"metric"` // steps, distance, calories
+	Target float64 `json:"target"`
+	// EndAfterFinishers ends the competition early once this many
+	// participants have reached the target
+	EndAfterFinishers *int `json:"end_after_finishers,omitempty"`
+}
+
+// CompetitionDivisions splits a competition into skill-based divisions, each
+// with its own standings and share of the prize pool. Division 1 is the top.
+// When the competition is an instance of a template, each instance is a
+// season and the promote/relegate counts move users between divisions for
+// the next one.
+type CompetitionDivisions struct {
+	Count         int `json:"count"`
+	PromoteCount  int `json:"promote_count"`  // top N of each division except the first move up
+	RelegateCount int `json:"relegate_count"` // bottom N of each division except the last move down
+}
+
 // Competition represents a fitness competition
 type Competition struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	EntryFee    float64   `json:"entry_fee"`
-	PrizePool   float64   `json:"prize_pool"`
-	StartDate   time.Time `json:"start_date"`
-	EndDate     time.Time `json:"end_date"`
-	Status      string    `json:"status"` // active, upcoming, completed
-	Type        string    `json:"type"`   // weekly, monthly
-	CreatedAt   time.Time `json:"created_at"`
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	EntryFee        float64   `json:"entry_fee"`
+	PrizePool       float64   `json:"prize_pool"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	Status          string    `json:"status"` // active, upcoming, completed, cancelled
+	Type            string    `json:"type"`   // weekly, monthly
+	CreatorID       string    `json:"creator_id,omitempty"`
+	Visibility      string    `json:"visibility"`                 // public, unlisted, private
+	MaxParticipants *int      `json:"max_participants,omitempty"` // nil means unlimited
+	TemplateID      string    `json:"template_id,omitempty"`      // set for recurring instances
+	CreatedAt       time.Time `json:"created_at"`
+
+	ParticipantCount int `json:"participant_count"`
+
+	Mode string           `json:"mode"` // score, goal
+	Goal *CompetitionGoal `json:"goal,omitempty"`
+
+	Divisions *CompetitionDivisions `json:"divisions,omitempty"`
+
+	// TimeZone is the IANA zone the competition's days are counted in.
+	// Start and end dates are returned in this zone.
+	TimeZone string `json:"time_zone"`
+
+	Eligibility *EligibilityRules `json:"eligibility,omitempty"`
+}
+
+// EligibilityRules restrict who can join a competition and who can win its
+// prizes. Empty lists allow anything.
+type EligibilityRules struct {
+	Sources       []string `json:"sources,omitempty"`         // fitness sources whose data counts, e.g. fitbit
+	Countries     []string `json:"countries,omitempty"`       // profile countries that may join
+	MinActiveDays int      `json:"min_active_days,omitempty"` // days with activity needed to win a prize
+}
+
+// EligibilityStatus reports whether a participant can currently win a prize
+type EligibilityStatus struct {
+	CompetitionID string   `json:"competition_id"`
+	UserID        string   `json:"user_id"`
+	Eligible      bool     `json:"eligible"`
+	Reasons       []string `json:"reasons,omitempty"`
+	ActiveDays    int      `json:"active_days"`
+	Sources       []string `json:"sources"` // sources the participant has synced data from
 }
 
 // LeaderboardEntry represents a single entry in the leaderboard
@@ -36,6 +104,16 @@ type LeaderboardEntry struct {
 	Calories       float64   `json:"calories"`
 	LastSyncedAt   time.Time `json:"last_synced_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Goal-mode competitions only
+	Progress    float64    `json:"progress,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// Why the participant cannot win a prize; empty when eligible
+	IneligibleReasons []string `json:"ineligible_reasons,omitempty"`
+
+	// Anomaly rules tripped by synced records still awaiting admin review
+	Flags []string `json:"flags,omitempty"`
 }
 
 // Leaderboard represents the full leaderboard for a competition
@@ -59,6 +137,32 @@ type FitnessData struct {
 	Date          time.Time `json:"date"`
 	SyncedAt      time.Time `json:"synced_at"`
 	CreatedAt     time.Time `json:"created_at"`
+
+	// On a day's merged record, the source each metric was taken from
+	MetricSources *MetricSources `json:"metric_sources,omitempty"`
+}
+
+// Source merge policies, deciding how records of several sources for the same
+// day become one
+const (
+	MergeMax       = "max"        // each metric's highest value across sources
+	MergePriority  = "priority"   // each metric from the most preferred source reporting it
+	MergePerMetric = "per_metric" // each metric from its chosen source, else by priority
+)
+
+// SourceMergeSettings is a user's choice of how their sources are merged
+type SourceMergeSettings struct {
+	Policy        string        `json:"policy"`
+	Priority      []string      `json:"priority,omitempty"` // most preferred first
+	MetricSources MetricSources `json:"metric_sources"`     // used by the per_metric policy
+}
+
+// MetricSources names a source per metric
+type MetricSources struct {
+	Steps         string `json:"steps,omitempty"`
+	Distance      string `json:"distance,omitempty"`
+	Calories      string `json:"calories,omitempty"`
+	ActiveMinutes string `json:"active_minutes,omitempty"`
 }
 
 // FitnessSyncRequest represents a request to sync fitness data
@@ -71,6 +175,181 @@ type FitnessSyncRequest struct {
 	ActiveMinutes int       `json:"active_minutes"`
 	Source        string    `json:"source"`
 	Date          time.Time `json:"date"`
+
+	// SyncID optionally identifies the sync; retrying with the same ID is a
+	// no-op
+	SyncID string `json:"sync_id,omitempty"`
+}
+
+// FitnessSyncResult reports what a sync changed. A sync sets the day's value
+// for its source, and Delta is how much that moved the user's totals once the
+// day's sources are merged.
+type FitnessSyncResult struct {
+	Applied bool         `json:"applied"` // false when the sync ID was already applied
+	Daily   *FitnessData `json:"daily,omitempty"`
+	Delta   FitnessDelta `json:"delta"`
+
+	// Quarantined syncs are held for admin review and not counted
+	Quarantined bool            `json:"quarantined,omitempty"`
+	Anomaly     *FitnessAnomaly `json:"anomaly,omitempty"`
+}
+
+// FitnessDelta is a change to synced totals
+type FitnessDelta struct {
+	Steps         int64   `json:"steps"`
+	Distance      float64 `json:"distance"`
+	Calories      float64 `json:"calories"`
+	ActiveMinutes int     `json:"active_minutes"`
+}
+
+// FitnessBackfillRequest syncs many days and sources of historical data for
+// a user in a competition at once
+type FitnessBackfillRequest struct {
+	UserID        string                  `json:"user_id"`
+	CompetitionID string                  `json:"competition_id"`
+	Records       []FitnessBackfillRecord `json:"records"`
+}
+
+// FitnessBackfillRecord is one day's value for one source, applied like a
+// FitnessSyncRequest
+type FitnessBackfillRecord struct {
+	Steps         int64     `json:"steps"`
+	Distance      float64   `json:"distance"`
+	Calories      float64   `json:"calories"`
+	ActiveMinutes int       `json:"active_minutes"`
+	Source        string    `json:"source"`
+	Date          time.Time `json:"date"`
+	SyncID        string    `json:"sync_id,omitempty"`
+}
+
+// Backfill record statuses
+const (
+	BackfillAccepted    = "accepted"
+	BackfillDuplicate   = "duplicate" // the record's sync ID was already applied
+	BackfillRejected    = "rejected"
+	BackfillQuarantined = "quarantined" // held for admin review as an anomaly
+)
+
+// FitnessBackfillResult reports what happened to each record of a backfill
+// and the user's totals afterwards
+type FitnessBackfillResult struct {
+	Accepted    int                     `json:"accepted"`
+	Rejected    int                     `json:"rejected"`
+	Quarantined int                     `json:"quarantined"`
+	Records     []FitnessBackfillStatus `json:"records"`
+	Totals      *FitnessData            `json:"totals"`
+}
+
+// FitnessBackfillStatus is the outcome of the backfill record at Index
+type FitnessBackfillStatus struct {
+	Index   int             `json:"index"`
+	Status  string          `json:"status"`
+	Error   string          `json:"error,omitempty"`
+	Delta   *FitnessDelta   `json:"delta,omitempty"`
+	Anomaly *FitnessAnomaly `json:"anomaly,omitempty"`
+}
+
+// ActivityImportResult reports a workout imported from an activity file: its
+// activity log entry and how adding it to the day's imported workouts synced
+type ActivityImportResult struct {
+	Activity *ActivityLog       `json:"activity"`
+	Sync     *FitnessSyncResult `json:"sync"`
+}
+
+// AppleHealthImportResult reports the days synced from an Apple Health export
+// and the user's totals afterwards
+type AppleHealthImportResult struct {
+	Imported    int          `json:"imported"`
+	Skipped     int          `json:"skipped"` // outside the competition or in the future
+	Quarantined int          `json:"quarantined"`
+	Totals      *FitnessData `json:"totals"`
+}
+
+// Fitness connection statuses
+const (
+	ConnectionActive      = "active"
+	ConnectionReauthorize = "reauthorize" // the provider rejected the stored tokens
+)
+
+// FitnessConnection links a user to a fitness provider that their daily
+// totals are pulled from. The OAuth tokens are never exposed.
+type FitnessConnection struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"user_id"`
+	Provider     string     `json:"provider"`
+	Status       string     `json:"status"`
+	Scope        string     `json:"scope,omitempty"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// FitnessConnectors lists the providers that can be connected and the user's
+// connections
+type FitnessConnectors struct {
+	Available   []string            `json:"available"`
+	Connections []FitnessConnection `json:"connections"`
+}
+
+// ConnectorAuthorization is where to send a user to grant a provider access
+type ConnectorAuthorization struct {
+	Provider string `json:"provider"`
+	URL      string `json:"url"`
+}
+
+// ConnectProviderRequest completes a connection with what the provider
+// redirected back with
+type ConnectProviderRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+// WearableAccount maps the user ID a wearable vendor pushes webhooks for to
+// one of our users
+type WearableAccount struct {
+	Vendor       string    `json:"vendor"`
+	VendorUserID string    `json:"vendor_user_id"`
+	UserID       string    `json:"user_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LinkWearableRequest names the user a vendor user's webhooks belong to
+type LinkWearableRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// Anomaly actions, from least to most severe
+const (
+	AnomalyFlag       = "flag"       // counted as synced
+	AnomalyClamp      = "clamp"      // counted with values cut to the daily caps
+	AnomalyQuarantine = "quarantine" // not counted unless an admin approves it
+)
+
+// FitnessAnomaly is a synced record that tripped anti-cheat rules. The values
+// are the ones submitted, before any clamping.
+type FitnessAnomaly struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	CompetitionID string     `json:"competition_id"`
+	Date          time.Time  `json:"date"`
+	Source        string     `json:"source"`
+	Steps         int64      `json:"steps"`
+	Distance      float64    `json:"distance"`
+	Calories      float64    `json:"calories"`
+	ActiveMinutes int        `json:"active_minutes"`
+	Rules         []string   `json:"rules"`   // daily_cap, stride_length, baseline_spike, cadence
+	Details       []string   `json:"details"` // one per rule
+	Action        string     `json:"action"`
+	Status        string     `json:"status"` // pending, approved, rejected
+	ReviewedBy    string     `json:"reviewed_by,omitempty"`
+	ReviewNote    string     `json:"review_note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ReviewAnomalyRequest represents an admin decision on a fitness anomaly
+type ReviewAnomalyRequest struct {
+	Note string `json:"note,omitempty"`
 }
 
 // ScoreUpdateRequest represents a request to update a user's score
@@ -88,6 +367,7 @@ type Prize struct {
 	CompetitionID string    `json:"competition_id"`
 	UserID        string    `json:"user_id"`
 	Rank          int       `json:"rank"`
+	Division      int       `json:"division,omitempty"` // set when prizes are awarded per division
 	Amount        float64   `json:"amount"`
 	Status        string    `json:"status"` // pending, distributed, failed
 	DistributedAt *time.Time `json:"distributed_at,omitempty"`
@@ -124,14 +404,27 @@ type SuccessResponse struct {
 
 // CreateCompetitionRequest represents a request to create a new competition
 type CreateCompetitionRequest struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	EntryFee    float64   `json:"entry_fee"`
-	PrizePool   float64   `json:"prize_pool"`
-	StartDate   time.Time `json:"start_date"`
-	EndDate     time.Time `json:"end_date"`
-	Type        string    `json:"type"`
-	CreatorID   string    `json:"creator_id,omitempty"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	EntryFee        float64   `json:"entry_fee"`
+	PrizePool       float64   `json:"prize_pool"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	Type            string    `json:"type"`
+	CreatorID       string    `json:"creator_id,omitempty"`
+	Visibility      string    `json:"visibility,omitempty"` // defaults to public
+	MaxParticipants *int      `json:"max_participants,omitempty"`
+
+	Mode string           `json:"mode,omitempty"` // defaults to score
+	Goal *CompetitionGoal `json:"goal,omitempty"`
+
+	Divisions *CompetitionDivisions `json:"divisions,omitempty"`
+
+	// TimeZone is the IANA zone for the competition, UTC when empty. The wall
+	// clock of StartDate and EndDate is read in this zone.
+	TimeZone string `json:"time_zone,omitempty"`
+
+	Eligibility *EligibilityRules `json:"eligibility,omitempty"`
 }
 
 // UserCompetition represents a user's participation in a competition
@@ -176,6 +469,10 @@ type ActivityLog struct {
 	Distance    float64   `json:"distance"`
 	Duration    int       `json:"duration"` // in minutes
 	CreatedAt   time.Time `json:"created_at"`
+
+	// Set for workouts imported from activity files
+	CompetitionID string     `json:"competition_id,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
 }
 
 // UserProfile represents user profile information
@@ -193,14 +490,24 @@ type UserProfile struct {
 	TotalPrizes     float64   `json:"total_prizes"`
 	JoinedAt        time.Time `json:"joined_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	ProfileVisibility string `json:"profile_visibility"` // public, private
 }
 
+// Profile visibility levels
+const (
+	ProfilePublic  = "public"  // name, avatar and country are shown to other users
+	ProfilePrivate = "private" // other users only see an anonymous placeholder
+)
+
 // UpdateProfileRequest represents a request to update user profile
 type UpdateProfileRequest struct {
 	Name    string `json:"name,omitempty"`
 	Avatar  string `json:"avatar,omitempty"`
 	Bio     string `json:"bio,omitempty"`
 	Country string `json:"country,omitempty"`
+
+	ProfileVisibility string `json:"profile_visibility,omitempty"`
 }
 
 // Transaction represents a financial transaction
@@ -217,3 +524,316 @@ type Transaction struct {
 	CreatedAt       time.Time `json:"created_at"`
 	CompletedAt     *time.Time `json:"completed_at,omitempty"`
 }
+
+// LedgerEntry represents a single credit or debit on a user's balance
+type LedgerEntry struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	Amount      float64   `json:"amount"` // positive = credit, negative = debit
+	Type        string    `json:"type"`   // prize, refund, withdrawal_hold, withdrawal_release
+	ReferenceID string    `json:"reference_id,omitempty"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Withdrawal represents a user's request to cash out their balance
+type Withdrawal struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	Amount          float64    `json:"amount"`
+	DestinationType string     `json:"destination_type"` // bank_account, upi, paypal
+	Destination     string     `json:"destination"`
+	Status          string     `json:"status"` // pending, approved, rejected, paid, failed
+	TransactionID   string     `json:"transaction_id"`
+	ReviewedBy      string     `json:"reviewed_by,omitempty"`
+	ReviewNote      string     `json:"review_note,omitempty"`
+	PayoutRef       string     `json:"payout_ref,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// CreateWithdrawalRequest represents a request to withdraw funds
+type CreateWithdrawalRequest struct {
+	Amount          float64 `json:"amount"`
+	DestinationType string  `json:"destination_type"`
+	Destination     string  `json:"destination"`
+}
+
+// ReviewWithdrawalRequest represents an admin decision on a withdrawal
+type ReviewWithdrawalRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// WithdrawalLimits represents the withdrawal limits that apply to a user
+type WithdrawalLimits struct {
+	MinAmount    float64 `json:"min_amount"`
+	DailyLimit   float64 `json:"daily_limit"`
+	MonthlyLimit float64 `json:"monthly_limit"`
+}
+
+// JoinCompetitionResult represents the outcome of joining a competition
+type JoinCompetitionResult struct {
+	CompetitionID    string       `json:"competition_id"`
+	Status           string       `json:"status"` // joined, waitlisted
+	WaitlistPosition int          `json:"waitlist_position,omitempty"`
+	Transaction      *Transaction `json:"transaction,omitempty"`
+}
+
+// Notification represents an in-app notification for a user
+type Notification struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	Type          string     `json:"type"` // competition_started, competition_completed, etc.
+	Title         string     `json:"title"`
+	Message       string     `json:"message"`
+	CompetitionID string     `json:"competition_id,omitempty"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// UpdateCompetitionRequest represents a partial update to a competition.
+// Nil fields are left unchanged.
+type UpdateCompetitionRequest struct {
+	Name        *string    `json:"name,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	EntryFee    *float64   `json:"entry_fee,omitempty"`
+	PrizePool   *float64   `json:"prize_pool,omitempty"`
+	StartDate   *time.Time `json:"start_date,omitempty"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	Type        *string    `json:"type,omitempty"`
+	Visibility  *string    `json:"visibility,omitempty"`
+	// MaxParticipants sets the capacity; 0 removes the limit
+	MaxParticipants *int `json:"max_participants,omitempty"`
+	// Mode and Goal can only change before the competition starts
+	Mode *string          `json:"mode,omitempty"`
+	Goal *CompetitionGoal `json:"goal,omitempty"`
+}
+
+// CancelCompetitionRequest represents a request to cancel a competition
+type CancelCompetitionRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// CancelCompetitionResult represents the outcome of cancelling a competition
+type CancelCompetitionResult struct {
+	Competition   *Competition  `json:"competition"`
+	Refunds       []Transaction `json:"refunds"`
+	FailedRefunds int           `json:"failed_refunds"`
+}
+
+// RefundPolicy controls how much of the entry fee is refunded when a
+// participant leaves a competition
+type RefundPolicy struct {
+	BeforeStartPercent float64 `json:"before_start_percent"`
+	AfterStartPercent  float64 `json:"after_start_percent"`
+}
+
+// LeaveCompetitionResult represents the outcome of leaving a competition
+type LeaveCompetitionResult struct {
+	CompetitionID   string       `json:"competition_id"`
+	LeftWaitlist    bool         `json:"left_waitlist,omitempty"`
+	RefundPercent   float64      `json:"refund_percent"`
+	Refund          *Transaction `json:"refund,omitempty"`
+	PromotedUserIDs []string     `json:"promoted_user_ids,omitempty"`
+}
+
+// CompetitionInvite is an invite code that lets users join a private
+// competition
+type CompetitionInvite struct {
+	ID            string     `json:"id"`
+	CompetitionID string     `json:"competition_id"`
+	Code          string     `json:"code"`
+	Link          string     `json:"link,omitempty"`
+	CreatedBy     string     `json:"created_by"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	MaxUses       *int       `json:"max_uses,omitempty"` // nil means unlimited
+	Uses          int        `json:"uses"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// CreateInviteRequest represents a request to create an invite code
+type CreateInviteRequest struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+}
+
+// CompetitionFilter narrows and orders the competitions listed by
+// GET /competitions. Nil and empty fields are not applied.
+type CompetitionFilter struct {
+	ViewerID    string     // used to include the viewer's private competitions
+	Status      string     // active, upcoming, completed, cancelled or all
+	Query       string     // full-text search on name and description
+	Type        string     // weekly, monthly
+	MinEntryFee *float64   // inclusive
+	MaxEntryFee *float64   // inclusive
+	From        *time.Time // competitions still running at or after From
+	To          *time.Time // competitions starting at or before To
+	CreatorID   string
+	HasSpots    bool   // only competitions that are not full
+	Sort        string // created_at, start_date, prize_pool, participants
+	Order       string // asc, desc
+	Limit       int
+	Offset      int
+}
+
+// RecurrenceRule describes how often a competition template repeats. Each
+// instance runs for one period and the next starts when it ends.
+type RecurrenceRule struct {
+	Frequency string `json:"frequency"` // daily, weekly, monthly
+	Interval  int    `json:"interval"`  // number of periods per instance, default 1
+}
+
+// CompetitionTemplate describes a recurring competition. The scheduler creates
+// each instance ahead of time, copying the template's settings.
+type CompetitionTemplate struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	Description      string         `json:"description"`
+	EntryFee         float64        `json:"entry_fee"`
+	PrizePool        float64        `json:"prize_pool"`
+	Visibility       string         `json:"visibility"`
+	MaxParticipants  *int           `json:"max_participants,omitempty"`
+	Recurrence       RecurrenceRule `json:"recurrence"`
+	FirstStartDate   time.Time      `json:"first_start_date"`
+	CreateAheadHours int            `json:"create_ahead_hours"` // how long before an instance ends the next is created
+	CreatorID        string         `json:"creator_id"`
+	Active           bool           `json:"active"`
+	Subscribed       bool           `json:"subscribed"` // whether the viewer is auto-enrolled
+	CreatedAt        time.Time      `json:"created_at"`
+
+	Mode string           `json:"mode"`
+	Goal *CompetitionGoal `json:"goal,omitempty"`
+
+	Divisions *CompetitionDivisions `json:"divisions,omitempty"`
+
+	TimeZone string `json:"time_zone"`
+
+	Eligibility *EligibilityRules `json:"eligibility,omitempty"`
+}
+
+// CreateTemplateRequest represents a request to create a competition template
+type CreateTemplateRequest struct {
+	Name             string         `json:"name"`
+	Description      string         `json:"description"`
+	EntryFee         float64        `json:"entry_fee"`
+	PrizePool        float64        `json:"prize_pool"`
+	Visibility       string         `json:"visibility,omitempty"`
+	MaxParticipants  *int           `json:"max_participants,omitempty"`
+	Recurrence       RecurrenceRule `json:"recurrence"`
+	FirstStartDate   time.Time      `json:"first_start_date"`
+	CreateAheadHours int            `json:"create_ahead_hours,omitempty"`
+	CreatorID        string         `json:"creator_id,omitempty"`
+
+	Mode string           `json:"mode,omitempty"`
+	Goal *CompetitionGoal `json:"goal,omitempty"`
+
+	Divisions *CompetitionDivisions `json:"divisions,omitempty"`
+
+	// TimeZone is the IANA zone for every instance, UTC when empty. The wall
+	// clock of FirstStartDate is read in this zone.
+	TimeZone string `json:"time_zone,omitempty"`
+
+	Eligibility *EligibilityRules `json:"eligibility,omitempty"`
+}
+
+// UpdateTemplateRequest represents a partial update to a competition
+// template. Changes apply to instances created afterwards.
+type UpdateTemplateRequest struct {
+	Name             *string  `json:"name,omitempty"`
+	Description      *string  `json:"description,omitempty"`
+	EntryFee         *float64 `json:"entry_fee,omitempty"`
+	PrizePool        *float64 `json:"prize_pool,omitempty"`
+	Visibility       *string  `json:"visibility,omitempty"`
+	MaxParticipants  *int     `json:"max_participants,omitempty"` // 0 removes the limit
+	CreateAheadHours *int     `json:"create_ahead_hours,omitempty"`
+	Active           *bool    `json:"active,omitempty"`
+}
+
+// Challenge statuses
+const (
+	ChallengePending   = "pending"   // waiting for the opponent to respond
+	ChallengeActive    = "active"    // accepted and running
+	ChallengeDeclined  = "declined"  // the opponent said no
+	ChallengeExpired   = "expired"   // the opponent did not respond in time
+	ChallengeCancelled = "cancelled" // withdrawn by the challenger before acceptance
+	ChallengeCompleted = "completed" // ended and resolved
+)
+
+// Challenge is a head-to-head contest between two users. Unlike a
+// competition it has exactly two participants, starts when the opponent
+// accepts and is never listed publicly.
+type Challenge struct {
+	ID              string     `json:"id"`
+	ChallengerID    string     `json:"challenger_id"`
+	OpponentID      string     `json:"opponent_id"`
+	Metric          string     `json:"metric"` // steps, distance, calories
+	DurationDays    int        `json:"duration_days"`
+	Stake           float64    `json:"stake"` // held from each side and paid to the winner
+	Message         string     `json:"message,omitempty"`
+	Status          string     `json:"status"`
+	ExpiresAt       time.Time  `json:"expires_at"` // deadline to accept
+	StartDate       *time.Time `json:"start_date,omitempty"`
+	EndDate         *time.Time `json:"end_date,omitempty"`
+	WinnerID        string     `json:"winner_id,omitempty"` // empty for a tie
+	ChallengerScore *float64   `json:"challenger_score,omitempty"`
+	OpponentScore   *float64   `json:"opponent_score,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// CreateChallengeRequest represents a request to challenge another user
+type CreateChallengeRequest struct {
+	OpponentID   string  `json:"opponent_id"`
+	Metric       string  `json:"metric,omitempty"` // default steps
+	DurationDays int     `json:"duration_days"`
+	Stake        float64 `json:"stake,omitempty"`
+	Message      string  `json:"message,omitempty"`
+	ChallengerID string  `json:"challenger_id,omitempty"`
+}
+
+// Division summarises one division of a competition
+type Division struct {
+	CompetitionID string  `json:"competition_id"`
+	Division      int     `json:"division"`
+	Participants  int     `json:"participants"`
+	PrizePool     float64 `json:"prize_pool"`
+}
+
+// DivisionHistoryEntry records where a user finished in a division and where
+// that placed them for the next season
+type DivisionHistoryEntry struct {
+	CompetitionID   string    `json:"competition_id"`
+	CompetitionName string    `json:"competition_name"`
+	TemplateID      string    `json:"template_id,omitempty"`
+	UserID          string    `json:"user_id"`
+	Division        int       `json:"division"`
+	Rank            int       `json:"rank"`
+	Movement        string    `json:"movement"` // promoted, relegated, stayed
+	NextDivision    int       `json:"next_division"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CompetitionParticipant is the public summary of someone who joined a
+// competition. Users with a private profile are anonymised for everyone but
+// themselves and admins.
+type CompetitionParticipant struct {
+	UserID   string    `json:"user_id"`
+	Name     string    `json:"name"`
+	Avatar   string    `json:"avatar,omitempty"`
+	Country  string    `json:"country,omitempty"`
+	Private  bool      `json:"private"`
+	JoinedAt time.Time `json:"joined_at"`
+	Rank     *int      `json:"rank,omitempty"` // nil until the user has synced activity
+	Division *int      `json:"division,omitempty"`
+}
+
+// ParticipantPage is one page of a competition's participants
+type ParticipantPage struct {
+	CompetitionID string                   `json:"competition_id"`
+	Participants  []CompetitionParticipant `json:"participants"`
+	TotalCount    int                      `json:"total_count"`
+	Limit         int                      `json:"limit"`
+	Offset        int                      `json:"offset"`
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/activityfile"
+)
+
+var (
+	ErrInvalidImport   = errors.New("invalid activity import")
+	ErrDuplicateImport = errors.New("activity file already imported")
+)
+
+// importSource is the fitness source that imported workouts are synced as
+const importSource = "file_import"
+
+// activityTypes maps the sports named by activity files to activity log types
+var activityTypes = map[string]string{
+	"running":  "run",
+	"run":      "run",
+	"biking":   "ride",
+	"cycling":  "ride",
+	"walking":  "walk",
+	"walk":     "walk",
+	"hiking":   "hike",
+	"swimming": "swim",
+}
+
+// ImportActivity imports a workout from a GPX, TCX or FIT file, picking the
+// format from filename. The workout is logged as an activity and counted on
+// the competition day it started: the day's value for the file_import source
+// becomes the sum of the workouts imported for it, synced like
+// SyncFitnessData. The workout has to lie within the competition and not in
+// the future, and the same file is only imported once per competition. Only
+// the competition's participants can import into it.
+func (s *FitnessService) ImportActivity(ctx context.Context, userID, competitionID, filename string, data []byte) (*models.ActivityImportResult, error) {
+	if s.db != nil {
+		if err := checkParticipant(ctx, s.db, competitionID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	format, err := activityfile.FormatOf(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	parsed, err := activityfile.Parse(format, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	loc, err := competitionLocation(ctx, s.cache, s.db, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	var window *competitionWindow
+	if s.db != nil {
+		if window, err = loadCompetitionWindow(ctx, s.db, competitionID, loc); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateImportTimes(parsed, loc, window, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	sum := sha256.Sum256(data)
+	externalID := hex.EncodeToString(sum[:])
+	started := parsed.StartTime
+	log := &models.ActivityLog{
+		UserID:        userID,
+		CompetitionID: competitionID,
+		Title:         activityTitle(parsed),
+		Type:          activityType(parsed.Sport),
+		Calories:      math.Round(parsed.Calories*100) / 100,
+		Distance:      math.Round(parsed.Distance*100) / 100,
+		Duration:      int(parsed.Duration.Round(time.Minute) / time.Minute),
+		StartedAt:     &started,
+	}
+	day := competitionDay(parsed.StartTime, loc)
+
+	var result *models.FitnessSyncResult
+	if s.db != nil {
+		err = withTx(ctx, s.db, func(tx *sql.Tx) error {
+			if err := insertImportedActivity(ctx, tx, log, day, externalID); err != nil {
+				return err
+			}
+			daily, err := loadImportedDay(ctx, tx, userID, competitionID, day)
+			if err != nil {
+				return err
+			}
+			result, err = applySync(ctx, tx, daily, "")
+			return err
+		})
+		if err == nil && !result.Quarantined {
+			err = s.refreshCache(ctx, userID, competitionID, day)
+		}
+	} else {
+		result, err = s.importToCache(ctx, log, day, externalID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.publishSync(ctx, userID, competitionID, result); err != nil {
+		return nil, err
+	}
+	return &models.ActivityImportResult{Activity: log, Sync: result}, nil
+}
+
+// validateImportTimes checks that an activity is over and lies within the
+// competition. window is nil when the competition's dates are unknown.
+func validateImportTimes(activity *activityfile.Activity, loc *time.Location, window *competitionWindow, now time.Time) error {
+	if activity.EndTime.After(now) {
+		return fmt.Errorf("activity ends in the future (%s)", activity.EndTime.Format(time.RFC3339))
+	}
+	if window == nil {
+		return nil
+	}
+	first, last := competitionDay(activity.StartTime, loc), competitionDay(activity.EndTime, loc)
+	if first.Before(window.first) || last.After(window.last) {
+		return fmt.Errorf("activity from %s to %s is outside the competition (%s to %s)",
+			activity.StartTime.In(loc).Format(time.RFC3339), activity.EndTime.In(loc).Format(time.RFC3339),
+			window.first.Format("2006-01-02"), window.last.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// insertImportedActivity stores an imported workout's activity log, filling
+// in its ID and creation time
+func insertImportedActivity(ctx context.Context, tx *sql.Tx, log *models.ActivityLog, day time.Time, externalID string) error {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO public.activity_logs (user_id, competition_id, title, type, steps, calories, distance, duration, started_at, activity_date, external_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (user_id, competition_id, external_id) DO NOTHING
+		RETURNING id, created_at
+	`, log.UserID, log.CompetitionID, log.Title, log.Type, log.Steps, log.Calories, log.Distance, log.Duration,
+		log.StartedAt, day.Format("2006-01-02"), externalID).Scan(&log.ID, &log.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrDuplicateImport
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert activity log: %w", err)
+	}
+	return nil
+}
+
+// loadImportedDay sums the workouts imported for a competition day into the
+// day's value for the file_import source
+func loadImportedDay(ctx context.Context, db dbExecutor, userID, competitionID string, day time.Time) (*models.FitnessData, error) {
+	data := newImportedDay(userID, competitionID, day)
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(steps), 0), COALESCE(SUM(distance), 0), COALESCE(SUM(calories), 0), COALESCE(SUM(duration), 0)
+		FROM public.activity_logs
+		WHERE user_id = $1 AND competition_id = $2 AND activity_date = $3 AND external_id IS NOT NULL
+	`, userID, competitionID, day.Format("2006-01-02")).Scan(&data.Steps, &data.Distance, &data.Calories, &data.ActiveMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum imported activities: %w", err)
+	}
+	return data, nil
+}
+
+// importToCache is ImportActivity for a service without a database. The
+// activity log isn't stored; the workout is added to the day's cached
+// file_import value.
+func (s *FitnessService) importToCache(ctx context.Context, log *models.ActivityLog, day time.Time, externalID string) (*models.FitnessSyncResult, error) {
+	fresh, err := s.cache.SetNX(ctx, s.getImportKey(log.UserID, log.CompetitionID, externalID), time.Now(), fitnessCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrDuplicateImport
+	}
+	log.ID = uuid.New().String()
+	log.CreatedAt = time.Now()
+
+	sources := map[string]models.FitnessData{}
+	err = s.cache.Get(ctx, s.getDaySourcesKey(log.UserID, log.CompetitionID, day), &sources)
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	data := newImportedDay(log.UserID, log.CompetitionID, day)
+	if previous, ok := sources[importSource]; ok {
+		data.Steps, data.Distance, data.Calories, data.ActiveMinutes =
+			previous.Steps, previous.Distance, previous.Calories, previous.ActiveMinutes
+	}
+	data.Steps += log.Steps
+	data.Distance += log.Distance
+	data.Calories += log.Calories
+	data.ActiveMinutes += log.Duration
+	return s.syncToCache(ctx, data, "")
+}
+
+func newImportedDay(userID, competitionID string, day time.Time) *models.FitnessData {
+	now := time.Now()
+	return &models.FitnessData{
+		ID:            fmt.Sprintf("%s-%s-%d", userID, competitionID, day.Unix()),
+		UserID:        userID,
+		CompetitionID: competitionID,
+		Source:        importSource,
+		Date:          day,
+		SyncedAt:      now,
+		CreatedAt:     now,
+	}
+}
+
+func activityType(sport string) string {
+	if t, ok := activityTypes[strings.ToLower(sport)]; ok {
+		return t
+	}
+	return "workout"
+}
+
+func activityTitle(activity *activityfile.Activity) string {
+	if name := strings.TrimSpace(activity.Name); name != "" {
+		if runes := []rune(name); len(runes) > 255 {
+			name = string(runes[:255])
+		}
+		return name
+	}
+	return "Imported " + activityType(activity.Sport)
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/pkg/activityfile"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readActivityFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "pkg", "activityfile", "testdata", name))
+	require.NoError(t, err)
+	return data
+}
+
+func TestFitnessService_ImportActivity(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	leaderboard := NewLeaderboardService(cache, client)
+	service := NewFitnessService(nil, cache, leaderboard, "")
+	ctx := context.Background()
+	run := readActivityFixture(t, "run.gpx")
+
+	result, err := service.ImportActivity(ctx, "user-1", "comp-1", "run.gpx", run)
+	require.NoError(t, err)
+	assert.Equal(t, "Morning Run", result.Activity.Title)
+	assert.Equal(t, "run", result.Activity.Type)
+	assert.Equal(t, 5, result.Activity.Duration)
+	assert.NotEmpty(t, result.Activity.ID)
+	require.True(t, result.Sync.Applied)
+	assert.Equal(t, importSource, result.Sync.Daily.Source)
+
+	_, err = service.ImportActivity(ctx, "user-1", "comp-1", "run.gpx", run)
+	assert.ErrorIs(t, err, ErrDuplicateImport)
+
+	// A second workout on the same day adds to the day's imported total
+	second := append(append([]byte(nil), run...), '\n')
+	result, err = service.ImportActivity(ctx, "user-1", "comp-1", "second.gpx", second)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Sync.Daily.ActiveMinutes)
+
+	_, err = service.ImportActivity(ctx, "user-1", "comp-1", "walk.fit", readActivityFixture(t, "walk.fit"))
+	require.NoError(t, err)
+
+	stats, err := service.GetUserStats(ctx, "user-1", "comp-1")
+	require.NoError(t, err)
+	assert.InDelta(t, 2*1111.95+3200.5, stats.Distance, 0.1)
+	assert.Equal(t, 180.0, stats.Calories)
+	assert.Equal(t, 10+38, stats.ActiveMinutes)
+
+	board, err := leaderboard.GetLeaderboard(ctx, "comp-1", 10)
+	require.NoError(t, err)
+	require.Len(t, board.Entries, 1)
+	assert.Equal(t, stats.Distance, board.Entries[0].Distance)
+
+	_, err = service.ImportActivity(ctx, "user-1", "comp-1", "notes.txt", []byte("ran 5k"))
+	assert.ErrorIs(t, err, ErrInvalidImport)
+}
+
+func TestFitnessService_ImportActivityRequiresParticipation(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "FROM public.competition_participants", rows: [][]driver.Value{{false}}},
+	)
+	cache := NewCacheService(client)
+	leaderboard := NewLeaderboardService(cache, client)
+	service := NewFitnessService(db, cache, leaderboard, "")
+	ctx := context.Background()
+
+	_, err := service.ImportActivity(ctx, "user-1", "comp-1", "run.gpx", readActivityFixture(t, "run.gpx"))
+	assert.ErrorIs(t, err, ErrNotParticipant)
+	assert.Equal(t, []driver.Value{"comp-1", "user-1"}, fake.args[0])
+
+	board, err := leaderboard.GetLeaderboard(ctx, "comp-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, board.Entries)
+}
+
+func TestValidateImportTimes(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	window := &competitionWindow{
+		first: time.Date(2024, 6, 1, 0, 0, 0, 0, loc),
+		last:  time.Date(2024, 6, 30, 0, 0, 0, 0, loc),
+	}
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	activity := func(start time.Time, d time.Duration) *activityfile.Activity {
+		return &activityfile.Activity{StartTime: start, EndTime: start.Add(d)}
+	}
+
+	assert.NoError(t, validateImportTimes(activity(time.Date(2024, 6, 10, 7, 0, 0, 0, loc), time.Hour), loc, window, now))
+	assert.Error(t, validateImportTimes(activity(time.Date(2024, 6, 15, 11, 30, 0, 0, time.UTC), time.Hour), loc, window, now), "still going")
+	assert.Error(t, validateImportTimes(activity(time.Date(2024, 5, 31, 23, 0, 0, 0, loc), 2*time.Hour), loc, window, now), "started before the competition")
+	// 2024-06-01T02:00Z is still May 31 in New York
+	assert.Error(t, validateImportTimes(activity(time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC), time.Hour), loc, window, now))
+	assert.NoError(t, validateImportTimes(activity(time.Date(2024, 5, 31, 23, 0, 0, 0, loc), 2*time.Hour), loc, nil, now))
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+var (
+	ErrAnomalyNotFound   = errors.New("fitness anomaly not found")
+	ErrAnomalyNotPending = errors.New("fitness anomaly is not pending review")
+)
+
+// Daily values no genuine record reaches. Records above them are clamped.
+const (
+	maxDailySteps         = 100000
+	maxDailyDistance      = 150000.0 // meters
+	maxDailyCalories      = 10000.0
+	maxDailyActiveMinutes = 24 * 60
+)
+
+// Plausible stride lengths in meters, checked once a record has enough steps
+// for the average to mean something
+const (
+	minStrideLength  = 0.3
+	maxStrideLength  = 2.5
+	strideCheckSteps = 2000
+)
+
+// maxCadence is the most steps per active minute anyone keeps up; sprinters
+// peak a little above 200
+const maxCadence = 250
+
+// A record spikes when its steps are spikeFactor times the user's average day
+// over the baselineDays before it, and at least spikeMinSteps. Users need
+// baselineMinDays of history for a baseline.
+const (
+	baselineDays    = 28
+	baselineMinDays = 7
+	spikeFactor     = 4
+	spikeMinSteps   = 20000
+)
+
+// anomalyFinding is one rule a record tripped and what to do about it
+type anomalyFinding struct {
+	rule   string
+	detail string
+	action string
+}
+
+// fitnessBaseline is a user's typical day before the one being synced
+type fitnessBaseline struct {
+	days     int
+	avgSteps float64
+}
+
+// anomalySeverity orders actions so the strictest finding wins
+var anomalySeverity = map[string]int{
+	models.AnomalyFlag:       1,
+	models.AnomalyClamp:      2,
+	models.AnomalyQuarantine: 3,
+}
+
+// detectAnomalies checks a record against the anti-cheat rules
+func detectAnomalies(data *models.FitnessData, baseline fitnessBaseline) []anomalyFinding {
+	var findings []anomalyFinding
+
+	var over []string
+	if data.Steps > maxDailySteps {
+		over = append(over, fmt.Sprintf("%d steps (max %d)", data.Steps, maxDailySteps))
+	}
+	if data.Distance > maxDailyDistance {
+		over = append(over, fmt.Sprintf("%.0f m (max %.0f)", data.Distance, maxDailyDistance))
+	}
+	if data.Calories > maxDailyCalories {
+		over = append(over, fmt.Sprintf("%.0f calories (max %.0f)", data.Calories, maxDailyCalories))
+	}
+	if data.ActiveMinutes > maxDailyActiveMinutes {
+		over = append(over, fmt.Sprintf("%d active minutes (max %d)", data.ActiveMinutes, maxDailyActiveMinutes))
+	}
+	for _, o := range over {
+		findings = append(findings, anomalyFinding{rule: "daily_cap", detail: o, action: models.AnomalyClamp})
+	}
+
+	if data.Steps >= strideCheckSteps && data.Distance > 0 {
+		stride := data.Distance / float64(data.Steps)
+		if stride < minStrideLength || stride > maxStrideLength {
+			findings = append(findings, anomalyFinding{
+				rule:   "stride_length",
+				detail: fmt.Sprintf("%.2f m per step from %d steps over %.0f m", stride, data.Steps, data.Distance),
+				action: models.AnomalyFlag,
+			})
+		}
+	}
+
+	if data.ActiveMinutes > 0 {
+		cadence := float64(data.Steps) / float64(data.ActiveMinutes)
+		if cadence > maxCadence {
+			findings = append(findings, anomalyFinding{
+				rule:   "cadence",
+				detail: fmt.Sprintf("%.0f steps per active minute (max %d)", cadence, maxCadence),
+				action: models.AnomalyQuarantine,
+			})
+		}
+	}
+
+	if baseline.days >= baselineMinDays && data.Steps >= spikeMinSteps &&
+		float64(data.Steps) > spikeFactor*baseline.avgSteps {
+		findings = append(findings, anomalyFinding{
+			rule:   "baseline_spike",
+			detail: fmt.Sprintf("%d steps against an average of %.0f over the last %d days", data.Steps, baseline.avgSteps, baseline.days),
+			action: models.AnomalyFlag,
+		})
+	}
+
+	return findings
+}
+
+// newFitnessAnomaly records the findings against data as submitted, with the
+// strictest of their actions
+func newFitnessAnomaly(data *models.FitnessData, findings []anomalyFinding) *models.FitnessAnomaly {
+	anomaly := &models.FitnessAnomaly{
+		UserID:        data.UserID,
+		CompetitionID: data.CompetitionID,
+		Date:          data.Date,
+		Source:        data.Source,
+		Steps:         data.Steps,
+		Distance:      data.Distance,
+		Calories:      data.Calories,
+		ActiveMinutes: data.ActiveMinutes,
+		Status:        "pending",
+		CreatedAt:     data.SyncedAt,
+	}
+	for _, f := range findings {
+		if !containsFold(anomaly.Rules, f.rule) {
+			anomaly.Rules = append(anomaly.Rules, f.rule)
+		}
+		anomaly.Details = append(anomaly.Details, f.detail)
+		if anomalySeverity[f.action] > anomalySeverity[anomaly.Action] {
+			anomaly.Action = f.action
+		}
+	}
+	return anomaly
+}
+
+// clampFitnessData cuts data down to the daily caps
+func clampFitnessData(data *models.FitnessData) {
+	if data.Steps > maxDailySteps {
+		data.Steps = maxDailySteps
+	}
+	data.Distance = math.Min(data.Distance, maxDailyDistance)
+	data.Calories = math.Min(data.Calories, maxDailyCalories)
+	if data.ActiveMinutes > maxDailyActiveMinutes {
+		data.ActiveMinutes = maxDailyActiveMinutes
+	}
+}
+
+// screenFitnessData checks data against the anti-cheat rules, clamping it if
+// called for. It returns nil when nothing was found.
+func screenFitnessData(data *models.FitnessData, baseline fitnessBaseline) *models.FitnessAnomaly {
+	findings := detectAnomalies(data, baseline)
+	if len(findings) == 0 {
+		return nil
+	}
+	anomaly := newFitnessAnomaly(data, findings)
+	if anomaly.Action == models.AnomalyClamp {
+		clampFitnessData(data)
+	}
+	return anomaly
+}
+
+// loadFitnessBaseline averages a user's merged daily steps over the
+// baselineDays before day. A day counts once even when it was synced to
+// several competitions.
+func loadFitnessBaseline(ctx context.Context, db dbExecutor, userID string, day time.Time) (fitnessBaseline, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(AVG(steps), 0)
+		FROM (
+			SELECT date, MAX(steps) AS steps
+			FROM public.fitness_daily
+			WHERE user_id = $1 AND date >= $2 AND date < $3
+			GROUP BY date
+		) per_day
+	`
+	var baseline fitnessBaseline
+	err := db.QueryRowContext(ctx, query, userID,
+		day.AddDate(0, 0, -baselineDays).Format("2006-01-02"), day.Format("2006-01-02"),
+	).Scan(&baseline.days, &baseline.avgSteps)
+	if err != nil {
+		return baseline, fmt.Errorf("failed to get fitness baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+const anomalyColumns = `id, user_id, competition_id, date, source, steps, distance, calories, active_minutes,
+	rules, details, action, status, reviewed_by, review_note, created_at, reviewed_at`
+
+func insertAnomaly(ctx context.Context, db dbExecutor, a *models.FitnessAnomaly) error {
+	query := `
+		INSERT INTO public.fitness_anomalies (user_id, competition_id, date, source, steps, distance, calories,
+			active_minutes, rules, details, action, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+	err := db.QueryRowContext(ctx, query,
+		a.UserID, a.CompetitionID, a.Date.Format("2006-01-02"), a.Source, a.Steps, a.Distance, a.Calories,
+		a.ActiveMinutes, pq.StringArray(a.Rules), pq.StringArray(a.Details), a.Action, a.Status, a.CreatedAt,
+	).Scan(&a.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record fitness anomaly: %w", err)
+	}
+	return nil
+}
+
+func scanAnomaly(row rowScanner) (*models.FitnessAnomaly, error) {
+	var a models.FitnessAnomaly
+	var rules, details pq.StringArray
+	var reviewedBy, reviewNote sql.NullString
+	if err := row.Scan(
+		&a.ID, &a.UserID, &a.CompetitionID, &a.Date, &a.Source, &a.Steps, &a.Distance, &a.Calories,
+		&a.ActiveMinutes, &rules, &details, &a.Action, &a.Status, &reviewedBy, &reviewNote,
+		&a.CreatedAt, &a.ReviewedAt,
+	); err != nil {
+		return nil, err
+	}
+	a.Rules = []string(rules)
+	a.Details = []string(details)
+	a.ReviewedBy = reviewedBy.String
+	a.ReviewNote = reviewNote.String
+	return &a, nil
+}
+
+// GetAnomalies retrieves anomalies by status for the admin review queue,
+// oldest first, optionally for one competition
+func (s *FitnessService) GetAnomalies(ctx context.Context, status, competitionID string, limit, offset int) ([]models.FitnessAnomaly, error) {
+	query := `SELECT ` + anomalyColumns + `
+		FROM public.fitness_anomalies
+		WHERE status = $1 AND ($2 = '' OR competition_id::text = $2)
+		ORDER BY created_at ASC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := s.db.QueryContext(ctx, query, status, competitionID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fitness anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	anomalies := []models.FitnessAnomaly{}
+	for rows.Next() {
+		a, err := scanAnomaly(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fitness anomaly: %w", err)
+		}
+		anomalies = append(anomalies, *a)
+	}
+	return anomalies, rows.Err()
+}
+
+// ApproveAnomaly accepts a record as genuine and counts it as submitted,
+// undoing any clamping or quarantine. A day and source synced again since the
+// record keep the newer value; the review is still recorded.
+func (s *FitnessService) ApproveAnomaly(ctx context.Context, anomalyID, adminID, note string) (*models.FitnessAnomaly, error) {
+	return s.reviewAnomaly(ctx, anomalyID, adminID, note, "approved")
+}
+
+// RejectAnomaly discards a record. A quarantined record was never counted;
+// otherwise the day's value for its source is cleared, unless it was synced
+// again since.
+func (s *FitnessService) RejectAnomaly(ctx context.Context, anomalyID, adminID, note string) (*models.FitnessAnomaly, error) {
+	return s.reviewAnomaly(ctx, anomalyID, adminID, note, "rejected")
+}
+
+func (s *FitnessService) reviewAnomaly(ctx context.Context, anomalyID, adminID, note, status string) (*models.FitnessAnomaly, error) {
+	var anomaly *models.FitnessAnomaly
+	var day time.Time
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		a, err := scanAnomaly(tx.QueryRowContext(ctx, `SELECT `+anomalyColumns+` FROM public.fitness_anomalies WHERE id = $1 FOR UPDATE`, anomalyID))
+		if err == sql.ErrNoRows {
+			return ErrAnomalyNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get fitness anomaly: %w", err)
+		}
+		if a.Status != "pending" {
+			return ErrAnomalyNotPending
+		}
+
+		// Dates come back as UTC midnight; the cache keys days in the
+		// competition's zone
+		loc, err := competitionLocation(ctx, s.cache, s.db, a.CompetitionID)
+		if err != nil {
+			return err
+		}
+		day = wallClockIn(a.Date, loc)
+
+		now := time.Now()
+		updateQuery := `
+			UPDATE public.fitness_anomalies
+			SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = $4
+			WHERE id = $5
+		`
+		if _, err := tx.ExecContext(ctx, updateQuery, status, adminID, note, now, anomalyID); err != nil {
+			return fmt.Errorf("failed to review fitness anomaly: %w", err)
+		}
+
+		superseded, err := anomalySuperseded(ctx, tx, a)
+		if err != nil {
+			return err
+		}
+
+		// The stored value keeps the record's sync time, so records synced
+		// after it still count as newer when they are reviewed
+		data := &models.FitnessData{
+			UserID:        a.UserID,
+			CompetitionID: a.CompetitionID,
+			Source:        a.Source,
+			Date:          day,
+			SyncedAt:      a.CreatedAt,
+		}
+		switch {
+		case superseded:
+			data = nil
+		case status == "approved":
+			data.Steps, data.Distance, data.Calories, data.ActiveMinutes = a.Steps, a.Distance, a.Calories, a.ActiveMinutes
+		case a.Action == models.AnomalyQuarantine:
+			data = nil
+		}
+		if data != nil {
+			if _, err := storeFitnessData(ctx, tx, data); err != nil {
+				return err
+			}
+		}
+
+		a.Status = status
+		a.ReviewedBy = adminID
+		a.ReviewNote = note
+		a.ReviewedAt = &now
+		anomaly = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Rejecting a quarantined record leaves nothing stored for a day that
+	// had no other syncs
+	if err := s.refreshCache(ctx, anomaly.UserID, anomaly.CompetitionID, day); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	totals, err := s.GetUserStats(ctx, anomaly.UserID, anomaly.CompetitionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.updateLeaderboard(ctx, totals); err != nil {
+		return nil, err
+	}
+	if err := s.refreshFlags(ctx, anomaly.UserID, anomaly.CompetitionID, nil); err != nil {
+		return nil, err
+	}
+	return anomaly, nil
+}
+
+// anomalySuperseded reports whether the anomaly's day and source were synced
+// again after the anomaly was raised
+func anomalySuperseded(ctx context.Context, tx *sql.Tx, a *models.FitnessAnomaly) (bool, error) {
+	var syncedAt time.Time
+	err := tx.QueryRowContext(ctx, `
+		SELECT synced_at FROM public.fitness_data
+		WHERE user_id = $1 AND competition_id = $2 AND date = $3 AND source = $4
+		FOR UPDATE
+	`, a.UserID, a.CompetitionID, a.Date.Format("2006-01-02"), a.Source).Scan(&syncedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get stored fitness data: %w", err)
+	}
+	return syncedAt.After(a.CreatedAt), nil
+}
+
+// refreshFlags updates the anomaly flags shown on a user's leaderboard entry.
+// With a database they are the rules of the user's pending anomalies;
+// without one, rules are added to those already shown.
+func (s *FitnessService) refreshFlags(ctx context.Context, userID, competitionID string, rules []string) error {
+	var flags []string
+	if s.db != nil {
+		query := `
+			SELECT DISTINCT UNNEST(rules)
+			FROM public.fitness_anomalies
+			WHERE user_id = $1 AND competition_id = $2 AND status = 'pending'
+		`
+		rows, err := s.db.QueryContext(ctx, query, userID, competitionID)
+		if err != nil {
+			return fmt.Errorf("failed to query anomaly flags: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var rule string
+			if err := rows.Scan(&rule); err != nil {
+				return fmt.Errorf("failed to scan anomaly flag: %w", err)
+			}
+			flags = append(flags, rule)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	} else {
+		current, err := s.leaderboard.GetFlags(ctx, competitionID)
+		if err != nil {
+			return err
+		}
+		flags = current[userID]
+		for _, rule := range rules {
+			if !containsFold(flags, rule) {
+				flags = append(flags, rule)
+			}
+		}
+	}
+
+	sort.Strings(flags)
+	return s.leaderboard.SetFlags(ctx, competitionID, userID, flags)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	rules := func(findings []anomalyFinding) []string {
+		var r []string
+		for _, f := range findings {
+			r = append(r, f.rule)
+		}
+		return r
+	}
+	active := fitnessBaseline{days: 14, avgSteps: 8000}
+
+	tests := []struct {
+		name     string
+		data     models.FitnessData
+		baseline fitnessBaseline
+		want     []string
+	}{
+		{"ordinary day", models.FitnessData{Steps: 9000, Distance: 6800, ActiveMinutes: 70}, active, nil},
+		{"over the caps", models.FitnessData{Steps: 2000000, Distance: 300000}, fitnessBaseline{}, []string{"daily_cap", "daily_cap", "stride_length"}},
+		{"distance without the steps for it", models.FitnessData{Steps: 5000, Distance: 40000}, active, []string{"stride_length"}},
+		{"a few steps aren't judged on stride", models.FitnessData{Steps: 500, Distance: 30000}, active, nil},
+		{"impossible cadence", models.FitnessData{Steps: 30000, Distance: 22000, ActiveMinutes: 60}, fitnessBaseline{}, []string{"cadence"}},
+		{"spike over the baseline", models.FitnessData{Steps: 40000, Distance: 30000, ActiveMinutes: 300}, active, []string{"baseline_spike"}},
+		{"no spike without enough history", models.FitnessData{Steps: 40000, Distance: 30000, ActiveMinutes: 300}, fitnessBaseline{days: 3, avgSteps: 2000}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules(detectAnomalies(&tt.data, tt.baseline)))
+		})
+	}
+}
+
+func TestScreenFitnessData(t *testing.T) {
+	t.Run("caps clamp the record but keep the submitted values", func(t *testing.T) {
+		data := &models.FitnessData{Steps: 150000, Distance: 110000, Calories: 4000, ActiveMinutes: 900}
+		anomaly := screenFitnessData(data, fitnessBaseline{})
+		require.NotNil(t, anomaly)
+		assert.Equal(t, models.AnomalyClamp, anomaly.Action)
+		assert.Equal(t, []string{"daily_cap"}, anomaly.Rules)
+		assert.Equal(t, int64(150000), anomaly.Steps)
+		assert.Equal(t, int64(maxDailySteps), data.Steps)
+	})
+
+	t.Run("the strictest action wins", func(t *testing.T) {
+		data := &models.FitnessData{Steps: 150000, Distance: 110000, ActiveMinutes: 60}
+		anomaly := screenFitnessData(data, fitnessBaseline{})
+		require.NotNil(t, anomaly)
+		assert.Equal(t, models.AnomalyQuarantine, anomaly.Action)
+		assert.Equal(t, []string{"daily_cap", "cadence"}, anomaly.Rules)
+		assert.Equal(t, int64(150000), data.Steps)
+	})
+
+	t.Run("clean records pass", func(t *testing.T) {
+		assert.Nil(t, screenFitnessData(&models.FitnessData{Steps: 9000, Distance: 6800}, fitnessBaseline{}))
+	})
+}
+
+func TestFitnessService_SyncAnomalies(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	leaderboard := NewLeaderboardService(cache, client)
+	service := NewFitnessService(nil, cache, leaderboard, "")
+	ctx := context.Background()
+	day := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	sync := func(steps int64, activeMinutes int) *models.FitnessSyncResult {
+		result, err := service.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+			UserID:        "user-1",
+			CompetitionID: "comp-1",
+			Steps:         steps,
+			ActiveMinutes: activeMinutes,
+			Source:        "fitbit",
+			Date:          day,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := sync(2000000, 0)
+	assert.False(t, result.Quarantined)
+	assert.Equal(t, int64(maxDailySteps), result.Daily.Steps)
+
+	result = sync(200000, 60)
+	assert.True(t, result.Quarantined)
+	assert.Equal(t, models.AnomalyQuarantine, result.Anomaly.Action)
+
+	stats, err := service.GetUserStats(ctx, "user-1", "comp-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(maxDailySteps), stats.Steps)
+
+	board, err := leaderboard.GetLeaderboard(ctx, "comp-1", 10)
+	require.NoError(t, err)
+	require.Len(t, board.Entries, 1)
+	assert.Equal(t, int64(maxDailySteps), board.Entries[0].Steps)
+	assert.Equal(t, []string{"cadence", "daily_cap"}, board.Entries[0].Flags)
+}
+
+func TestFitnessService_ReviewLeavesNewerSyncsAlone(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	raised := time.Date(2024, 6, 3, 18, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	anomaly := []driver.Value{
+		"anomaly-1", "user-1", "comp-1", day, "fitbit", int64(200000), 0.0, 0.0, int64(60),
+		"{cadence}", "{too many steps}", models.AnomalyQuarantine, "pending", nil, nil, raised, nil,
+	}
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "FROM public.fitness_anomalies WHERE id = $1 FOR UPDATE", rows: [][]driver.Value{anomaly}},
+		fakeQuery{match: "UPDATE public.fitness_anomalies", affected: 1},
+		// The user synced the day again after the anomaly was raised
+		fakeQuery{match: "SELECT synced_at FROM public.fitness_data", rows: [][]driver.Value{{raised.Add(time.Hour)}}},
+		fakeQuery{match: "FROM public.fitness_daily d", rows: [][]driver.Value{
+			{int64(9000), 7.0, 300.0, int64(50), "fitbit", "fitbit", "fitbit", "fitbit", raised.Add(time.Hour), raised},
+		}},
+		fakeQuery{match: "SUM(d.steps)", rows: [][]driver.Value{{int64(9000), 7.0, 300.0, int64(50)}}},
+		fakeQuery{match: "FROM public.challenges"},
+		fakeQuery{match: "UNNEST(rules)"},
+	)
+	cache := NewCacheService(client)
+	leaderboard := NewLeaderboardService(cache, client)
+	service := NewFitnessService(db, cache, leaderboard, "")
+	ctx := context.Background()
+	require.NoError(t, cacheCompetitionTimeZone(ctx, cache, "comp-1", "UTC"))
+
+	reviewed, err := service.ApproveAnomaly(ctx, "anomaly-1", "admin-1", "looks real")
+	require.NoError(t, err)
+	assert.Equal(t, "approved", reviewed.Status)
+	assert.Equal(t, []driver.Value{"user-1", "comp-1", "2024-06-03", "fitbit"}, fake.args[2])
+
+	// The newer sync still counts rather than the anomaly's values
+	board, err := leaderboard.GetLeaderboard(ctx, "comp-1", 10)
+	require.NoError(t, err)
+	require.Len(t, board.Entries, 1)
+	assert.Equal(t, int64(9000), board.Entries[0].Steps)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/applehealth"
+)
+
+// appleHealthSource is the fitness source Apple Health exports are synced as
+const appleHealthSource = "apple_health"
+
+// zipMagic starts every zip archive
+var zipMagic = []byte("PK\x03\x04")
+
+// ImportAppleHealth imports an Apple Health export.zip, or the export.xml in
+// it, of size bytes read from r. The export is streamed rather than loaded,
+// and each day's totals are synced through SyncFitnessData as the
+// apple_health source, so importing a newer export replaces the days an
+// older one set. Days outside the competition or in the future are skipped.
+func (s *FitnessService) ImportAppleHealth(ctx context.Context, userID, competitionID string, r io.ReaderAt, size int64) (*models.AppleHealthImportResult, error) {
+	loc, err := competitionLocation(ctx, s.cache, s.db, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	var window *competitionWindow
+	if s.db != nil {
+		// Checked before the export is read, not just by each day's sync
+		if err := checkParticipant(ctx, s.db, competitionID, userID); err != nil {
+			return nil, err
+		}
+		if window, err = loadCompetitionWindow(ctx, s.db, competitionID, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	magic := make([]byte, len(zipMagic))
+	if _, err := r.ReadAt(magic, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	var days []applehealth.Day
+	if bytes.Equal(magic, zipMagic) {
+		days, err = applehealth.ReadZip(r, size, loc)
+	} else {
+		days, err = applehealth.Read(io.NewSectionReader(r, 0, size), loc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	result := &models.AppleHealthImportResult{}
+	now := time.Now()
+	for _, day := range days {
+		if checkFitnessDay(day.Date, loc, window, now) != nil {
+			result.Skipped++
+			continue
+		}
+		synced, err := s.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+			UserID:        userID,
+			CompetitionID: competitionID,
+			Steps:         day.Steps,
+			Distance:      day.Distance,
+			Calories:      day.Calories,
+			ActiveMinutes: day.ActiveMinutes,
+			Source:        appleHealthSource,
+			Date:          day.Date,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if synced.Quarantined {
+			result.Quarantined++
+			continue
+		}
+		result.Imported++
+	}
+
+	result.Totals, err = s.GetUserStats(ctx, userID, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitnessService_ImportAppleHealth(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	leaderboard := NewLeaderboardService(cache, client)
+	service := NewFitnessService(nil, cache, leaderboard, "")
+	ctx := context.Background()
+
+	export, err := os.ReadFile(filepath.Join("..", "..", "pkg", "applehealth", "testdata", "export.xml"))
+	require.NoError(t, err)
+
+	result, err := service.ImportAppleHealth(ctx, "user-1", "comp-1", bytes.NewReader(export), int64(len(export)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, int64(15000), result.Totals.Steps)
+	assert.Equal(t, 70, result.Totals.ActiveMinutes)
+
+	// Importing the export again replaces the days rather than adding to them
+	result, err = service.ImportAppleHealth(ctx, "user-1", "comp-1", bytes.NewReader(export), int64(len(export)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(15000), result.Totals.Steps)
+
+	board, err := leaderboard.GetLeaderboard(ctx, "comp-1", 10)
+	require.NoError(t, err)
+	require.Len(t, board.Entries, 1)
+	assert.Equal(t, int64(15000), board.Entries[0].Steps)
+
+	future := `<HealthData><Record type="HKQuantityTypeIdentifierStepCount" sourceName="iPhone" unit="count" startDate="2099-01-01 10:00:00 +0000" value="100"/></HealthData>`
+	result, err = service.ImportAppleHealth(ctx, "user-1", "comp-1", strings.NewReader(future), int64(len(future)))
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Imported)
+	assert.Equal(t, 1, result.Skipped)
+
+	_, err = service.ImportAppleHealth(ctx, "user-1", "comp-1", strings.NewReader("PK\x03\x04 truncated"), 16)
+	assert.ErrorIs(t, err, ErrInvalidImport)
+}
+
+func TestFitnessService_ImportAppleHealthRequiresParticipation(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	db, _ := newFakeDB(t,
+		fakeQuery{match: "FROM public.competition_participants", rows: [][]driver.Value{{false}}},
+	)
+	cache := NewCacheService(client)
+	service := NewFitnessService(db, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+	require.NoError(t, cacheCompetitionTimeZone(ctx, cache, "comp-1", "UTC"))
+
+	export, err := os.ReadFile(filepath.Join("..", "..", "pkg", "applehealth", "testdata", "export.xml"))
+	require.NoError(t, err)
+
+	_, err = service.ImportAppleHealth(ctx, "user-1", "comp-1", bytes.NewReader(export), int64(len(export)))
+	assert.ErrorIs(t, err, ErrNotParticipant)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+// ErrInvalidBackfill is returned for a backfill that cannot be applied at all,
+// as opposed to one with individual records that are rejected
+var ErrInvalidBackfill = errors.New("invalid backfill")
+
+// maxBackfillRecords bounds how many records one backfill can carry
+const maxBackfillRecords = 1000
+
+// competitionWindow is the first and last day of a competition in its time
+// zone, as returned by competitionDay
+type competitionWindow struct {
+	first, last time.Time
+}
+
+// BackfillFitnessData applies historical data for many days and sources. Each
+// record is validated on its own and invalid ones are rejected; the rest are
+// applied like SyncFitnessData calls in a single transaction, so either all of
+// them are stored or, on error, none are. Without a database the records are
+// applied one by one. Records are screened for anomalies like single syncs.
+// The leaderboard is updated once, from the final totals. Only the
+// competition's participants can backfill it.
+func (s *FitnessService) BackfillFitnessData(ctx context.Context, req *models.FitnessBackfillRequest) (*models.FitnessBackfillResult, error) {
+	if len(req.Records) == 0 {
+		return nil, fmt.Errorf("%w: no records", ErrInvalidBackfill)
+	}
+	if len(req.Records) > maxBackfillRecords {
+		return nil, fmt.Errorf("%w: at most %d records can be backfilled at once", ErrInvalidBackfill, maxBackfillRecords)
+	}
+
+	loc, err := competitionLocation(ctx, s.cache, s.db, req.CompetitionID)
+	if err != nil {
+		return nil, err
+	}
+	var window *competitionWindow
+	if s.db != nil {
+		if err := checkParticipant(ctx, s.db, req.CompetitionID, req.UserID); err != nil {
+			return nil, err
+		}
+		if window, err = loadCompetitionWindow(ctx, s.db, req.CompetitionID, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &models.FitnessBackfillResult{Records: make([]models.FitnessBackfillStatus, len(req.Records))}
+	now := time.Now()
+	seen := map[string]int{}
+	var pending []int
+	data := make([]*models.FitnessData, len(req.Records))
+	for i, record := range req.Records {
+		result.Records[i].Index = i
+		day, err := validateBackfillRecord(record, loc, window, now)
+		if err == nil {
+			key := day.Format("2006-01-02") + "/" + record.Source
+			if j, ok := seen[key]; ok {
+				err = fmt.Errorf("same day and source as record %d", j)
+			} else {
+				seen[key] = i
+			}
+		}
+		if err != nil {
+			result.Records[i].Status = models.BackfillRejected
+			result.Records[i].Error = err.Error()
+			result.Rejected++
+			continue
+		}
+
+		data[i] = &models.FitnessData{
+			ID:            fmt.Sprintf("%s-%s-%d", req.UserID, req.CompetitionID, day.Unix()),
+			UserID:        req.UserID,
+			CompetitionID: req.CompetitionID,
+			Steps:         record.Steps,
+			Distance:      record.Distance,
+			Calories:      record.Calories,
+			ActiveMinutes: record.ActiveMinutes,
+			Source:        record.Source,
+			Date:          day,
+			SyncedAt:      now,
+			CreatedAt:     now,
+		}
+		pending = append(pending, i)
+	}
+
+	synced := make(map[int]*models.FitnessSyncResult, len(pending))
+	if s.db != nil {
+		err = withTx(ctx, s.db, func(tx *sql.Tx) error {
+			for _, i := range pending {
+				r, err := applySync(ctx, tx, data[i], req.Records[i].SyncID)
+				if err != nil {
+					return err
+				}
+				synced[i] = r
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var days []time.Time
+		touched := map[string]bool{}
+		for _, i := range pending {
+			key := data[i].Date.Format("2006-01-02")
+			if synced[i].Applied && !synced[i].Quarantined && !touched[key] {
+				touched[key] = true
+				days = append(days, data[i].Date)
+			}
+		}
+		if err := s.refreshCache(ctx, req.UserID, req.CompetitionID, days...); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, i := range pending {
+			r, err := s.syncToCache(ctx, data[i], req.Records[i].SyncID)
+			if err != nil {
+				return nil, err
+			}
+			synced[i] = r
+		}
+	}
+
+	var flagged []string
+	for _, i := range pending {
+		if !synced[i].Applied {
+			result.Records[i].Status = models.BackfillDuplicate
+			continue
+		}
+		if anomaly := synced[i].Anomaly; anomaly != nil {
+			result.Records[i].Anomaly = anomaly
+			flagged = append(flagged, anomaly.Rules...)
+		}
+		if synced[i].Quarantined {
+			result.Records[i].Status = models.BackfillQuarantined
+			result.Quarantined++
+			continue
+		}
+		delta := synced[i].Delta
+		result.Records[i].Status = models.BackfillAccepted
+		result.Records[i].Delta = &delta
+		result.Accepted++
+	}
+	if len(flagged) > 0 {
+		if err := s.refreshFlags(ctx, req.UserID, req.CompetitionID, flagged); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Totals, err = s.GetUserStats(ctx, req.UserID, req.CompetitionID)
+	if err != nil {
+		return nil, err
+	}
+	if result.Accepted > 0 {
+		if err := s.updateLeaderboard(ctx, result.Totals); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// validateBackfillRecord checks a record and returns the competition day it
+// is for. window is nil when the competition's dates are unknown.
+func validateBackfillRecord(record models.FitnessBackfillRecord, loc *time.Location, window *competitionWindow, now time.Time) (time.Time, error) {
+	if record.Source == "" {
+		return time.Time{}, errors.New("source is required")
+	}
+	if record.Date.IsZero() {
+		return time.Time{}, errors.New("date is required")
+	}
+	if record.Steps < 0 || record.Distance < 0 || record.Calories < 0 || record.ActiveMinutes < 0 {
+		return time.Time{}, errors.New("values cannot be negative")
+	}
+
+	day := competitionDay(record.Date, loc)
+	if err := checkFitnessDay(day, loc, window, now); err != nil {
+		return time.Time{}, err
+	}
+	return day, nil
+}
+
+// checkFitnessDay rejects a competition day that is in the future or outside
+// the competition. window is nil when the competition's dates are unknown.
+func checkFitnessDay(day time.Time, loc *time.Location, window *competitionWindow, now time.Time) error {
+	if day.After(competitionDay(now, loc)) {
+		return fmt.Errorf("%s is in the future", day.Format("2006-01-02"))
+	}
+	if window != nil && (day.Before(window.first) || day.After(window.last)) {
+		return fmt.Errorf("%s is outside the competition (%s to %s)",
+			day.Format("2006-01-02"), window.first.Format("2006-01-02"), window.last.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// loadCompetitionWindow returns the days a competition runs in loc
+func loadCompetitionWindow(ctx context.Context, db dbExecutor, competitionID string, loc *time.Location) (*competitionWindow, error) {
+	var start, end time.Time
+	err := db.QueryRowContext(ctx, `SELECT start_date, end_date FROM public.competitions WHERE id = $1`, competitionID).Scan(&start, &end)
+	if err == sql.ErrNoRows {
+		return nil, ErrCompetitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get competition dates: %w", err)
+	}
+	return &competitionWindow{first: competitionDay(start, loc), last: competitionDay(end, loc)}, nil
+}
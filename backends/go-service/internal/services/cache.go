@@ -36,6 +36,16 @@ func (s *CacheService) Get(ctx context.Context, key string, dest interface{}) er
 	return json.Unmarshal(data, dest)
 }
 
+// SetNX stores a value only if key does not exist yet, reporting whether it
+// was stored
+func (s *CacheService) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return s.client.SetNX(ctx, key, data, expiration).Result()
+}
+
 // Delete removes a key from cache
 func (s *CacheService) Delete(ctx context.Context, key string) error {
 	return s.client.Del(ctx, key).Err()
@@ -60,6 +70,11 @@ func (s *CacheService) ZAdd(ctx context.Context, key string, score float64, memb
 	}).Err()
 }
 
+// ZRem removes a member from a sorted set
+func (s *CacheService) ZRem(ctx context.Context, key, member string) error {
+	return s.client.ZRem(ctx, key, member).Err()
+}
+
 // ZRangeWithScores retrieves a range from sorted set with scores
 func (s *CacheService) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
 	return s.client.ZRangeWithScores(ctx, key, start, stop).Result()
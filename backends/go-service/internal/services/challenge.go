@@ -0,0 +1,678 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+var (
+	ErrChallengeNotFound   = errors.New("challenge not found")
+	ErrInvalidChallenge    = errors.New("invalid challenge")
+	ErrChallengeNotPending = errors.New("challenge is no longer awaiting a response")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// challengeAcceptWindow is how long the opponent has to respond
+const challengeAcceptWindow = 48 * time.Hour
+
+const maxChallengeDays = 30
+
+var challengeMetrics = map[string]bool{
+	"steps":    true,
+	"distance": true,
+	"calories": true,
+}
+
+const challengeColumns = `
+	id, challenger_id, opponent_id, metric, duration_days, stake, COALESCE(message, ''), status,
+	expires_at, start_date, end_date, COALESCE(winner_id::text, ''), challenger_score, opponent_score,
+	resolved_at, created_at
+`
+
+// ChallengeService runs one-on-one challenges. Each challenge has its own
+// two-entry leaderboard in LeaderboardService, keyed by challengeBoardID so it
+// never mixes with competition leaderboards. Scores come from the
+// participants' stored fitness data for the challenge's days, never from the
+// client. Stakes are held on the ledger while a challenge is open and paid out
+// or released when it closes.
+type ChallengeService struct {
+	db            *sql.DB
+	leaderboard   *LeaderboardService
+	notifications *NotificationService
+}
+
+func NewChallengeService(db *sql.DB, leaderboard *LeaderboardService, notifications *NotificationService) *ChallengeService {
+	return &ChallengeService{
+		db:            db,
+		leaderboard:   leaderboard,
+		notifications: notifications,
+	}
+}
+
+// CreateChallenge challenges another user, holding the challenger's stake
+// until the challenge is resolved or never starts
+func (s *ChallengeService) CreateChallenge(ctx context.Context, req *models.CreateChallengeRequest) (*models.Challenge, error) {
+	if req.Metric == "" {
+		req.Metric = "steps"
+	}
+	if err := validateChallenge(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	challenge := &models.Challenge{
+		ID:           uuid.New().String(),
+		ChallengerID: req.ChallengerID,
+		OpponentID:   req.OpponentID,
+		Metric:       req.Metric,
+		DurationDays: req.DurationDays,
+		Stake:        req.Stake,
+		Message:      strings.TrimSpace(req.Message),
+		Status:       models.ChallengePending,
+		ExpiresAt:    now.Add(challengeAcceptWindow),
+		CreatedAt:    now,
+	}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM public.users WHERE id = $1)`, req.OpponentID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up opponent: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: opponent not found", ErrInvalidChallenge)
+		}
+
+		if err := holdStake(ctx, tx, challenge.ChallengerID, challenge.Stake, challenge.ID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO public.challenges (id, challenger_id, opponent_id, metric, duration_days, stake, message, status, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10)
+		`
+		if _, err := tx.ExecContext(ctx, query,
+			challenge.ID, challenge.ChallengerID, challenge.OpponentID, challenge.Metric, challenge.DurationDays,
+			challenge.Stake, challenge.Message, challenge.Status, challenge.ExpiresAt, challenge.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to create challenge: %w", err)
+		}
+
+		return s.notifications.NotifyUser(ctx, tx, &models.Notification{
+			UserID:  challenge.OpponentID,
+			Type:    "challenge_received",
+			Title:   "You've been challenged",
+			Message: fmt.Sprintf("You have been challenged to a %d-day %s challenge%s.", challenge.DurationDays, challenge.Metric, stakeSuffix(challenge.Stake)),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// GetChallenge retrieves a challenge visible to one of its two users or an
+// admin
+func (s *ChallengeService) GetChallenge(ctx context.Context, id, viewerID string, isAdmin bool) (*models.Challenge, error) {
+	query := `SELECT ` + challengeColumns + ` FROM public.challenges WHERE id = $1`
+
+	challenge, err := scanChallenge(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	if !isAdmin && viewerID != challenge.ChallengerID && viewerID != challenge.OpponentID {
+		return nil, ErrChallengeNotFound
+	}
+
+	return challenge, nil
+}
+
+// GetUserChallenges lists challenges a user sent or received, newest first,
+// optionally narrowed to one status
+func (s *ChallengeService) GetUserChallenges(ctx context.Context, userID, status string) ([]models.Challenge, error) {
+	query := `SELECT ` + challengeColumns + `
+		FROM public.challenges
+		WHERE (challenger_id = $1 OR opponent_id = $1)
+	`
+	args := []interface{}{userID}
+	if status != "" && status != "all" {
+		query += ` AND status = $2`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query challenges: %w", err)
+	}
+	defer rows.Close()
+
+	challenges := []models.Challenge{}
+	for rows.Next() {
+		challenge, err := scanChallenge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan challenge: %w", err)
+		}
+		challenges = append(challenges, *challenge)
+	}
+
+	return challenges, nil
+}
+
+// AcceptChallenge starts a pending challenge on behalf of its opponent,
+// holding the opponent's stake
+func (s *ChallengeService) AcceptChallenge(ctx context.Context, id, userID string) (*models.Challenge, error) {
+	var challenge *models.Challenge
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		challenge, err = lockChallenge(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if err := checkChallengeResponse(challenge, userID, time.Now()); err != nil {
+			return err
+		}
+
+		if err := holdStake(ctx, tx, userID, challenge.Stake, challenge.ID); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		end := start.AddDate(0, 0, challenge.DurationDays)
+		query := `UPDATE public.challenges SET status = $1, start_date = $2, end_date = $3 WHERE id = $4`
+		if _, err := tx.ExecContext(ctx, query, models.ChallengeActive, start, end, challenge.ID); err != nil {
+			return fmt.Errorf("failed to accept challenge: %w", err)
+		}
+		challenge.Status = models.ChallengeActive
+		challenge.StartDate = &start
+		challenge.EndDate = &end
+
+		if err := s.leaderboard.SetMetric(ctx, challengeBoardID(challenge.ID), challenge.Metric); err != nil {
+			return fmt.Errorf("failed to set up challenge leaderboard: %w", err)
+		}
+		// The first day counts in full, so either side may already have data
+		for _, participant := range []string{challenge.ChallengerID, challenge.OpponentID} {
+			if _, err := refreshChallengeScore(ctx, tx, s.leaderboard, challenge, participant); err != nil {
+				return err
+			}
+		}
+
+		return s.notifications.NotifyUser(ctx, tx, &models.Notification{
+			UserID:  challenge.ChallengerID,
+			Type:    "challenge_accepted",
+			Title:   "Challenge accepted",
+			Message: fmt.Sprintf("Your %d-day %s challenge has started. Good luck!", challenge.DurationDays, challenge.Metric),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// DeclineChallenge turns down a pending challenge on behalf of its opponent
+func (s *ChallengeService) DeclineChallenge(ctx context.Context, id, userID string) (*models.Challenge, error) {
+	var challenge *models.Challenge
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		challenge, err = lockChallenge(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if err := checkChallengeResponse(challenge, userID, time.Now()); err != nil {
+			return err
+		}
+
+		if err := closePendingChallenge(ctx, tx, challenge, models.ChallengeDeclined); err != nil {
+			return err
+		}
+
+		return s.notifications.NotifyUser(ctx, tx, &models.Notification{
+			UserID:  challenge.ChallengerID,
+			Type:    "challenge_declined",
+			Title:   "Challenge declined",
+			Message: fmt.Sprintf("Your %s challenge was declined.%s", challenge.Metric, releasedSuffix(challenge.Stake)),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// CancelChallenge withdraws a challenge the opponent has not yet responded to
+func (s *ChallengeService) CancelChallenge(ctx context.Context, id, userID string) (*models.Challenge, error) {
+	var challenge *models.Challenge
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		challenge, err = lockChallenge(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if userID != challenge.ChallengerID {
+			if userID == challenge.OpponentID {
+				return fmt.Errorf("%w: only the challenger can cancel; decline it instead", ErrInvalidChallenge)
+			}
+			return ErrChallengeNotFound
+		}
+		if challenge.Status != models.ChallengePending {
+			return ErrChallengeNotPending
+		}
+
+		if err := closePendingChallenge(ctx, tx, challenge, models.ChallengeCancelled); err != nil {
+			return err
+		}
+
+		return s.notifications.NotifyUser(ctx, tx, &models.Notification{
+			UserID:  challenge.OpponentID,
+			Type:    "challenge_cancelled",
+			Title:   "Challenge withdrawn",
+			Message: fmt.Sprintf("A %s challenge sent to you was withdrawn.", challenge.Metric),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// GetChallengeLeaderboard returns the two-entry standings of a challenge
+func (s *ChallengeService) GetChallengeLeaderboard(ctx context.Context, id, viewerID string, isAdmin bool) (*models.Leaderboard, error) {
+	if _, err := s.GetChallenge(ctx, id, viewerID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.leaderboard.GetLeaderboard(ctx, challengeBoardID(id), 2)
+}
+
+// DueChallengeIDs returns pending challenges whose response window has passed
+// and running challenges that have ended
+func (s *ChallengeService) DueChallengeIDs(ctx context.Context, now time.Time) ([]string, error) {
+	query := `
+		SELECT id FROM public.challenges
+		WHERE (status = 'pending' AND expires_at <= $1) OR (status = 'active' AND end_date <= $1)
+		ORDER BY created_at
+		LIMIT 100
+	`
+	rows, err := s.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due challenges: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan challenge id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// SettleChallenge expires a challenge nobody responded to or resolves one that
+// has ended. Rows locked by another scheduler instance are skipped, and the
+// status is re-checked under the lock so a challenge settles exactly once.
+func (s *ChallengeService) SettleChallenge(ctx context.Context, id string, now time.Time) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		challenge, err := lockChallenge(ctx, tx, id, true)
+		if err == ErrChallengeNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case challenge.Status == models.ChallengePending && !now.Before(challenge.ExpiresAt):
+			return s.expire(ctx, tx, challenge)
+		case challenge.Status == models.ChallengeActive && challenge.EndDate != nil && !now.Before(*challenge.EndDate):
+			return s.resolve(ctx, tx, challenge, now)
+		}
+		return nil
+	})
+}
+
+func (s *ChallengeService) expire(ctx context.Context, tx *sql.Tx, challenge *models.Challenge) error {
+	if err := closePendingChallenge(ctx, tx, challenge, models.ChallengeExpired); err != nil {
+		return err
+	}
+
+	return s.notifications.NotifyUser(ctx, tx, &models.Notification{
+		UserID:  challenge.ChallengerID,
+		Type:    "challenge_expired",
+		Title:   "Challenge expired",
+		Message: fmt.Sprintf("Your %s challenge was not accepted in time.%s", challenge.Metric, releasedSuffix(challenge.Stake)),
+	})
+}
+
+// resolve scores both participants from their stored fitness data, freezes
+// the challenge leaderboard on those scores, records the winner and pays out
+// the stakes. A tie returns each stake to its owner.
+func (s *ChallengeService) resolve(ctx context.Context, tx *sql.Tx, challenge *models.Challenge, now time.Time) error {
+	challengerScore, err := refreshChallengeScore(ctx, tx, s.leaderboard, challenge, challenge.ChallengerID)
+	if err != nil {
+		return err
+	}
+	opponentScore, err := refreshChallengeScore(ctx, tx, s.leaderboard, challenge, challenge.OpponentID)
+	if err != nil {
+		return err
+	}
+	if err := s.leaderboard.FreezeLeaderboard(ctx, challengeBoardID(challenge.ID)); err != nil {
+		return fmt.Errorf("failed to freeze challenge leaderboard: %w", err)
+	}
+
+	winnerID := challengeWinner(challenge, challengerScore, opponentScore)
+
+	if challenge.Stake > 0 {
+		if winnerID != "" {
+			if err := recordLedgerEntry(ctx, tx, winnerID, 2*challenge.Stake, LedgerTypeChallengePayout, challenge.ID, "Challenge winnings"); err != nil {
+				return err
+			}
+		} else {
+			for _, userID := range []string{challenge.ChallengerID, challenge.OpponentID} {
+				if err := recordLedgerEntry(ctx, tx, userID, challenge.Stake, LedgerTypeChallengeRelease, challenge.ID, "Challenge tied"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	query := `
+		UPDATE public.challenges
+		SET status = $1, winner_id = NULLIF($2, '')::uuid, challenger_score = $3, opponent_score = $4, resolved_at = $5
+		WHERE id = $6
+	`
+	if _, err := tx.ExecContext(ctx, query,
+		models.ChallengeCompleted, winnerID, challengerScore, opponentScore, now, challenge.ID,
+	); err != nil {
+		return fmt.Errorf("failed to resolve challenge: %w", err)
+	}
+
+	for _, userID := range []string{challenge.ChallengerID, challenge.OpponentID} {
+		if err := s.notifications.NotifyUser(ctx, tx, &models.Notification{
+			UserID:  userID,
+			Type:    "challenge_completed",
+			Title:   "Challenge finished",
+			Message: challengeOutcomeMessage(challenge, userID, winnerID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// activeChallenges returns the running challenges userID takes part in
+func activeChallenges(ctx context.Context, db dbExecutor, userID string) ([]*models.Challenge, error) {
+	query := `SELECT ` + challengeColumns + ` FROM public.challenges
+		WHERE status = 'active' AND (challenger_id = $1 OR opponent_id = $1)`
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active challenges: %w", err)
+	}
+	defer rows.Close()
+
+	var challenges []*models.Challenge
+	for rows.Next() {
+		challenge, err := scanChallenge(rows)
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, challenge)
+	}
+	return challenges, rows.Err()
+}
+
+// refreshChallengeScore sets userID's score on a challenge leaderboard from
+// their stored fitness data for the challenge's days and returns it. A frozen
+// leaderboard keeps its final standings.
+func refreshChallengeScore(ctx context.Context, db dbExecutor, leaderboard *LeaderboardService, challenge *models.Challenge, userID string) (float64, error) {
+	first, end, ok := challengeDays(challenge)
+	if !ok {
+		return 0, nil
+	}
+	totals, err := loadChallengeTotals(ctx, db, userID, first, end)
+	if err != nil {
+		return 0, err
+	}
+
+	req := &models.ScoreUpdateRequest{
+		UserID:        userID,
+		CompetitionID: challengeBoardID(challenge.ID),
+		Steps:         totals.Steps,
+		Distance:      totals.Distance,
+		Calories:      totals.Calories,
+	}
+	if err := leaderboard.UpdateScore(ctx, req); err != nil && !errors.Is(err, ErrLeaderboardFrozen) {
+		return 0, fmt.Errorf("failed to update challenge score: %w", err)
+	}
+	return metricValue(challenge.Metric, req), nil
+}
+
+// challengeDays returns the dates a running challenge counts, from first up to
+// but excluding end. The day it was accepted counts in full and the day it
+// ends doesn't, so every counted day is over by the time it is resolved.
+func challengeDays(challenge *models.Challenge) (first, end time.Time, ok bool) {
+	if challenge.StartDate == nil || challenge.EndDate == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start, stop := challenge.StartDate.UTC(), challenge.EndDate.UTC()
+	first = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(stop.Year(), stop.Month(), stop.Day(), 0, 0, 0, 0, time.UTC)
+	return first, end, true
+}
+
+// loadChallengeTotals sums a user's merged days from first up to but
+// excluding end. A day synced to several competitions is the same activity,
+// so it counts once, at the highest value synced for it.
+func loadChallengeTotals(ctx context.Context, db dbExecutor, userID string, first, end time.Time) (*models.FitnessData, error) {
+	query := `
+		SELECT COALESCE(SUM(steps), 0), COALESCE(SUM(distance), 0), COALESCE(SUM(calories), 0),
+			COALESCE(SUM(active_minutes), 0)
+		FROM (
+			SELECT MAX(steps) AS steps, MAX(distance) AS distance, MAX(calories) AS calories,
+				MAX(active_minutes) AS active_minutes
+			FROM public.fitness_daily
+			WHERE user_id = $1 AND date >= $2 AND date < $3
+			GROUP BY date
+		) days
+	`
+	totals := &models.FitnessData{UserID: userID}
+	if err := db.QueryRowContext(ctx, query, userID, first.Format("2006-01-02"), end.Format("2006-01-02")).Scan(
+		&totals.Steps, &totals.Distance, &totals.Calories, &totals.ActiveMinutes,
+	); err != nil {
+		return nil, fmt.Errorf("failed to sum challenge fitness data: %w", err)
+	}
+	return totals, nil
+}
+
+// lockChallenge loads a challenge FOR UPDATE, optionally skipping rows that
+// are already locked
+func lockChallenge(ctx context.Context, tx *sql.Tx, id string, skipLocked bool) (*models.Challenge, error) {
+	query := `SELECT ` + challengeColumns + ` FROM public.challenges WHERE id = $1 FOR UPDATE`
+	if skipLocked {
+		query += ` SKIP LOCKED`
+	}
+
+	challenge, err := scanChallenge(tx.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// closePendingChallenge ends a challenge that never started and returns the
+// challenger's stake
+func closePendingChallenge(ctx context.Context, tx *sql.Tx, challenge *models.Challenge, status string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE public.challenges SET status = $1 WHERE id = $2`, status, challenge.ID); err != nil {
+		return fmt.Errorf("failed to update challenge: %w", err)
+	}
+	challenge.Status = status
+
+	if challenge.Stake <= 0 {
+		return nil
+	}
+	return recordLedgerEntry(ctx, tx, challenge.ChallengerID, challenge.Stake, LedgerTypeChallengeRelease, challenge.ID, "Challenge stake returned")
+}
+
+// holdStake debits a stake from the user's balance, locking the user row so
+// concurrent holds and withdrawals see each other
+func holdStake(ctx context.Context, tx *sql.Tx, userID string, stake float64, challengeID string) error {
+	if stake <= 0 {
+		return nil
+	}
+
+	var lockedID string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM public.users WHERE id = $1 FOR UPDATE`, userID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	balance, err := ledgerBalance(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if stake > balance {
+		return fmt.Errorf("%w: available %.2f", ErrInsufficientBalance, balance)
+	}
+
+	return recordLedgerEntry(ctx, tx, userID, -stake, LedgerTypeChallengeStake, challengeID, "Challenge stake")
+}
+
+// checkChallengeResponse verifies that userID may accept or decline challenge
+// at now
+func checkChallengeResponse(challenge *models.Challenge, userID string, now time.Time) error {
+	if userID != challenge.OpponentID {
+		if userID == challenge.ChallengerID {
+			return fmt.Errorf("%w: only the opponent can respond", ErrInvalidChallenge)
+		}
+		return ErrChallengeNotFound
+	}
+	if challenge.Status != models.ChallengePending || !now.Before(challenge.ExpiresAt) {
+		return ErrChallengeNotPending
+	}
+	return nil
+}
+
+// validateChallenge checks a create request before it touches the database
+func validateChallenge(req *models.CreateChallengeRequest) error {
+	if req.OpponentID == "" {
+		return fmt.Errorf("%w: opponent is required", ErrInvalidChallenge)
+	}
+	if req.OpponentID == req.ChallengerID {
+		return fmt.Errorf("%w: you cannot challenge yourself", ErrInvalidChallenge)
+	}
+	if !challengeMetrics[req.Metric] {
+		return fmt.Errorf("%w: metric must be steps, distance or calories", ErrInvalidChallenge)
+	}
+	if req.DurationDays < 1 || req.DurationDays > maxChallengeDays {
+		return fmt.Errorf("%w: duration must be between 1 and %d days", ErrInvalidChallenge, maxChallengeDays)
+	}
+	if req.Stake < 0 {
+		return fmt.Errorf("%w: stake cannot be negative", ErrInvalidChallenge)
+	}
+	return nil
+}
+
+// challengeWinner returns the user with the higher score, or "" for a tie
+func challengeWinner(challenge *models.Challenge, challengerScore, opponentScore float64) string {
+	switch {
+	case challengerScore > opponentScore:
+		return challenge.ChallengerID
+	case opponentScore > challengerScore:
+		return challenge.OpponentID
+	default:
+		return ""
+	}
+}
+
+func challengeOutcomeMessage(challenge *models.Challenge, userID, winnerID string) string {
+	switch {
+	case winnerID == "":
+		return fmt.Sprintf("Your %s challenge ended in a tie.%s", challenge.Metric, releasedSuffix(challenge.Stake))
+	case winnerID == userID && challenge.Stake > 0:
+		return fmt.Sprintf("You won your %s challenge and %.2f has been added to your balance.", challenge.Metric, 2*challenge.Stake)
+	case winnerID == userID:
+		return fmt.Sprintf("You won your %s challenge!", challenge.Metric)
+	default:
+		return fmt.Sprintf("Your %s challenge is over and your opponent came out ahead. Rematch?", challenge.Metric)
+	}
+}
+
+func stakeSuffix(stake float64) string {
+	if stake <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" for a stake of %.2f", stake)
+}
+
+func releasedSuffix(stake float64) string {
+	if stake <= 0 {
+		return ""
+	}
+	return " Your stake has been returned."
+}
+
+// challengeBoardID namespaces a challenge's leaderboard away from
+// competition leaderboards
+func challengeBoardID(challengeID string) string {
+	return "challenge:" + challengeID
+}
+
+func scanChallenge(row rowScanner) (*models.Challenge, error) {
+	var c models.Challenge
+	var startDate, endDate, resolvedAt sql.NullTime
+	var challengerScore, opponentScore sql.NullFloat64
+
+	err := row.Scan(
+		&c.ID, &c.ChallengerID, &c.OpponentID, &c.Metric, &c.DurationDays, &c.Stake, &c.Message, &c.Status,
+		&c.ExpiresAt, &startDate, &endDate, &c.WinnerID, &challengerScore, &opponentScore,
+		&resolvedAt, &c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if startDate.Valid {
+		c.StartDate = &startDate.Time
+	}
+	if endDate.Valid {
+		c.EndDate = &endDate.Time
+	}
+	if resolvedAt.Valid {
+		c.ResolvedAt = &resolvedAt.Time
+	}
+	if challengerScore.Valid {
+		c.ChallengerScore = &challengerScore.Float64
+	}
+	if opponentScore.Valid {
+		c.OpponentScore = &opponentScore.Float64
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChallenge(t *testing.T) {
+	valid := func() *models.CreateChallengeRequest {
+		return &models.CreateChallengeRequest{
+			ChallengerID: "alice",
+			OpponentID:   "bob",
+			Metric:       "steps",
+			DurationDays: 7,
+			Stake:        5,
+		}
+	}
+
+	assert.NoError(t, validateChallenge(valid()))
+
+	tests := []struct {
+		name   string
+		modify func(req *models.CreateChallengeRequest)
+	}{
+		{"missing opponent", func(req *models.CreateChallengeRequest) { req.OpponentID = "" }},
+		{"self challenge", func(req *models.CreateChallengeRequest) { req.OpponentID = req.ChallengerID }},
+		{"unknown metric", func(req *models.CreateChallengeRequest) { req.Metric = "floors" }},
+		{"zero duration", func(req *models.CreateChallengeRequest) { req.DurationDays = 0 }},
+		{"too long", func(req *models.CreateChallengeRequest) { req.DurationDays = maxChallengeDays + 1 }},
+		{"negative stake", func(req *models.CreateChallengeRequest) { req.Stake = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(req)
+			assert.ErrorIs(t, validateChallenge(req), ErrInvalidChallenge)
+		})
+	}
+}
+
+func TestCheckChallengeResponse(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	challenge := &models.Challenge{
+		ChallengerID: "alice",
+		OpponentID:   "bob",
+		Status:       models.ChallengePending,
+		ExpiresAt:    now.Add(time.Hour),
+	}
+
+	assert.NoError(t, checkChallengeResponse(challenge, "bob", now))
+	assert.ErrorIs(t, checkChallengeResponse(challenge, "alice", now), ErrInvalidChallenge)
+	assert.ErrorIs(t, checkChallengeResponse(challenge, "mallory", now), ErrChallengeNotFound)
+	assert.ErrorIs(t, checkChallengeResponse(challenge, "bob", now.Add(time.Hour)), ErrChallengeNotPending, "response window has passed")
+
+	challenge.Status = models.ChallengeActive
+	assert.ErrorIs(t, checkChallengeResponse(challenge, "bob", now), ErrChallengeNotPending)
+}
+
+func TestChallengeWinner(t *testing.T) {
+	challenge := &models.Challenge{ChallengerID: "alice", OpponentID: "bob"}
+
+	assert.Equal(t, "alice", challengeWinner(challenge, 12000, 9000))
+	assert.Equal(t, "bob", challengeWinner(challenge, 4.2, 4.3))
+	assert.Equal(t, "", challengeWinner(challenge, 500, 500))
+	assert.Equal(t, "", challengeWinner(challenge, 0, 0), "nobody synced")
+}
+
+func TestChallengeDays(t *testing.T) {
+	start := time.Date(2024, 6, 3, 21, 30, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	challenge := &models.Challenge{StartDate: &start, EndDate: &end}
+
+	first, last, ok := challengeDays(challenge)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), first)
+	assert.Equal(t, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), last)
+
+	_, _, ok = challengeDays(&models.Challenge{})
+	assert.False(t, ok, "a challenge that never started counts no days")
+}
+
+func TestChallengeService_ResolveScoresFromStoredData(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	leaderboard := NewLeaderboardService(cache, client)
+	ctx := context.Background()
+
+	start := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	challenge := &models.Challenge{
+		ID:           "33333333-3333-3333-3333-333333333333",
+		ChallengerID: "alice",
+		OpponentID:   "bob",
+		Metric:       "steps",
+		DurationDays: 7,
+		Stake:        5,
+		Status:       models.ChallengeActive,
+		StartDate:    &start,
+		EndDate:      &end,
+	}
+	require.NoError(t, leaderboard.SetMetric(ctx, challengeBoardID(challenge.ID), challenge.Metric))
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "FROM public.fitness_daily", rows: [][]driver.Value{{int64(52000), 40.5, 2100.0, int64(300)}}},
+		fakeQuery{match: "FROM public.fitness_daily", rows: [][]driver.Value{{int64(61000), 48.0, 2500.0, int64(340)}}},
+		fakeQuery{match: "INSERT INTO public.ledger_entries"},
+		fakeQuery{match: "UPDATE public.challenges"},
+		fakeQuery{match: "INSERT INTO public.notifications", rows: [][]driver.Value{{"n-1"}}},
+		fakeQuery{match: "INSERT INTO public.notifications", rows: [][]driver.Value{{"n-2"}}},
+	)
+	s := NewChallengeService(db, leaderboard, NewNotificationService(db, cache))
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, s.resolve(ctx, tx, challenge, end))
+
+	// Only the challenge's days count, from the day it was accepted
+	assert.Equal(t, []driver.Value{"alice", "2024-06-03", "2024-06-10"}, fake.args[0])
+
+	payout := fake.args[2]
+	assert.Equal(t, "bob", payout[1])
+	assert.Equal(t, 10.0, payout[2])
+	assert.Equal(t, LedgerTypeChallengePayout, payout[3])
+
+	resolved := fake.args[3]
+	assert.Equal(t, "bob", resolved[1])
+	assert.Equal(t, 52000.0, resolved[2])
+	assert.Equal(t, 61000.0, resolved[3])
+
+	// The leaderboard shows the stored scores and no longer moves
+	board, err := leaderboard.GetLeaderboard(ctx, challengeBoardID(challenge.ID), 2)
+	require.NoError(t, err)
+	require.Len(t, board.Entries, 2)
+	assert.Equal(t, "bob", board.Entries[0].UserID)
+	assert.Equal(t, int64(61000), board.Entries[0].Score)
+
+	err = leaderboard.UpdateScore(ctx, &models.ScoreUpdateRequest{UserID: "alice", CompetitionID: challengeBoardID(challenge.ID), Steps: 1000000})
+	assert.ErrorIs(t, err, ErrLeaderboardFrozen)
+}
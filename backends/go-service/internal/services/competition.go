@@ -3,45 +3,73 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/payments"
 )
 
+var (
+	ErrCompetitionNotFound = errors.New("competition not found")
+	ErrAlreadyJoined       = errors.New("user already joined this competition")
+	ErrPaymentFailed       = errors.New("entry fee payment failed")
+
+	ErrInvalidCompetition       = errors.New("invalid competition")
+	ErrInvalidGoal              = errors.New("invalid goal")
+	ErrNotParticipant           = errors.New("user is not a participant in this competition")
+	ErrNotCompetitionOwner      = errors.New("only the creator or an admin can modify this competition")
+	ErrCompetitionClosed        = errors.New("competition is already completed or cancelled")
+	ErrInvalidCompetitionUpdate = errors.New("invalid competition update")
+	ErrDuplicateInstance        = errors.New("competition instance already exists for this template and start date")
+)
+
+// competitionColumns lists the columns read by scanCompetition; queries must
+// alias public.competitions as c
+const competitionColumns = `
+	c.id, c.name, c.description, c.entry_fee, c.prize_pool, c.start_date, c.end_date,
+	c.status, c.type, COALESCE(c.creator_id::text, ''), c.visibility, c.max_participants,
+	COALESCE(c.template_id::text, ''), c.created_at,
+	c.mode, c.goal_metric, c.goal_target, c.goal_end_after_finishers,
+	c.division_count, c.division_promote, c.division_relegate,
+	c.time_zone,
+	c.eligible_sources, c.eligible_countries, c.min_active_days
+`
+
 type CompetitionService struct {
-	db    *sql.DB
-	cache *CacheService
+	db            *sql.DB
+	cache         *CacheService
+	leaderboard   *LeaderboardService
+	payments      payments.PaymentProvider
+	notifications *NotificationService
+	refundPolicy  models.RefundPolicy
+	inviteBaseURL string
 }
 
-func NewCompetitionService(db *sql.DB, cache *CacheService) *CompetitionService {
+// NewCompetitionService creates a competition service. inviteBaseURL is the
+// prefix for shareable invite links; when empty invites carry only a code.
+func NewCompetitionService(db *sql.DB, cache *CacheService, leaderboard *LeaderboardService, paymentProvider payments.PaymentProvider, notifications *NotificationService, refundPolicy models.RefundPolicy, inviteBaseURL string) *CompetitionService {
 	return &CompetitionService{
-		db:    db,
-		cache: cache,
+		db:            db,
+		cache:         cache,
+		leaderboard:   leaderboard,
+		payments:      paymentProvider,
+		notifications: notifications,
+		refundPolicy:  refundPolicy,
+		inviteBaseURL: inviteBaseURL,
 	}
 }
 
-// GetCompetitions retrieves competitions based on status filter
-func (s *CompetitionService) GetCompetitions(ctx context.Context, status string, limit, offset int) ([]models.Competition, error) {
-	query := `
-		SELECT id, name, description, entry_fee, prize_pool, start_date, end_date, status, type, created_at
-		FROM public.competitions
-		WHERE 1=1
-	`
-
-	args := []interface{}{}
-	argPos := 1
-
-	if status != "all" {
-		query += fmt.Sprintf(" AND status = $%d", argPos)
-		args = append(args, status)
-		argPos++
+// GetCompetitions retrieves competitions matching filter. Only public
+// competitions are listed, plus any the viewer created or participates in.
+func (s *CompetitionService) GetCompetitions(ctx context.Context, filter *models.CompetitionFilter) ([]models.Competition, error) {
+	query, args, err := buildCompetitionsQuery(filter)
+	if err != nil {
+		return nil, err
 	}
 
-	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
-	args = append(args, limit, offset)
-
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query competitions: %w", err)
@@ -51,10 +79,7 @@ func (s *CompetitionService) GetCompetitions(ctx context.Context, status string,
 	var competitions []models.Competition
 	for rows.Next() {
 		var comp models.Competition
-		if err := rows.Scan(
-			&comp.ID, &comp.Name, &comp.Description, &comp.EntryFee, &comp.PrizePool,
-			&comp.StartDate, &comp.EndDate, &comp.Status, &comp.Type, &comp.CreatedAt,
-		); err != nil {
+		if err := scanCompetition(rows, &comp, &comp.ParticipantCount); err != nil {
 			return nil, fmt.Errorf("failed to scan competition: %w", err)
 		}
 		competitions = append(competitions, comp)
@@ -63,26 +88,35 @@ func (s *CompetitionService) GetCompetitions(ctx context.Context, status string,
 	return competitions, nil
 }
 
-// GetCompetitionByID retrieves a single competition by ID
-func (s *CompetitionService) GetCompetitionByID(ctx context.Context, id string) (*models.Competition, error) {
-	query := `
-		SELECT id, name, description, entry_fee, prize_pool, start_date, end_date, status, type, created_at
-		FROM public.competitions
-		WHERE id = $1
+// GetCompetitionByID retrieves a single competition by ID. Private
+// competitions are reported as not found to anyone but their creator,
+// participants and admins; unlisted ones are visible to anyone with the ID.
+func (s *CompetitionService) GetCompetitionByID(ctx context.Context, id, viewerID string, isAdmin bool) (*models.Competition, error) {
+	query := `SELECT ` + competitionColumns + `, ` + competitionParticipantCount + `
+		FROM public.competitions c
+		WHERE c.id = $1
 	`
 
 	var comp models.Competition
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&comp.ID, &comp.Name, &comp.Description, &comp.EntryFee, &comp.PrizePool,
-		&comp.StartDate, &comp.EndDate, &comp.Status, &comp.Type, &comp.CreatedAt,
-	)
+	err := scanCompetition(s.db.QueryRowContext(ctx, query, id), &comp, &comp.ParticipantCount)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("competition not found")
+		return nil, ErrCompetitionNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get competition: %w", err)
 	}
 
+	if comp.Visibility == models.VisibilityPrivate && !isAdmin && comp.CreatorID != viewerID {
+		var participant bool
+		checkQuery := `SELECT EXISTS(SELECT 1 FROM public.competition_participants WHERE competition_id = $1 AND user_id::text = $2)`
+		if err := s.db.QueryRowContext(ctx, checkQuery, id, viewerID).Scan(&participant); err != nil {
+			return nil, fmt.Errorf("failed to check participation: %w", err)
+		}
+		if !participant {
+			return nil, ErrCompetitionNotFound
+		}
+	}
+
 	return &comp, nil
 }
 
@@ -92,14 +126,33 @@ func (s *CompetitionService) CreateCompetition(ctx context.Context, req *models.
 	if req.StartDate.After(req.EndDate) {
 		return nil, fmt.Errorf("start date must be before end date")
 	}
-
-	// Determine status based on dates
-	now := time.Now()
-	status := "upcoming"
-	if now.After(req.StartDate) && now.Before(req.EndDate) {
-		status = "active"
-	} else if now.After(req.EndDate) {
-		status = "completed"
+	if req.MaxParticipants != nil && *req.MaxParticipants <= 0 {
+		return nil, fmt.Errorf("%w: max participants must be positive", ErrInvalidCompetition)
+	}
+	if req.Visibility == "" {
+		req.Visibility = models.VisibilityPublic
+	}
+	if !validVisibility(req.Visibility) {
+		return nil, fmt.Errorf("%w: visibility must be one of public, unlisted or private", ErrInvalidCompetition)
+	}
+	if req.Mode == "" {
+		req.Mode = models.ModeScore
+	}
+	if err := validateGoal(req.Mode, req.Goal); err != nil {
+		return nil, err
+	}
+	if err := validateDivisions(req.Divisions); err != nil {
+		return nil, err
+	}
+	if err := validateEligibility(req.Eligibility); err != nil {
+		return nil, err
+	}
+	if req.TimeZone == "" {
+		req.TimeZone = defaultTimeZone
+	}
+	loc, err := loadTimeZone(req.TimeZone)
+	if err != nil {
+		return nil, err
 	}
 
 	comp := &models.Competition{
@@ -108,64 +161,552 @@ func (s *CompetitionService) CreateCompetition(ctx context.Context, req *models.
 		Description: req.Description,
 		EntryFee:    req.EntryFee,
 		PrizePool:   req.PrizePool,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
-		Status:      status,
+		StartDate:   wallClockIn(req.StartDate, loc),
+		EndDate:     wallClockIn(req.EndDate, loc),
 		Type:        req.Type,
+		CreatorID:   req.CreatorID,
 		CreatedAt:   time.Now(),
+
+		Visibility:      req.Visibility,
+		MaxParticipants: req.MaxParticipants,
+
+		Mode: req.Mode,
+		Goal: req.Goal,
+
+		Divisions: req.Divisions,
+
+		TimeZone: req.TimeZone,
+
+		Eligibility: req.Eligibility,
+	}
+
+	// Determine status based on dates; the scheduler moves it on from here
+	comp.Status = nextCompetitionStatus(comp, comp.CreatedAt)
+
+	if err := insertCompetition(ctx, s.db, comp); err != nil {
+		return nil, err
 	}
 
+	if err := s.leaderboard.SetGoal(ctx, comp.ID, comp.Goal); err != nil {
+		return nil, fmt.Errorf("created competition but failed to configure leaderboard: %w", err)
+	}
+	if err := cacheCompetitionTimeZone(ctx, s.cache, comp.ID, comp.TimeZone); err != nil {
+		return nil, fmt.Errorf("created competition but failed to cache its time zone: %w", err)
+	}
+
+	return comp, nil
+}
+
+// insertCompetition stores a new competition. Instances of a template are
+// unique per start date, so inserting one twice reports ErrDuplicateInstance.
+func insertCompetition(ctx context.Context, db dbExecutor, comp *models.Competition) error {
 	query := `
-		INSERT INTO public.competitions (id, name, description, entry_fee, prize_pool, start_date, end_date, status, type, created_at, creator_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO public.competitions (
+			id, name, description, entry_fee, prize_pool, start_date, end_date, status, type, created_at,
+			creator_id, visibility, max_participants, template_id,
+			mode, goal_metric, goal_target, goal_end_after_finishers,
+			division_count, division_promote, division_relegate, time_zone,
+			eligible_sources, eligible_countries, min_active_days
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, '')::uuid, $12, $13, NULLIF($14, '')::uuid, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
+		ON CONFLICT (template_id, start_date) DO NOTHING
 	`
 
-	_, err := s.db.ExecContext(ctx, query,
+	metric, target, endAfterFinishers := goalArgs(comp.Goal)
+	divisionCount, promote, relegate := divisionArgs(comp.Divisions)
+	sources, countries, minActiveDays := eligibilityArgs(comp.Eligibility)
+	res, err := db.ExecContext(ctx, query,
 		comp.ID, comp.Name, comp.Description, comp.EntryFee, comp.PrizePool,
-		comp.StartDate, comp.EndDate, comp.Status, comp.Type, comp.CreatedAt, req.CreatorID,
+		comp.StartDate, comp.EndDate, comp.Status, comp.Type, comp.CreatedAt, comp.CreatorID, comp.Visibility, comp.MaxParticipants,
+		comp.TemplateID, comp.Mode, metric, target, endAfterFinishers,
+		divisionCount, promote, relegate, comp.TimeZone,
+		sources, countries, minActiveDays,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create competition: %w", err)
+		return fmt.Errorf("failed to create competition: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDuplicateInstance
 	}
 
-	return comp, nil
+	return nil
 }
 
-// JoinCompetition allows a user to join a competition
-func (s *CompetitionService) JoinCompetition(ctx context.Context, competitionID, userID string) error {
-	// Check if competition exists and is active/upcoming
-	comp, err := s.GetCompetitionByID(ctx, competitionID)
+// UpdateCompetition applies a partial update on behalf of the creator or an
+// admin. Once a competition has started only its name and description can
+// change, the prize pool can only grow and the end date can only be extended.
+func (s *CompetitionService) UpdateCompetition(ctx context.Context, competitionID, actorID string, isAdmin bool, req *models.UpdateCompetitionRequest) (*models.Competition, error) {
+	var updated *models.Competition
+	var charges []*payments.ChargeResult
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		comp, err := s.lockCompetition(ctx, tx, competitionID)
+		if err != nil {
+			return err
+		}
+
+		if comp.CreatorID != actorID && !isAdmin {
+			return ErrNotCompetitionOwner
+		}
+		if comp.Status == "completed" || comp.Status == "cancelled" {
+			return ErrCompetitionClosed
+		}
+
+		participants, err := countParticipants(ctx, tx, competitionID)
+		if err != nil {
+			return err
+		}
+
+		// New dates are read in the competition's zone like at creation
+		loc := comp.StartDate.Location()
+		if req.StartDate != nil {
+			start := wallClockIn(*req.StartDate, loc)
+			req.StartDate = &start
+		}
+		if req.EndDate != nil {
+			end := wallClockIn(*req.EndDate, loc)
+			req.EndDate = &end
+		}
+
+		now := time.Now()
+		if err := validateCompetitionUpdate(comp, participants, req, now); err != nil {
+			return err
+		}
+
+		// Status changes are left to the scheduler so its side effects still run
+		applyCompetitionUpdate(comp, req)
+
+		updateQuery := `
+			UPDATE public.competitions
+			SET name = $1, description = $2, entry_fee = $3, prize_pool = $4,
+				start_date = $5, end_date = $6, type = $7, visibility = $8, max_participants = $9,
+				mode = $10, goal_metric = $11, goal_target = $12, goal_end_after_finishers = $13
+			WHERE id = $14
+		`
+		metric, target, endAfterFinishers := goalArgs(comp.Goal)
+		if _, err := tx.ExecContext(ctx, updateQuery,
+			comp.Name, comp.Description, comp.EntryFee, comp.PrizePool,
+			comp.StartDate, comp.EndDate, comp.Type, comp.Visibility, comp.MaxParticipants,
+			comp.Mode, metric, target, endAfterFinishers, comp.ID,
+		); err != nil {
+			return fmt.Errorf("failed to update competition: %w", err)
+		}
+
+		// Raising or removing the cap before the start admits waitlisted users
+		if req.MaxParticipants != nil && comp.Status == "upcoming" && now.Before(comp.StartDate) {
+			_, c, err := s.promoteFromWaitlist(ctx, tx, comp)
+			charges = append(charges, c...)
+			if err != nil {
+				return err
+			}
+		}
+
+		comp.ParticipantCount = participants
+		updated = comp
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, s.refundCharges(ctx, charges, err)
+	}
+
+	if req.Mode != nil || req.Goal != nil {
+		if err := s.leaderboard.SetGoal(ctx, updated.ID, updated.Goal); err != nil {
+			return nil, fmt.Errorf("updated competition but failed to configure leaderboard: %w", err)
+		}
+	}
+
+	return updated, nil
+}
+
+// CancelCompetition cancels a competition on behalf of the creator or an
+// admin, refunds every paid entry fee and notifies participants. Refunds are
+// recorded as pending in the same transaction as the cancellation and sent to
+// the payment provider afterwards, so a provider failure never undoes the
+// cancellation; failed refunds are left in the failed state for follow-up.
+func (s *CompetitionService) CancelCompetition(ctx context.Context, competitionID, actorID string, isAdmin bool, reason string) (*models.CancelCompetitionResult, error) {
+	var comp *models.Competition
+	var refunds []models.Transaction
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		comp, err = s.lockCompetition(ctx, tx, competitionID)
+		if err != nil {
+			return err
+		}
+
+		if comp.CreatorID != actorID && !isAdmin {
+			return ErrNotCompetitionOwner
+		}
+		if comp.Status == "completed" || comp.Status == "cancelled" {
+			return ErrCompetitionClosed
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE public.competitions SET status = 'cancelled' WHERE id = $1`, competitionID); err != nil {
+			return fmt.Errorf("failed to cancel competition: %w", err)
+		}
+		comp.Status = "cancelled"
+
+		refunds, err = s.createEntryFeeRefunds(ctx, tx, competitionID, "", 100, fmt.Sprintf("Refund: %s was cancelled", comp.Name))
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("%s has been cancelled.", comp.Name)
+		if reason != "" {
+			message += " Reason: " + reason
+		}
+		if comp.EntryFee > 0 {
+			message += " Your entry fee will be refunded."
+		}
+		return s.notifications.NotifyParticipants(ctx, tx, competitionID, "competition_cancelled", "Competition cancelled", message)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	failed := s.processRefunds(ctx, refunds)
+
+	return &models.CancelCompetitionResult{
+		Competition:   comp,
+		Refunds:       refunds,
+		FailedRefunds: failed,
+	}, nil
+}
+
+// JoinCompetition allows a user to join a competition. For paid competitions
+// the entry fee is charged and the participant inserted in one database
+// transaction; any failure after the charge refunds it. When the competition
+// is full the user is placed on the waitlist instead and is not charged.
+// Private competitions can only be joined with an invite code.
+func (s *CompetitionService) JoinCompetition(ctx context.Context, competitionID, userID string) (*models.JoinCompetitionResult, error) {
+	return s.join(ctx, userID, func(tx *sql.Tx) (*models.Competition, error) {
+		comp, err := s.lockCompetition(ctx, tx, competitionID)
+		if err != nil {
+			return nil, err
+		}
+		if comp.Visibility == models.VisibilityPrivate && comp.CreatorID != userID {
+			return nil, ErrInviteRequired
+		}
+		return comp, nil
+	})
+}
+
+// JoinCompetitionByCode joins the competition an invite code belongs to,
+// counting a use of the code
+func (s *CompetitionService) JoinCompetitionByCode(ctx context.Context, code, userID string) (*models.JoinCompetitionResult, error) {
+	return s.join(ctx, userID, func(tx *sql.Tx) (*models.Competition, error) {
+		invite, err := s.redeemInvite(ctx, tx, code, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return s.lockCompetition(ctx, tx, invite.CompetitionID)
+	})
+}
+
+// autoEnrol joins a user who subscribed to a recurring template into one of
+// its instances. Private instances do not need an invite since the user opted
+// in to the template.
+func (s *CompetitionService) autoEnrol(ctx context.Context, competitionID, userID string) (*models.JoinCompetitionResult, error) {
+	return s.join(ctx, userID, func(tx *sql.Tx) (*models.Competition, error) {
+		return s.lockCompetition(ctx, tx, competitionID)
+	})
+}
+
+// join runs the join flow for the competition returned by lock, which must
+// lock the competition row within tx
+func (s *CompetitionService) join(ctx context.Context, userID string, lock func(tx *sql.Tx) (*models.Competition, error)) (*models.JoinCompetitionResult, error) {
+	result := &models.JoinCompetitionResult{Status: "joined"}
+
+	var charges []*payments.ChargeResult
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Lock the competition row so concurrent joins are serialized
+		comp, err := lock(tx)
+		if err != nil {
+			return err
+		}
+		result.CompetitionID = comp.ID
+
+		if comp.Status == "completed" || comp.Status == "cancelled" {
+			return fmt.Errorf("cannot join a %s competition", comp.Status)
+		}
+
+		var exists bool
+		checkQuery := `SELECT EXISTS(SELECT 1 FROM public.competition_participants WHERE competition_id = $1 AND user_id = $2)`
+		if err := tx.QueryRowContext(ctx, checkQuery, comp.ID, userID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check participation: %w", err)
+		}
+		if exists {
+			return ErrAlreadyJoined
+		}
+
+		if err := checkJoinEligibility(ctx, tx, comp, userID); err != nil {
+			return err
+		}
+
+		if comp.MaxParticipants != nil {
+			participants, err := countParticipants(ctx, tx, comp.ID)
+			if err != nil {
+				return err
+			}
+			if participants >= *comp.MaxParticipants {
+				position, err := addToWaitlist(ctx, tx, comp.ID, userID)
+				if err != nil {
+					return err
+				}
+				result.Status = "waitlisted"
+				result.WaitlistPosition = position
+				return nil
+			}
+		}
+
+		txn, charge, err := s.addParticipant(ctx, tx, comp, userID)
+		if charge != nil {
+			charges = append(charges, charge)
+		}
+		if err != nil {
+			return err
+		}
+		result.Transaction = txn
+
+		return nil
+	})
+	if err != nil {
+		return nil, s.refundCharges(ctx, charges, err)
+	}
+
+	return result, nil
+}
+
+// addParticipant charges the entry fee (if any) and inserts the participant,
+// taking them off the waitlist. The returned charge must be refunded if tx is
+// rolled back.
+func (s *CompetitionService) addParticipant(ctx context.Context, tx *sql.Tx, comp *models.Competition, userID string) (*models.Transaction, *payments.ChargeResult, error) {
+	var txn *models.Transaction
+	var charge *payments.ChargeResult
+	var transactionID sql.NullString
+
+	if comp.EntryFee > 0 {
+		var err error
+		txn, charge, err = s.chargeEntryFee(ctx, tx, comp, userID)
+		if err != nil {
+			return nil, charge, err
+		}
+		transactionID = sql.NullString{String: txn.ID, Valid: true}
+	}
+
+	division, err := lateJoinerDivision(ctx, tx, comp, userID)
+	if err != nil {
+		return nil, charge, err
+	}
+
+	// The unique constraint on (competition_id, user_id) guards against
+	// any join that slipped past the existence check
+	insertQuery := `
+		INSERT INTO public.competition_participants (id, competition_id, user_id, joined_at, entry_transaction_id, division)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (competition_id, user_id) DO NOTHING
+	`
+	res, err := tx.ExecContext(ctx, insertQuery, uuid.New().String(), comp.ID, userID, time.Now(), transactionID, division)
+	if err != nil {
+		return nil, charge, fmt.Errorf("failed to join competition: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, charge, ErrAlreadyJoined
+	}
+
+	if err := removeFromWaitlist(ctx, tx, comp.ID, userID); err != nil {
+		return nil, charge, err
+	}
+
+	return txn, charge, nil
+}
+
+// refundCharges reverses charges whose database transaction was rolled back
+// and returns cause, annotated with any refund failures
+func (s *CompetitionService) refundCharges(ctx context.Context, charges []*payments.ChargeResult, cause error) error {
+	for _, charge := range charges {
+		if _, err := s.payments.Refund(ctx, charge.Reference, charge.Amount); err != nil {
+			cause = fmt.Errorf("%w (refund of charge %s failed: %v)", cause, charge.Reference, err)
+		}
+	}
+	return cause
+}
+
+// LeaveCompetition removes a participant from a competition and its
+// leaderboard, refunding the entry fee according to the refund policy
+func (s *CompetitionService) LeaveCompetition(ctx context.Context, competitionID, userID string) (*models.LeaveCompetitionResult, error) {
+	result := &models.LeaveCompetitionResult{
+		CompetitionID: competitionID,
+	}
+
+	var refunds []models.Transaction
+	var charges []*payments.ChargeResult
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		comp, err := s.lockCompetition(ctx, tx, competitionID)
+		if err != nil {
+			return err
+		}
+
+		if comp.Status == "completed" || comp.Status == "cancelled" {
+			return ErrCompetitionClosed
+		}
+
+		// Users on the waitlist simply give up their place
+		left, err := removeFromWaitlistIfPresent(ctx, tx, competitionID, userID)
+		if err != nil {
+			return err
+		}
+		if left {
+			result.LeftWaitlist = true
+			return nil
+		}
+
+		started := competitionStarted(comp, time.Now())
+		percent := leaveRefundPercent(s.refundPolicy, started)
+		result.RefundPercent = percent
+
+		description := fmt.Sprintf("Refund: left %s", comp.Name)
+		if percent == 0 {
+			description = fmt.Sprintf("No refund: left %s after it started", comp.Name)
+		}
+
+		refunds, err = s.createEntryFeeRefunds(ctx, tx, competitionID, userID, percent, description)
+		if err != nil {
+			return err
+		}
+
+		deleteQuery := `DELETE FROM public.competition_participants WHERE competition_id = $1 AND user_id = $2`
+		res, err := tx.ExecContext(ctx, deleteQuery, competitionID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to leave competition: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrNotParticipant
+		}
+
+		// A spot freed up before the start goes to the next user on the waitlist
+		if !started {
+			promoted, c, err := s.promoteFromWaitlist(ctx, tx, comp)
+			charges = append(charges, c...)
+			if err != nil {
+				return err
+			}
+			result.PromotedUserIDs = promoted
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, s.refundCharges(ctx, charges, err)
 	}
 
-	if comp.Status == "completed" {
-		return fmt.Errorf("cannot join a completed competition")
+	if result.LeftWaitlist {
+		return result, nil
 	}
 
-	// Check if user already joined
-	var exists bool
-	checkQuery := `SELECT EXISTS(SELECT 1 FROM public.competition_participants WHERE competition_id = $1 AND user_id = $2)`
-	if err := s.db.QueryRowContext(ctx, checkQuery, competitionID, userID).Scan(&exists); err != nil {
-		return fmt.Errorf("failed to check participation: %w", err)
+	// Refunds are sent before touching Redis so a leaderboard failure can't
+	// strand them as pending
+	s.processRefunds(ctx, refunds)
+	if len(refunds) > 0 {
+		result.Refund = &refunds[0]
 	}
 
-	if exists {
-		return fmt.Errorf("user already joined this competition")
+	if err := s.leaderboard.RemoveUser(ctx, competitionID, userID); err != nil {
+		return nil, fmt.Errorf("left competition but failed to update leaderboard: %w", err)
+	}
+
+	return result, nil
+}
+
+// competitionStarted reports whether a competition has started at now, even
+// if the scheduler hasn't marked it active yet
+func competitionStarted(comp *models.Competition, now time.Time) bool {
+	return comp.Status == "active" || !now.Before(comp.StartDate)
+}
+
+// leaveRefundPercent is the share of the entry fee refunded to a participant
+// who leaves
+func leaveRefundPercent(policy models.RefundPolicy, started bool) float64 {
+	if started {
+		return policy.AfterStartPercent
+	}
+	return policy.BeforeStartPercent
+}
+
+// chargeEntryFee records a pending entry fee transaction, charges the user and
+// marks the transaction completed, all within tx
+func (s *CompetitionService) chargeEntryFee(ctx context.Context, tx *sql.Tx, comp *models.Competition, userID string) (*models.Transaction, *payments.ChargeResult, error) {
+	txn := &models.Transaction{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		CompetitionID: comp.ID,
+		Type:          "entry_fee",
+		Amount:        comp.EntryFee,
+		Status:        "pending",
+		Description:   fmt.Sprintf("Entry fee for %s", comp.Name),
+		PaymentMethod: s.payments.Name(),
+		CreatedAt:     time.Now(),
 	}
 
-	// Insert participation record
 	insertQuery := `
-		INSERT INTO public.competition_participants (id, competition_id, user_id, joined_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO public.transactions (id, user_id, competition_id, type, amount, status, description, payment_method, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
+	if _, err := tx.ExecContext(ctx, insertQuery,
+		txn.ID, txn.UserID, txn.CompetitionID, txn.Type, txn.Amount, txn.Status, txn.Description, txn.PaymentMethod, txn.CreatedAt,
+	); err != nil {
+		return nil, nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
 
-	_, err = s.db.ExecContext(ctx, insertQuery, uuid.New().String(), competitionID, userID, time.Now())
+	charge, err := s.payments.Charge(ctx, &payments.ChargeRequest{
+		TransactionID: txn.ID,
+		UserID:        userID,
+		CompetitionID: comp.ID,
+		Amount:        comp.EntryFee,
+		Description:   txn.Description,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to join competition: %w", err)
+		// Keep a record of the attempt in case the surrounding transaction
+		// commits, as it does when promoting from the waitlist
+		if _, updateErr := tx.ExecContext(ctx, `UPDATE public.transactions SET status = 'failed' WHERE id = $1`, txn.ID); updateErr != nil {
+			return nil, nil, fmt.Errorf("failed to record failed charge: %w", updateErr)
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
 	}
 
-	return nil
+	updateQuery := `
+		UPDATE public.transactions
+		SET status = 'completed', transaction_ref = $1, completed_at = $2
+		WHERE id = $3
+	`
+	if _, err := tx.ExecContext(ctx, updateQuery, charge.Reference, charge.CompletedAt, txn.ID); err != nil {
+		return nil, charge, fmt.Errorf("failed to confirm transaction: %w", err)
+	}
+
+	txn.Status = "completed"
+	txn.TransactionRef = charge.Reference
+	txn.CompletedAt = &charge.CompletedAt
+
+	return txn, charge, nil
+}
+
+// lockCompetition loads a competition with a row lock held until tx ends
+func (s *CompetitionService) lockCompetition(ctx context.Context, tx *sql.Tx, id string) (*models.Competition, error) {
+	query := `SELECT ` + competitionColumns + `
+		FROM public.competitions c
+		WHERE c.id = $1
+		FOR UPDATE
+	`
+
+	var comp models.Competition
+	err := scanCompetition(tx.QueryRowContext(ctx, query, id), &comp)
+	if err == sql.ErrNoRows {
+		return nil, ErrCompetitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get competition: %w", err)
+	}
+
+	return &comp, nil
 }
 
 // GetUserCompetitions retrieves competitions that a user has joined
@@ -173,14 +714,15 @@ func (s *CompetitionService) GetUserCompetitions(ctx context.Context, userID, st
 	query := `
 		SELECT 
 			c.id, c.name, c.description, c.entry_fee, c.prize_pool,
-			c.start_date, c.end_date, c.status, c.type, c.created_at,
+			c.start_date, c.end_date, c.status, c.type, c.created_at, c.time_zone,
+			` + competitionParticipantCount + `,
 			cp.joined_at,
 			COALESCE(SUM(fd.steps), 0) as user_steps,
 			COALESCE(SUM(fd.calories), 0) as user_calories,
 			COALESCE(SUM(fd.distance), 0) as user_distance
 		FROM public.competitions c
 		INNER JOIN public.competition_participants cp ON c.id = cp.competition_id
-		LEFT JOIN public.fitness_data fd ON fd.competition_id = c.id AND fd.user_id = cp.user_id
+		LEFT JOIN public.fitness_daily fd ON fd.competition_id = c.id AND fd.user_id = cp.user_id
 		WHERE cp.user_id = $1
 	`
 
@@ -193,7 +735,7 @@ func (s *CompetitionService) GetUserCompetitions(ctx context.Context, userID, st
 		argPos++
 	}
 
-	query += ` GROUP BY c.id, c.name, c.description, c.entry_fee, c.prize_pool, c.start_date, c.end_date, c.status, c.type, c.created_at, cp.joined_at ORDER BY c.created_at DESC`
+	query += ` GROUP BY c.id, c.name, c.description, c.entry_fee, c.prize_pool, c.start_date, c.end_date, c.status, c.type, c.created_at, c.time_zone, cp.joined_at ORDER BY c.created_at DESC`
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -206,13 +748,249 @@ func (s *CompetitionService) GetUserCompetitions(ctx context.Context, userID, st
 		var uc models.UserCompetition
 		if err := rows.Scan(
 			&uc.ID, &uc.Name, &uc.Description, &uc.EntryFee, &uc.PrizePool,
-			&uc.StartDate, &uc.EndDate, &uc.Status, &uc.Type, &uc.CreatedAt,
-			&uc.JoinedAt, &uc.UserSteps, &uc.UserCalories, &uc.UserDistance,
+			&uc.StartDate, &uc.EndDate, &uc.Status, &uc.Type, &uc.CreatedAt, &uc.TimeZone,
+			&uc.ParticipantCount, &uc.JoinedAt, &uc.UserSteps, &uc.UserCalories, &uc.UserDistance,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan user competition: %w", err)
 		}
+		localizeCompetition(&uc.Competition)
 		competitions = append(competitions, uc)
 	}
 
 	return competitions, nil
 }
+
+// scanCompetition scans competitionColumns followed by any extra columns
+func scanCompetition(row rowScanner, comp *models.Competition, extra ...interface{}) error {
+	var goal goalColumns
+	var divisions divisionColumns
+	var eligibility eligibilityColumns
+	dest := []interface{}{
+		&comp.ID, &comp.Name, &comp.Description, &comp.EntryFee, &comp.PrizePool, &comp.StartDate, &comp.EndDate,
+		&comp.Status, &comp.Type, &comp.CreatorID, &comp.Visibility, &comp.MaxParticipants,
+		&comp.TemplateID, &comp.CreatedAt,
+		&comp.Mode, &goal.metric, &goal.target, &goal.endAfterFinishers,
+		&divisions.count, &divisions.promote, &divisions.relegate,
+		&comp.TimeZone,
+		&eligibility.sources, &eligibility.countries, &eligibility.minActiveDays,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	comp.Goal = goal.goal()
+	comp.Divisions = divisions.divisions()
+	comp.Eligibility = eligibility.rules()
+	localizeCompetition(comp)
+	return nil
+}
+
+// localizeCompetition moves the dates of comp into its own time zone
+func localizeCompetition(comp *models.Competition) {
+	loc, err := loadTimeZone(comp.TimeZone)
+	if err != nil {
+		return
+	}
+	comp.StartDate = comp.StartDate.In(loc)
+	comp.EndDate = comp.EndDate.In(loc)
+}
+
+// goalColumns holds the nullable goal columns shared by competitions and
+// competition templates
+type goalColumns struct {
+	metric            sql.NullString
+	target            sql.NullFloat64
+	endAfterFinishers *int
+}
+
+func (g goalColumns) goal() *models.CompetitionGoal {
+	if !g.metric.Valid {
+		return nil
+	}
+	return &models.CompetitionGoal{
+		Metric:            g.metric.String,
+		Target:            g.target.Float64,
+		EndAfterFinishers: g.endAfterFinishers,
+	}
+}
+
+// goalArgs returns the goal column values to store for goal
+func goalArgs(goal *models.CompetitionGoal) (metric sql.NullString, target sql.NullFloat64, endAfterFinishers *int) {
+	if goal == nil {
+		return
+	}
+	return sql.NullString{String: goal.Metric, Valid: true}, sql.NullFloat64{Float64: goal.Target, Valid: true}, goal.EndAfterFinishers
+}
+
+// validateGoal checks that a goal is given exactly when mode is goal
+func validateGoal(mode string, goal *models.CompetitionGoal) error {
+	switch mode {
+	case models.ModeScore:
+		if goal != nil {
+			return fmt.Errorf("%w: a goal can only be set in goal mode", ErrInvalidGoal)
+		}
+		return nil
+	case models.ModeGoal:
+	default:
+		return fmt.Errorf("%w: mode must be score or goal", ErrInvalidGoal)
+	}
+
+	if goal == nil {
+		return fmt.Errorf("%w: goal mode requires a goal", ErrInvalidGoal)
+	}
+	switch goal.Metric {
+	case "steps", "distance", "calories":
+	default:
+		return fmt.Errorf("%w: goal metric must be steps, distance or calories", ErrInvalidGoal)
+	}
+	if goal.Target <= 0 {
+		return fmt.Errorf("%w: goal target must be positive", ErrInvalidGoal)
+	}
+	if goal.EndAfterFinishers != nil && *goal.EndAfterFinishers <= 0 {
+		return fmt.Errorf("%w: end after finishers must be positive", ErrInvalidGoal)
+	}
+	return nil
+}
+
+// validateCompetitionUpdate checks an update against the competition's
+// current state
+func validateCompetitionUpdate(comp *models.Competition, participants int, req *models.UpdateCompetitionRequest, now time.Time) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidCompetitionUpdate, fmt.Sprintf(format, args...))
+	}
+
+	started := comp.Status == "active" || !now.Before(comp.StartDate)
+
+	if req.Name != nil && *req.Name == "" {
+		return invalid("name cannot be empty")
+	}
+	if req.EntryFee != nil && *req.EntryFee < 0 {
+		return invalid("entry fee cannot be negative")
+	}
+	if req.PrizePool != nil && *req.PrizePool < 0 {
+		return invalid("prize pool cannot be negative")
+	}
+	if req.Visibility != nil && !validVisibility(*req.Visibility) {
+		return invalid("visibility must be one of public, unlisted or private")
+	}
+	if req.Mode != nil || req.Goal != nil {
+		mode, goal := updatedGoal(comp, req)
+		if err := validateGoal(mode, goal); err != nil {
+			return invalid("%v", err)
+		}
+	}
+	if req.MaxParticipants != nil {
+		if *req.MaxParticipants < 0 {
+			return invalid("max participants cannot be negative")
+		}
+		if *req.MaxParticipants > 0 && *req.MaxParticipants < participants {
+			return invalid("max participants cannot be below the current %d participants", participants)
+		}
+	}
+
+	if started {
+		if req.EntryFee != nil && *req.EntryFee != comp.EntryFee {
+			return invalid("entry fee cannot be changed after the competition has started")
+		}
+		if req.StartDate != nil && !req.StartDate.Equal(comp.StartDate) {
+			return invalid("start date cannot be changed after the competition has started")
+		}
+		if req.Type != nil && *req.Type != comp.Type {
+			return invalid("type cannot be changed after the competition has started")
+		}
+		if req.Mode != nil || req.Goal != nil {
+			return invalid("mode and goal cannot be changed after the competition has started")
+		}
+		if req.PrizePool != nil && *req.PrizePool < comp.PrizePool {
+			return invalid("prize pool cannot be reduced after the competition has started")
+		}
+		if req.EndDate != nil && req.EndDate.Before(comp.EndDate) {
+			return invalid("end date can only be extended after the competition has started")
+		}
+	} else {
+		if req.EntryFee != nil && *req.EntryFee != comp.EntryFee && participants > 0 {
+			return invalid("entry fee cannot be changed once participants have joined")
+		}
+		if req.StartDate != nil && req.StartDate.Before(now) {
+			return invalid("start date cannot be in the past")
+		}
+	}
+
+	startDate, endDate := comp.StartDate, comp.EndDate
+	if req.StartDate != nil {
+		startDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		endDate = *req.EndDate
+	}
+	if !startDate.Before(endDate) {
+		return invalid("start date must be before end date")
+	}
+	if req.EndDate != nil && !endDate.After(now) {
+		return invalid("end date must be in the future")
+	}
+
+	return nil
+}
+
+// applyCompetitionUpdate copies the set fields of req onto comp
+func applyCompetitionUpdate(comp *models.Competition, req *models.UpdateCompetitionRequest) {
+	if req.Name != nil {
+		comp.Name = *req.Name
+	}
+	if req.Description != nil {
+		comp.Description = *req.Description
+	}
+	if req.EntryFee != nil {
+		comp.EntryFee = *req.EntryFee
+	}
+	if req.PrizePool != nil {
+		comp.PrizePool = *req.PrizePool
+	}
+	if req.StartDate != nil {
+		comp.StartDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		comp.EndDate = *req.EndDate
+	}
+	if req.Type != nil {
+		comp.Type = *req.Type
+	}
+	if req.Visibility != nil {
+		comp.Visibility = *req.Visibility
+	}
+	if req.MaxParticipants != nil {
+		if *req.MaxParticipants == 0 {
+			comp.MaxParticipants = nil
+		} else {
+			limit := *req.MaxParticipants
+			comp.MaxParticipants = &limit
+		}
+	}
+	if req.Mode != nil || req.Goal != nil {
+		comp.Mode, comp.Goal = updatedGoal(comp, req)
+	}
+}
+
+// updatedGoal returns the mode and goal comp will have after req. Switching
+// to score mode clears the goal.
+func updatedGoal(comp *models.Competition, req *models.UpdateCompetitionRequest) (string, *models.CompetitionGoal) {
+	mode, goal := comp.Mode, comp.Goal
+	if req.Mode != nil {
+		mode = *req.Mode
+	}
+	if req.Goal != nil {
+		goal = req.Goal
+	}
+	if mode == models.ModeScore && req.Goal == nil {
+		goal = nil
+	}
+	return mode, goal
+}
+
+func validVisibility(visibility string) bool {
+	switch visibility {
+	case models.VisibilityPublic, models.VisibilityUnlisted, models.VisibilityPrivate:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+var ErrInvalidFilter = errors.New("invalid competition filter")
+
+// competitionSearchDocument must match the expression of the
+// idx_competitions_search index for full-text search to use it
+const competitionSearchDocument = `to_tsvector('english', c.name || ' ' || COALESCE(c.description, ''))`
+
+// competitionSortColumns maps the sort options accepted by GetCompetitions to
+// SQL expressions. Only these expressions are ever interpolated into the
+// query; every user-supplied value is passed as a bind parameter.
+var competitionSortColumns = map[string]string{
+	"created_at":   "c.created_at",
+	"start_date":   "c.start_date",
+	"prize_pool":   "c.prize_pool",
+	"participants": "pc.participants",
+}
+
+// buildCompetitionsQuery builds the listing query and its arguments for filter
+func buildCompetitionsQuery(filter *models.CompetitionFilter) (string, []interface{}, error) {
+	sortColumn, ok := competitionSortColumns[filter.Sort]
+	if filter.Sort == "" {
+		sortColumn, ok = competitionSortColumns["created_at"], true
+	}
+	if !ok {
+		return "", nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidFilter, filter.Sort)
+	}
+
+	order := strings.ToUpper(filter.Order)
+	switch order {
+	case "":
+		order = "DESC"
+	case "ASC", "DESC":
+	default:
+		return "", nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidFilter)
+	}
+
+	if filter.MinEntryFee != nil && filter.MaxEntryFee != nil && *filter.MinEntryFee > *filter.MaxEntryFee {
+		return "", nil, fmt.Errorf("%w: min entry fee is above max entry fee", ErrInvalidFilter)
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return "", nil, fmt.Errorf("%w: from is after to", ErrInvalidFilter)
+	}
+
+	query := `SELECT ` + competitionColumns + `, pc.participants
+		FROM public.competitions c
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS participants
+			FROM public.competition_participants
+			WHERE competition_id = c.id
+		) pc
+		WHERE (c.visibility = 'public' OR c.creator_id::text = $1 OR EXISTS (
+			SELECT 1 FROM public.competition_participants cp
+			WHERE cp.competition_id = c.id AND cp.user_id::text = $1
+		))
+	`
+
+	args := []interface{}{filter.ViewerID}
+	argPos := 2
+
+	if filter.Status != "" && filter.Status != "all" {
+		query += fmt.Sprintf(" AND c.status = $%d", argPos)
+		args = append(args, filter.Status)
+		argPos++
+	}
+
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		query += fmt.Sprintf(" AND %s @@ plainto_tsquery('english', $%d)", competitionSearchDocument, argPos)
+		args = append(args, q)
+		argPos++
+	}
+
+	if filter.Type != "" {
+		query += fmt.Sprintf(" AND c.type = $%d", argPos)
+		args = append(args, filter.Type)
+		argPos++
+	}
+
+	if filter.MinEntryFee != nil {
+		query += fmt.Sprintf(" AND c.entry_fee >= $%d", argPos)
+		args = append(args, *filter.MinEntryFee)
+		argPos++
+	}
+
+	if filter.MaxEntryFee != nil {
+		query += fmt.Sprintf(" AND c.entry_fee <= $%d", argPos)
+		args = append(args, *filter.MaxEntryFee)
+		argPos++
+	}
+
+	if filter.From != nil {
+		query += fmt.Sprintf(" AND c.end_date >= $%d", argPos)
+		args = append(args, *filter.From)
+		argPos++
+	}
+
+	if filter.To != nil {
+		query += fmt.Sprintf(" AND c.start_date <= $%d", argPos)
+		args = append(args, *filter.To)
+		argPos++
+	}
+
+	if filter.CreatorID != "" {
+		query += fmt.Sprintf(" AND c.creator_id::text = $%d", argPos)
+		args = append(args, filter.CreatorID)
+		argPos++
+	}
+
+	if filter.HasSpots {
+		query += " AND (c.max_participants IS NULL OR pc.participants < c.max_participants)"
+	}
+
+	// Tie-break on id so pages are stable when sort values repeat
+	query += fmt.Sprintf(" ORDER BY %s %s, c.id LIMIT $%d OFFSET $%d", sortColumn, order, argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	return query, args, nil
+}
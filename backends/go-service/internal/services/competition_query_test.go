@@ -0,0 +1,91 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCompetitionsQuery_Defaults(t *testing.T) {
+	query, args, err := buildCompetitionsQuery(&models.CompetitionFilter{ViewerID: "viewer", Status: "all", Limit: 50})
+	require.NoError(t, err)
+
+	assert.NotContains(t, query, "c.status =")
+	assert.Contains(t, query, "ORDER BY c.created_at DESC, c.id LIMIT $2 OFFSET $3")
+	assert.Equal(t, []interface{}{"viewer", 50, 0}, args)
+}
+
+func TestBuildCompetitionsQuery_AllFilters(t *testing.T) {
+	minFee, maxFee := 5.0, 20.0
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	query, args, err := buildCompetitionsQuery(&models.CompetitionFilter{
+		ViewerID:    "viewer",
+		Status:      "upcoming",
+		Query:       "  step challenge ",
+		Type:        "weekly",
+		MinEntryFee: &minFee,
+		MaxEntryFee: &maxFee,
+		From:        &from,
+		To:          &to,
+		CreatorID:   "creator",
+		HasSpots:    true,
+		Sort:        "participants",
+		Order:       "asc",
+		Limit:       10,
+		Offset:      20,
+	})
+	require.NoError(t, err)
+
+	for _, clause := range []string{
+		"c.status = $2",
+		"@@ plainto_tsquery('english', $3)",
+		"c.type = $4",
+		"c.entry_fee >= $5",
+		"c.entry_fee <= $6",
+		"c.end_date >= $7",
+		"c.start_date <= $8",
+		"c.creator_id::text = $9",
+		"pc.participants < c.max_participants",
+		"ORDER BY pc.participants ASC, c.id LIMIT $10 OFFSET $11",
+	} {
+		assert.Contains(t, query, clause)
+	}
+	assert.Equal(t, []interface{}{"viewer", "upcoming", "step challenge", "weekly", minFee, maxFee, from, to, "creator", 10, 20}, args)
+}
+
+func TestBuildCompetitionsQuery_UserInputIsNeverInterpolated(t *testing.T) {
+	injection := "'; DROP TABLE public.competitions; --"
+
+	query, _, err := buildCompetitionsQuery(&models.CompetitionFilter{
+		Status:    injection,
+		Query:     injection,
+		Type:      injection,
+		CreatorID: injection,
+	})
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(query, "DROP TABLE"))
+
+	_, _, err = buildCompetitionsQuery(&models.CompetitionFilter{Sort: injection})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	_, _, err = buildCompetitionsQuery(&models.CompetitionFilter{Order: injection})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestBuildCompetitionsQuery_InvalidRanges(t *testing.T) {
+	low, high := 5.0, 20.0
+	_, _, err := buildCompetitionsQuery(&models.CompetitionFilter{MinEntryFee: &high, MaxEntryFee: &low})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	early := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(24 * time.Hour)
+	_, _, err = buildCompetitionsQuery(&models.CompetitionFilter{From: &late, To: &early})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/payments"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCompetitionUpdate(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	upcoming := &models.Competition{
+		Status:    "upcoming",
+		EntryFee:  10,
+		PrizePool: 100,
+		Type:      "weekly",
+		StartDate: now.Add(24 * time.Hour),
+		EndDate:   now.Add(8 * 24 * time.Hour),
+	}
+	active := &models.Competition{
+		Status:    "active",
+		EntryFee:  10,
+		PrizePool: 100,
+		Type:      "weekly",
+		StartDate: now.Add(-24 * time.Hour),
+		EndDate:   now.Add(6 * 24 * time.Hour),
+	}
+
+	str := func(v string) *string { return &v }
+	num := func(v float64) *float64 { return &v }
+	at := func(v time.Time) *time.Time { return &v }
+	count := func(v int) *int { return &v }
+
+	tests := []struct {
+		name         string
+		comp         *models.Competition
+		participants int
+		req          models.UpdateCompetitionRequest
+		wantErr      bool
+	}{
+		{"rename upcoming", upcoming, 0, models.UpdateCompetitionRequest{Name: str("New name")}, false},
+		{"empty name", upcoming, 0, models.UpdateCompetitionRequest{Name: str("")}, true},
+		{"change fee without participants", upcoming, 0, models.UpdateCompetitionRequest{EntryFee: num(20)}, false},
+		{"change fee with participants", upcoming, 3, models.UpdateCompetitionRequest{EntryFee: num(20)}, true},
+		{"move start later", upcoming, 3, models.UpdateCompetitionRequest{StartDate: at(now.Add(48 * time.Hour))}, false},
+		{"start in the past", upcoming, 0, models.UpdateCompetitionRequest{StartDate: at(now.Add(-time.Hour))}, true},
+		{"start after end", upcoming, 0, models.UpdateCompetitionRequest{StartDate: at(now.Add(10 * 24 * time.Hour))}, true},
+		{"rename active", active, 3, models.UpdateCompetitionRequest{Name: str("New name"), Description: str("desc")}, false},
+		{"change fee after start", active, 3, models.UpdateCompetitionRequest{EntryFee: num(20)}, true},
+		{"same fee after start", active, 3, models.UpdateCompetitionRequest{EntryFee: num(10)}, false},
+		{"change start after start", active, 3, models.UpdateCompetitionRequest{StartDate: at(now)}, true},
+		{"change type after start", active, 3, models.UpdateCompetitionRequest{Type: str("monthly")}, true},
+		{"raise prize pool after start", active, 3, models.UpdateCompetitionRequest{PrizePool: num(200)}, false},
+		{"reduce prize pool after start", active, 3, models.UpdateCompetitionRequest{PrizePool: num(50)}, true},
+		{"extend end after start", active, 3, models.UpdateCompetitionRequest{EndDate: at(active.EndDate.Add(24 * time.Hour))}, false},
+		{"shorten end after start", active, 3, models.UpdateCompetitionRequest{EndDate: at(active.EndDate.Add(-24 * time.Hour))}, true},
+		{"set capacity above participants", upcoming, 3, models.UpdateCompetitionRequest{MaxParticipants: count(5)}, false},
+		{"set capacity below participants", upcoming, 3, models.UpdateCompetitionRequest{MaxParticipants: count(2)}, true},
+		{"remove capacity", upcoming, 3, models.UpdateCompetitionRequest{MaxParticipants: count(0)}, false},
+		{"negative capacity", upcoming, 0, models.UpdateCompetitionRequest{MaxParticipants: count(-1)}, true},
+		{"make private", active, 3, models.UpdateCompetitionRequest{Visibility: str("private")}, false},
+		{"unknown visibility", upcoming, 0, models.UpdateCompetitionRequest{Visibility: str("secret")}, true},
+		{"switch to goal mode", upcoming, 3, models.UpdateCompetitionRequest{Mode: str("goal"), Goal: &models.CompetitionGoal{Metric: "steps", Target: 250000}}, false},
+		{"goal mode without goal", upcoming, 3, models.UpdateCompetitionRequest{Mode: str("goal")}, true},
+		{"change goal after start", active, 3, models.UpdateCompetitionRequest{Goal: &models.CompetitionGoal{Metric: "steps", Target: 1000}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCompetitionUpdate(tt.comp, tt.participants, &tt.req, now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCompetitionUpdate)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApplyCompetitionUpdate_MaxParticipants(t *testing.T) {
+	limit := 10
+	comp := &models.Competition{Mode: models.ModeScore, MaxParticipants: &limit}
+
+	raised := 20
+	applyCompetitionUpdate(comp, &models.UpdateCompetitionRequest{MaxParticipants: &raised})
+	assert.Equal(t, 20, *comp.MaxParticipants)
+
+	removed := 0
+	applyCompetitionUpdate(comp, &models.UpdateCompetitionRequest{MaxParticipants: &removed})
+	assert.Nil(t, comp.MaxParticipants)
+}
+
+func TestCreateCompetition_RejectsInvalidRequests(t *testing.T) {
+	s := &CompetitionService{}
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	zero := 0
+
+	tests := []struct {
+		name   string
+		modify func(req *models.CreateCompetitionRequest)
+		want   error
+	}{
+		{"zero participant cap", func(req *models.CreateCompetitionRequest) { req.MaxParticipants = &zero }, ErrInvalidCompetition},
+		{"unknown visibility", func(req *models.CreateCompetitionRequest) { req.Visibility = "secret" }, ErrInvalidCompetition},
+		{"unknown mode", func(req *models.CreateCompetitionRequest) { req.Mode = "fastest" }, ErrInvalidGoal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.CreateCompetitionRequest{
+				Name:      "June Steps",
+				StartDate: start,
+				EndDate:   start.AddDate(0, 0, 7),
+			}
+			tt.modify(req)
+			_, err := s.CreateCompetition(context.Background(), req)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestValidateGoal(t *testing.T) {
+	zero := 0
+	assert.NoError(t, validateGoal(models.ModeScore, nil))
+	assert.NoError(t, validateGoal(models.ModeGoal, &models.CompetitionGoal{Metric: "distance", Target: 100}))
+	assert.ErrorIs(t, validateGoal("fastest", nil), ErrInvalidGoal)
+	assert.ErrorIs(t, validateGoal(models.ModeScore, &models.CompetitionGoal{Metric: "steps", Target: 1}), ErrInvalidGoal)
+	assert.ErrorIs(t, validateGoal(models.ModeGoal, nil), ErrInvalidGoal)
+	assert.ErrorIs(t, validateGoal(models.ModeGoal, &models.CompetitionGoal{Metric: "floors", Target: 100}), ErrInvalidGoal)
+	assert.ErrorIs(t, validateGoal(models.ModeGoal, &models.CompetitionGoal{Metric: "steps", Target: 0}), ErrInvalidGoal)
+	assert.ErrorIs(t, validateGoal(models.ModeGoal, &models.CompetitionGoal{Metric: "steps", Target: 100, EndAfterFinishers: &zero}), ErrInvalidGoal)
+}
+
+func TestApplyProfilePrivacy(t *testing.T) {
+	participant := func() *models.CompetitionParticipant {
+		return &models.CompetitionParticipant{UserID: "user-1", Name: "Alex", Avatar: "a.png", Country: "NZ"}
+	}
+
+	p := participant()
+	applyProfilePrivacy(p, models.ProfilePublic, "user-2", false)
+	assert.Equal(t, "Alex", p.Name)
+	assert.False(t, p.Private)
+
+	p = participant()
+	applyProfilePrivacy(p, models.ProfilePrivate, "user-2", false)
+	assert.Equal(t, privateParticipantName, p.Name)
+	assert.Empty(t, p.Avatar)
+	assert.Empty(t, p.Country)
+	assert.True(t, p.Private)
+
+	p = participant()
+	applyProfilePrivacy(p, models.ProfilePrivate, "user-1", false)
+	assert.Equal(t, "Alex", p.Name, "users see their own profile")
+	assert.True(t, p.Private)
+
+	p = participant()
+	applyProfilePrivacy(p, models.ProfilePrivate, "user-2", true)
+	assert.Equal(t, "Alex", p.Name, "admins see every profile")
+}
+
+// competitionRow is comp as selected by competitionColumns, leaving out its
+// participant limit and goal, division and eligibility settings
+func competitionRow(comp *models.Competition) []driver.Value {
+	return []driver.Value{
+		comp.ID, comp.Name, comp.Description, comp.EntryFee, comp.PrizePool, comp.StartDate, comp.EndDate,
+		comp.Status, comp.Type, comp.CreatorID, comp.Visibility, nil,
+		comp.TemplateID, comp.CreatedAt,
+		"score", nil, nil, nil,
+		nil, int64(0), int64(0),
+		"UTC",
+		"{}", "{}", int64(0),
+	}
+}
+
+func TestLeaveCompetition_RefundsDespiteLeaderboardFailure(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	cache := NewCacheService(client)
+
+	provider := payments.NewFakePaymentProvider()
+	charge, err := provider.Charge(context.Background(), &payments.ChargeRequest{UserID: "user-1", Amount: 10})
+	require.NoError(t, err)
+
+	comp := &models.Competition{
+		ID: "comp-1", Name: "June Steps", EntryFee: 10, Status: "active", Visibility: "public",
+		StartDate: time.Now().Add(-24 * time.Hour), EndDate: time.Now().Add(6 * 24 * time.Hour),
+	}
+	db, _ := newFakeDB(t,
+		fakeQuery{match: "FOR UPDATE", rows: [][]driver.Value{competitionRow(comp)}},
+		fakeQuery{match: "DELETE FROM public.competition_waitlist"},
+		fakeQuery{match: "SELECT t.id, t.user_id, t.amount", rows: [][]driver.Value{{"entry-1", "user-1", 10.0}}},
+		fakeQuery{match: "INSERT INTO public.transactions", affected: 1},
+		fakeQuery{match: "DELETE FROM public.competition_participants", affected: 1},
+		fakeQuery{match: "SELECT COALESCE(p.transaction_ref", rows: [][]driver.Value{{charge.Reference}}},
+		fakeQuery{match: "SET status = 'completed'", affected: 1},
+	)
+	service := NewCompetitionService(db, cache, NewLeaderboardService(cache, client), provider, nil,
+		models.RefundPolicy{BeforeStartPercent: 100, AfterStartPercent: 25}, "")
+
+	// Redis goes away after the participant is removed from the database
+	mr.Close()
+
+	_, err = service.LeaveCompetition(context.Background(), "comp-1", "user-1")
+	assert.Error(t, err)
+	assert.Equal(t, 2.5, provider.Refunded(charge.Reference))
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/connectors"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+)
+
+var (
+	ErrUnknownConnector   = errors.New("fitness provider is not available")
+	ErrConnectionNotFound = errors.New("fitness connection not found")
+	ErrInvalidOAuthState  = errors.New("invalid or expired authorization state")
+)
+
+const (
+	// oauthStateTTL is how long a user has to grant access once they start
+	// connecting a provider
+	oauthStateTTL = 10 * time.Minute
+
+	// tokenRefreshMargin is how long before expiry access tokens are renewed
+	tokenRefreshMargin = 5 * time.Minute
+
+	// maxConnectorDays bounds how many days one pull covers
+	maxConnectorDays = 31
+
+	// connectorBatchSize is how many connections one tick pulls
+	connectorBatchSize = 50
+)
+
+// connectorState is what an OAuth state parameter stands for
+type connectorState struct {
+	UserID   string `json:"user_id"`
+	Provider string `json:"provider"`
+}
+
+// connection is a stored connection with its tokens
+type connection struct {
+	models.FitnessConnection
+	token connectors.Token
+}
+
+// ConnectorService pulls daily totals from fitness providers that users have
+// connected with OAuth. Tokens are stored per user and provider and renewed
+// before they expire. Connections are pulled every interval: each claims its
+// next pull time first, so several instances can run the connector at once
+// without pulling a connection twice. Pulled days are written through
+// FitnessService as the provider's source, replacing what the last pull set.
+type ConnectorService struct {
+	db        *sql.DB
+	cache     *CacheService
+	fitness   *FitnessService
+	providers map[string]connectors.Provider
+	logger    *utils.Logger
+	interval  time.Duration
+}
+
+func NewConnectorService(db *sql.DB, cache *CacheService, fitness *FitnessService, providers map[string]connectors.Provider, logger *utils.Logger, interval time.Duration) *ConnectorService {
+	return &ConnectorService{
+		db:        db,
+		cache:     cache,
+		fitness:   fitness,
+		providers: providers,
+		logger:    logger,
+		interval:  interval,
+	}
+}
+
+// GetConnectors lists the providers that are configured and the user's
+// connections to them
+func (s *ConnectorService) GetConnectors(ctx context.Context, userID string) (*models.FitnessConnectors, error) {
+	result := &models.FitnessConnectors{Available: []string{}, Connections: []models.FitnessConnection{}}
+	for name := range s.providers {
+		result.Available = append(result.Available, name)
+	}
+	sort.Strings(result.Available)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+connectionColumns+` FROM public.fitness_connections WHERE user_id = $1 ORDER BY provider`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fitness connections: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c, err := scanConnection(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Connections = append(result.Connections, c.FitnessConnection)
+	}
+	return result, rows.Err()
+}
+
+// AuthorizeConnector starts connecting a provider, returning where to send
+// the user to grant access
+func (s *ConnectorService) AuthorizeConnector(ctx context.Context, userID, provider string) (*models.ConnectorAuthorization, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownConnector, provider)
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(b)
+	if err := s.cache.Set(ctx, s.getStateKey(state), connectorState{UserID: userID, Provider: provider}, oauthStateTTL); err != nil {
+		return nil, err
+	}
+	return &models.ConnectorAuthorization{Provider: provider, URL: p.AuthURL(state)}, nil
+}
+
+// ConnectProvider completes a connection with the authorization code the
+// provider redirected back with, then pulls the user's data once. A state is
+// only good once, and only for the user and provider it was issued to.
+// Connecting again replaces the stored tokens.
+func (s *ConnectorService) ConnectProvider(ctx context.Context, userID, provider string, req *models.ConnectProviderRequest) (*models.FitnessConnection, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownConnector, provider)
+	}
+
+	var state connectorState
+	key := s.getStateKey(req.State)
+	if err := s.cache.Get(ctx, key, &state); err == redis.Nil {
+		return nil, ErrInvalidOAuthState
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return nil, err
+	}
+	if state.UserID != userID || state.Provider != provider {
+		return nil, ErrInvalidOAuthState
+	}
+
+	token, err := p.Exchange(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO public.fitness_connections (user_id, provider, access_token, refresh_token, token_expires_at, scope, status, next_sync_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'active', NOW())
+		ON CONFLICT (user_id, provider) DO UPDATE SET
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			token_expires_at = EXCLUDED.token_expires_at,
+			scope = EXCLUDED.scope,
+			status = 'active',
+			next_sync_at = NOW(),
+			last_error = NULL,
+			updated_at = NOW()
+		RETURNING `+connectionColumns,
+		userID, provider, token.AccessToken, token.RefreshToken, nullTime(token.ExpiresAt), token.Scope)
+	c, err := scanConnection(row)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed first pull is recorded on the connection and retried later
+	if err := s.pull(ctx, c); err != nil {
+		s.logger.Warnf("Initial %s pull for user %s failed: %v", provider, userID, err)
+	}
+	return &c.FitnessConnection, nil
+}
+
+// DisconnectProvider forgets a user's connection and tokens. Data already
+// pulled stays.
+func (s *ConnectorService) DisconnectProvider(ctx context.Context, userID, provider string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM public.fitness_connections WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return fmt.Errorf("failed to delete fitness connection: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConnectionNotFound
+	}
+	return nil
+}
+
+// Run pulls due connections every interval until ctx is cancelled
+func (s *ConnectorService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			s.logger.Errorf("Fitness connector tick failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick claims the connections that are due and pulls each. A failed pull is
+// recorded on its connection and doesn't stop the others.
+func (s *ConnectorService) Tick(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE public.fitness_connections SET next_sync_at = NOW() + $1 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM public.fitness_connections
+			WHERE status = 'active' AND next_sync_at <= NOW()
+			ORDER BY next_sync_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+connectionColumns,
+		int64(s.interval/time.Second), connectorBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim fitness connections: %w", err)
+	}
+	var due []*connection
+	for rows.Next() {
+		c, err := scanConnection(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range due {
+		if _, ok := s.providers[c.Provider]; !ok {
+			continue
+		}
+		if err := s.pull(ctx, c); err != nil {
+			s.logger.Errorf("Failed to pull %s for user %s: %v", c.Provider, c.UserID, err)
+		}
+	}
+	return nil
+}
+
+// pull syncs the days of the user's active competitions that the provider
+// may have new data for, and records the outcome on the connection. Tokens
+// the provider rejects put the connection on hold until the user connects
+// again.
+func (s *ConnectorService) pull(ctx context.Context, c *connection) error {
+	err := s.pullCompetitions(ctx, c)
+	status := models.ConnectionActive
+	var lastError interface{}
+	if err != nil {
+		lastError = err.Error()
+		if errors.Is(err, connectors.ErrUnauthorized) {
+			status = models.ConnectionReauthorize
+		}
+	}
+
+	_, updateErr := s.db.ExecContext(ctx, `
+		UPDATE public.fitness_connections
+		SET status = $2, last_error = $3,
+			last_synced_at = CASE WHEN $3::text IS NULL THEN NOW() ELSE last_synced_at END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, c.ID, status, lastError)
+	if updateErr != nil {
+		return fmt.Errorf("failed to update fitness connection: %w", updateErr)
+	}
+	return err
+}
+
+func (s *ConnectorService) pullCompetitions(ctx context.Context, c *connection) error {
+	p := s.providers[c.Provider]
+	accessToken, err := s.freshToken(ctx, c, p)
+	if err != nil {
+		return err
+	}
+
+	competitionIDs, err := activeCompetitionIDs(ctx, s.db, c.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, competitionID := range competitionIDs {
+		loc, err := competitionLocation(ctx, s.cache, s.db, competitionID)
+		if err != nil {
+			return err
+		}
+		window, err := loadCompetitionWindow(ctx, s.db, competitionID, loc)
+		if err != nil {
+			return err
+		}
+		first, last, ok := connectorRange(window, c.LastSyncedAt, time.Now(), loc)
+		if !ok {
+			continue
+		}
+
+		days, err := p.FetchDaily(ctx, accessToken, first, last, loc)
+		if err != nil {
+			return err
+		}
+		for _, day := range days {
+			_, err := s.fitness.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+				UserID:        c.UserID,
+				CompetitionID: competitionID,
+				Steps:         day.Steps,
+				Distance:      day.Distance,
+				Calories:      day.Calories,
+				ActiveMinutes: day.ActiveMinutes,
+				Source:        c.Provider,
+				Date:          day.Date,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// freshToken returns the connection's access token, renewing and storing it
+// first when it is about to expire
+func (s *ConnectorService) freshToken(ctx context.Context, c *connection, p connectors.Provider) (string, error) {
+	if c.token.ExpiresAt.IsZero() || time.Until(c.token.ExpiresAt) > tokenRefreshMargin {
+		return c.token.AccessToken, nil
+	}
+	if c.token.RefreshToken == "" {
+		return "", fmt.Errorf("%w: access token expired and there is no refresh token", connectors.ErrUnauthorized)
+	}
+
+	token, err := p.Refresh(ctx, c.token.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE public.fitness_connections
+		SET access_token = $2, refresh_token = $3, token_expires_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`, c.ID, token.AccessToken, token.RefreshToken, nullTime(token.ExpiresAt))
+	if err != nil {
+		return "", fmt.Errorf("failed to store refreshed token: %w", err)
+	}
+	c.token = *token
+	return token.AccessToken, nil
+}
+
+// activeCompetitionIDs returns the active competitions a user takes part in,
+// which data pulled or pushed from providers is synced to
+func activeCompetitionIDs(ctx context.Context, db dbExecutor, userID string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.id FROM public.competition_participants cp
+		JOIN public.competitions c ON c.id = cp.competition_id
+		WHERE cp.user_id = $1 AND c.status = 'active'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active competitions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan competition: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// connectorRange returns the days to pull for a competition: from the day
+// before the last pull, as providers fill in days late, or the competition's
+// first day, up to today or its last day. At most maxConnectorDays are
+// pulled at once. ok is false when there is nothing to pull.
+func connectorRange(window *competitionWindow, lastSynced *time.Time, now time.Time, loc *time.Location) (first, last time.Time, ok bool) {
+	first, last = window.first, competitionDay(now, loc)
+	if window.last.Before(last) {
+		last = window.last
+	}
+	if lastSynced != nil {
+		if since := competitionDay(*lastSynced, loc).AddDate(0, 0, -1); since.After(first) {
+			first = since
+		}
+	}
+	if earliest := last.AddDate(0, 0, -(maxConnectorDays - 1)); first.Before(earliest) {
+		first = earliest
+	}
+	return first, last, !first.After(last)
+}
+
+const connectionColumns = `id, user_id, provider, status, scope, last_synced_at, COALESCE(last_error, ''), created_at,
+	access_token, refresh_token, token_expires_at`
+
+func scanConnection(row rowScanner) (*connection, error) {
+	var c connection
+	var lastSynced, expiresAt sql.NullTime
+	err := row.Scan(&c.ID, &c.UserID, &c.Provider, &c.Status, &c.Scope, &lastSynced, &c.LastError, &c.CreatedAt,
+		&c.token.AccessToken, &c.token.RefreshToken, &expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan fitness connection: %w", err)
+	}
+	if lastSynced.Valid {
+		c.LastSyncedAt = &lastSynced.Time
+	}
+	c.token.ExpiresAt = expiresAt.Time
+	c.token.Scope = c.Scope
+	return &c, nil
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (s *ConnectorService) getStateKey(state string) string {
+	return fmt.Sprintf("connector_state:%s", state)
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectorRange(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, loc) }
+	at := func(d, hour int) *time.Time {
+		t := time.Date(2024, 6, d, hour, 0, 0, 0, loc)
+		return &t
+	}
+	window := &competitionWindow{first: day(1), last: day(20)}
+
+	tests := []struct {
+		name        string
+		window      *competitionWindow
+		lastSynced  *time.Time
+		now         time.Time
+		first, last time.Time
+		ok          bool
+	}{
+		{"first pull covers the competition so far", window, nil, *at(10, 9), day(1), day(10), true},
+		{"later pulls go back a day", window, at(8, 23), *at(10, 9), day(7), day(10), true},
+		{"never before the competition", window, at(1, 8), *at(2, 9), day(1), day(2), true},
+		{"not past the last day", window, at(19, 8), *at(25, 9), day(18), day(20), true},
+		{"nothing before the start", window, nil, time.Date(2024, 5, 28, 9, 0, 0, 0, loc), day(1), time.Date(2024, 5, 28, 0, 0, 0, 0, loc), false},
+		{"long competitions are capped", &competitionWindow{first: time.Date(2024, 1, 1, 0, 0, 0, 0, loc), last: day(30)}, nil, *at(10, 9),
+			day(10).AddDate(0, 0, -(maxConnectorDays - 1)), day(10), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, last, ok := connectorRange(tt.window, tt.lastSynced, tt.now, loc)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.first, first)
+				assert.Equal(t, tt.last, last)
+			}
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+var (
+	ErrNoDivisions      = errors.New("competition does not use divisions")
+	ErrDivisionNotFound = errors.New("division not found")
+	ErrInvalidDivisions = errors.New("invalid divisions")
+)
+
+// Movements recorded in division history at the end of a season
+const (
+	DivisionPromoted  = "promoted"
+	DivisionRelegated = "relegated"
+	DivisionStayed    = "stayed"
+)
+
+// divisionRatingDays is how far back a newcomer's activity is averaged to
+// place them
+const divisionRatingDays = 30
+
+const maxDivisions = 10
+
+// DivisionService places competition participants into skill-based
+// divisions and carries them between seasons. A season is one instance of a
+// recurring template; a user's division in the next instance comes from
+// where they finished in the previous one. Newcomers are placed by their
+// average daily steps over the last divisionRatingDays.
+type DivisionService struct {
+	db            *sql.DB
+	competitions  *CompetitionService
+	notifications *NotificationService
+}
+
+func NewDivisionService(db *sql.DB, competitions *CompetitionService, notifications *NotificationService) *DivisionService {
+	return &DivisionService{
+		db:            db,
+		competitions:  competitions,
+		notifications: notifications,
+	}
+}
+
+// GetDivisions lists a competition's divisions with their size and prize pool
+func (s *DivisionService) GetDivisions(ctx context.Context, competitionID, viewerID string, isAdmin bool) ([]models.Division, error) {
+	comp, err := s.competitions.GetCompetitionByID(ctx, competitionID, viewerID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if comp.Divisions == nil {
+		return nil, ErrNoDivisions
+	}
+
+	query := `
+		SELECT division, COUNT(*)
+		FROM public.competition_participants
+		WHERE competition_id = $1 AND division IS NOT NULL
+		GROUP BY division
+	`
+	rows, err := s.db.QueryContext(ctx, query, competitionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count division members: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var division, count int
+		if err := rows.Scan(&division, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan division count: %w", err)
+		}
+		counts[division] = count
+	}
+
+	divisions := make([]models.Division, 0, comp.Divisions.Count)
+	for d := 1; d <= comp.Divisions.Count; d++ {
+		divisions = append(divisions, models.Division{
+			CompetitionID: competitionID,
+			Division:      d,
+			Participants:  counts[d],
+			PrizePool:     divisionPrizePool(comp),
+		})
+	}
+
+	return divisions, nil
+}
+
+// GetDivisionLeaderboard returns the standings within one division
+func (s *DivisionService) GetDivisionLeaderboard(ctx context.Context, competitionID string, division int, viewerID string, isAdmin bool) (*models.Leaderboard, error) {
+	comp, err := s.competitions.GetCompetitionByID(ctx, competitionID, viewerID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if comp.Divisions == nil {
+		return nil, ErrNoDivisions
+	}
+	if division < 1 || division > comp.Divisions.Count {
+		return nil, ErrDivisionNotFound
+	}
+
+	return s.divisionStandings(ctx, s.db, comp.ID, division)
+}
+
+// GetDivisionHistory lists a user's past division finishes, newest first
+func (s *DivisionService) GetDivisionHistory(ctx context.Context, userID string) ([]models.DivisionHistoryEntry, error) {
+	query := `
+		SELECT dh.competition_id, c.name, COALESCE(dh.template_id::text, ''), dh.user_id,
+			dh.division, dh.rank, dh.movement, dh.next_division, dh.created_at
+		FROM public.division_history dh
+		JOIN public.competitions c ON c.id = dh.competition_id
+		WHERE dh.user_id = $1
+		ORDER BY dh.created_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query division history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.DivisionHistoryEntry{}
+	for rows.Next() {
+		var h models.DivisionHistoryEntry
+		if err := rows.Scan(
+			&h.CompetitionID, &h.CompetitionName, &h.TemplateID, &h.UserID,
+			&h.Division, &h.Rank, &h.Movement, &h.NextDivision, &h.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan division history: %w", err)
+		}
+		history = append(history, h)
+	}
+
+	return history, nil
+}
+
+// AssignDivisions places every participant of a competition that is starting.
+// Returning users keep the division their last season earned them; the rest
+// fill the divisions from the top by activity rating.
+func (s *DivisionService) AssignDivisions(ctx context.Context, tx *sql.Tx, comp *models.Competition) error {
+	if comp.Divisions == nil {
+		return nil
+	}
+
+	query := `
+		SELECT cp.user_id,
+			COALESCE((
+				SELECT AVG(daily) FROM (
+					SELECT MAX(fd.steps) AS daily
+					FROM public.fitness_daily fd
+					WHERE fd.user_id = cp.user_id AND fd.date >= $2 AND fd.date < $3
+					GROUP BY fd.date
+				) d
+			), 0),
+			(
+				SELECT dh.next_division FROM public.division_history dh
+				WHERE dh.user_id = cp.user_id AND dh.template_id = NULLIF($4, '')::uuid
+				ORDER BY dh.created_at DESC
+				LIMIT 1
+			)
+		FROM public.competition_participants cp
+		WHERE cp.competition_id = $1
+	`
+	ratingFrom := comp.StartDate.AddDate(0, 0, -divisionRatingDays)
+	rows, err := tx.QueryContext(ctx, query, comp.ID, ratingFrom, comp.StartDate, comp.TemplateID)
+	if err != nil {
+		return fmt.Errorf("failed to load division ratings: %w", err)
+	}
+
+	var members []divisionMember
+	for rows.Next() {
+		var m divisionMember
+		var carried sql.NullInt64
+		if err := rows.Scan(&m.userID, &m.rating, &carried); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan division rating: %w", err)
+		}
+		m.carried = int(carried.Int64)
+		members = append(members, m)
+	}
+	rows.Close()
+
+	updateQuery := `UPDATE public.competition_participants SET division = $1 WHERE competition_id = $2 AND user_id = $3`
+	for userID, division := range assignDivisions(members, comp.Divisions.Count) {
+		if _, err := tx.ExecContext(ctx, updateQuery, division, comp.ID, userID); err != nil {
+			return fmt.Errorf("failed to assign division: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CloseSeason records each participant's finish and next division, notifies
+// users who move, and returns the prizes won in every division
+func (s *DivisionService) CloseSeason(ctx context.Context, tx *sql.Tx, comp *models.Competition) ([]models.Prize, error) {
+	if comp.Divisions == nil {
+		return nil, nil
+	}
+
+	historyQuery := `
+		INSERT INTO public.division_history (competition_id, template_id, user_id, division, rank, movement, next_division, created_at)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (competition_id, user_id) DO NOTHING
+	`
+
+	var prizes []models.Prize
+	now := time.Now()
+	for d := 1; d <= comp.Divisions.Count; d++ {
+		standings, err := s.divisionStandings(ctx, tx, comp.ID, d)
+		if err != nil {
+			return nil, err
+		}
+
+		if pool := divisionPrizePool(comp); pool > 0 {
+			prizes = append(prizes, topThreePrizes(comp.ID, d, eligibleEntries(standings.Entries), pool)...)
+		}
+
+		ranked := make([]string, len(standings.Entries))
+		for i, e := range standings.Entries {
+			ranked[i] = e.UserID
+		}
+
+		for _, move := range divisionMovements(d, comp.Divisions, ranked) {
+			if _, err := tx.ExecContext(ctx, historyQuery,
+				comp.ID, comp.TemplateID, move.userID, d, move.rank, move.movement, move.nextDivision, now,
+			); err != nil {
+				return nil, fmt.Errorf("failed to record division history: %w", err)
+			}
+
+			if move.movement == DivisionStayed || comp.TemplateID == "" {
+				continue
+			}
+			if err := s.notifications.NotifyUser(ctx, tx, &models.Notification{
+				UserID:        move.userID,
+				Type:          "division_" + move.movement,
+				Title:         fmt.Sprintf("You've been %s", move.movement),
+				Message:       fmt.Sprintf("You finished %s in division %d of %s and will compete in division %d next season.", ordinal(move.rank), d, comp.Name, move.nextDivision),
+				CompetitionID: comp.ID,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return prizes, nil
+}
+
+func (s *DivisionService) divisionStandings(ctx context.Context, db dbExecutor, competitionID string, division int) (*models.Leaderboard, error) {
+	userIDs, err := divisionMembers(ctx, db, competitionID, division)
+	if err != nil {
+		return nil, err
+	}
+	return s.competitions.leaderboard.GetLeaderboardForUsers(ctx, competitionID, userIDs)
+}
+
+func divisionMembers(ctx context.Context, db dbExecutor, competitionID string, division int) ([]string, error) {
+	query := `SELECT user_id FROM public.competition_participants WHERE competition_id = $1 AND division = $2`
+	rows, err := db.QueryContext(ctx, query, competitionID, division)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query division members: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan division member: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, nil
+}
+
+// lateJoinerDivision places someone joining a competition whose divisions
+// were assigned at the start: a returning user keeps the division their last
+// season earned them, anyone else starts in the bottom division
+func lateJoinerDivision(ctx context.Context, tx *sql.Tx, comp *models.Competition, userID string) (*int, error) {
+	if comp.Divisions == nil || comp.Status != "active" {
+		return nil, nil
+	}
+
+	division := comp.Divisions.Count
+	if comp.TemplateID != "" {
+		query := `
+			SELECT next_division FROM public.division_history
+			WHERE user_id = $1 AND template_id = $2
+			ORDER BY created_at DESC
+			LIMIT 1
+		`
+		var carried int
+		err := tx.QueryRowContext(ctx, query, userID, comp.TemplateID).Scan(&carried)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to look up division history: %w", err)
+		}
+		if err == nil {
+			division = clampDivision(carried, comp.Divisions.Count)
+		}
+	}
+
+	return &division, nil
+}
+
+// divisionMember is a participant to be placed at the start of a season
+type divisionMember struct {
+	userID  string
+	rating  float64 // average daily steps before the season
+	carried int     // division earned last season, 0 for newcomers
+}
+
+// assignDivisions maps each member to a division. Returning members keep
+// their carried division; newcomers, best rated first, fill divisions from
+// the top up to an even share of the members each.
+func assignDivisions(members []divisionMember, count int) map[string]int {
+	assigned := make(map[string]int, len(members))
+	if count < 1 || len(members) == 0 {
+		return assigned
+	}
+
+	target := (len(members) + count - 1) / count
+	sizes := make([]int, count+1)
+
+	var newcomers []divisionMember
+	for _, m := range members {
+		if m.carried > 0 {
+			d := clampDivision(m.carried, count)
+			assigned[m.userID] = d
+			sizes[d]++
+			continue
+		}
+		newcomers = append(newcomers, m)
+	}
+
+	sort.SliceStable(newcomers, func(i, j int) bool {
+		if newcomers[i].rating != newcomers[j].rating {
+			return newcomers[i].rating > newcomers[j].rating
+		}
+		return newcomers[i].userID < newcomers[j].userID
+	})
+
+	d := 1
+	for _, m := range newcomers {
+		for d < count && sizes[d] >= target {
+			d++
+		}
+		assigned[m.userID] = d
+		sizes[d]++
+	}
+
+	return assigned
+}
+
+// divisionMove is one participant's end-of-season result
+type divisionMove struct {
+	userID       string
+	rank         int
+	movement     string
+	nextDivision int
+}
+
+// divisionMovements applies promotion and relegation to the final standings
+// of a division, given as user IDs in rank order. The top division cannot
+// promote and the bottom one cannot relegate; when a small division would
+// both promote and relegate someone, promotion wins.
+func divisionMovements(division int, divisions *models.CompetitionDivisions, ranked []string) []divisionMove {
+	moves := make([]divisionMove, len(ranked))
+	for i, userID := range ranked {
+		move := divisionMove{userID: userID, rank: i + 1, movement: DivisionStayed, nextDivision: division}
+		switch {
+		case division > 1 && i < divisions.PromoteCount:
+			move.movement = DivisionPromoted
+			move.nextDivision = division - 1
+		case division < divisions.Count && i >= len(ranked)-divisions.RelegateCount:
+			move.movement = DivisionRelegated
+			move.nextDivision = division + 1
+		}
+		moves[i] = move
+	}
+	return moves
+}
+
+// divisionPrizePool is each division's equal share of the prize pool
+func divisionPrizePool(comp *models.Competition) float64 {
+	if comp.Divisions == nil || comp.Divisions.Count == 0 {
+		return comp.PrizePool
+	}
+	return comp.PrizePool / float64(comp.Divisions.Count)
+}
+
+func clampDivision(division, count int) int {
+	if division < 1 {
+		return 1
+	}
+	if division > count {
+		return count
+	}
+	return division
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// validateDivisions checks the division settings of a competition or template
+func validateDivisions(divisions *models.CompetitionDivisions) error {
+	if divisions == nil {
+		return nil
+	}
+	if divisions.Count < 2 || divisions.Count > maxDivisions {
+		return fmt.Errorf("%w: division count must be between 2 and %d", ErrInvalidDivisions, maxDivisions)
+	}
+	if divisions.PromoteCount < 0 || divisions.RelegateCount < 0 {
+		return fmt.Errorf("%w: promote and relegate counts cannot be negative", ErrInvalidDivisions)
+	}
+	return nil
+}
+
+// divisionColumns holds the division columns shared by competitions and
+// competition templates
+type divisionColumns struct {
+	count    *int
+	promote  int
+	relegate int
+}
+
+func (d divisionColumns) divisions() *models.CompetitionDivisions {
+	if d.count == nil {
+		return nil
+	}
+	return &models.CompetitionDivisions{
+		Count:         *d.count,
+		PromoteCount:  d.promote,
+		RelegateCount: d.relegate,
+	}
+}
+
+// divisionArgs returns the division column values to store for divisions
+func divisionArgs(divisions *models.CompetitionDivisions) (count *int, promote, relegate int) {
+	if divisions == nil {
+		return
+	}
+	return &divisions.Count, divisions.PromoteCount, divisions.RelegateCount
+}
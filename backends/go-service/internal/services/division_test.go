@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignDivisions(t *testing.T) {
+	t.Run("newcomers fill divisions from the top by rating", func(t *testing.T) {
+		members := []divisionMember{
+			{userID: "walker", rating: 3000},
+			{userID: "runner", rating: 15000},
+			{userID: "hiker", rating: 9000},
+			{userID: "couch", rating: 0},
+			{userID: "jogger", rating: 11000},
+		}
+
+		assigned := assignDivisions(members, 2)
+
+		assert.Equal(t, map[string]int{
+			"runner": 1, "jogger": 1, "hiker": 1,
+			"walker": 2, "couch": 2,
+		}, assigned)
+	})
+
+	t.Run("returning members keep their earned division", func(t *testing.T) {
+		members := []divisionMember{
+			{userID: "promoted", rating: 100, carried: 1},
+			{userID: "relegated", rating: 20000, carried: 2},
+			{userID: "new-fast", rating: 18000},
+			{userID: "new-slow", rating: 500},
+		}
+
+		assigned := assignDivisions(members, 2)
+
+		assert.Equal(t, 1, assigned["promoted"])
+		assert.Equal(t, 2, assigned["relegated"])
+		assert.Equal(t, 1, assigned["new-fast"])
+		assert.Equal(t, 2, assigned["new-slow"])
+	})
+
+	t.Run("carried divisions are clamped when the count shrinks", func(t *testing.T) {
+		assigned := assignDivisions([]divisionMember{{userID: "u", carried: 5}}, 3)
+		assert.Equal(t, 3, assigned["u"])
+	})
+}
+
+func TestDivisionMovements(t *testing.T) {
+	divisions := &models.CompetitionDivisions{Count: 3, PromoteCount: 1, RelegateCount: 2}
+	ranked := []string{"a", "b", "c", "d", "e"}
+
+	movement := func(moves []divisionMove) map[string]string {
+		m := make(map[string]string)
+		for _, move := range moves {
+			m[move.userID] = move.movement
+		}
+		return m
+	}
+
+	t.Run("top division only relegates", func(t *testing.T) {
+		moves := divisionMovements(1, divisions, ranked)
+		assert.Equal(t, map[string]string{
+			"a": DivisionStayed, "b": DivisionStayed, "c": DivisionStayed,
+			"d": DivisionRelegated, "e": DivisionRelegated,
+		}, movement(moves))
+		assert.Equal(t, 2, moves[4].nextDivision)
+		assert.Equal(t, 5, moves[4].rank)
+	})
+
+	t.Run("middle division promotes and relegates", func(t *testing.T) {
+		moves := divisionMovements(2, divisions, ranked)
+		assert.Equal(t, DivisionPromoted, moves[0].movement)
+		assert.Equal(t, 1, moves[0].nextDivision)
+		assert.Equal(t, DivisionStayed, moves[2].movement)
+		assert.Equal(t, 3, moves[3].nextDivision)
+	})
+
+	t.Run("bottom division only promotes", func(t *testing.T) {
+		assert.Equal(t, map[string]string{
+			"a": DivisionPromoted, "b": DivisionStayed, "c": DivisionStayed,
+			"d": DivisionStayed, "e": DivisionStayed,
+		}, movement(divisionMovements(3, divisions, ranked)))
+	})
+
+	t.Run("promotion wins in a tiny division", func(t *testing.T) {
+		moves := divisionMovements(2, divisions, []string{"solo"})
+		assert.Equal(t, DivisionPromoted, moves[0].movement)
+	})
+}
+
+func TestValidateDivisions(t *testing.T) {
+	assert.NoError(t, validateDivisions(nil))
+	assert.NoError(t, validateDivisions(&models.CompetitionDivisions{Count: 3, PromoteCount: 2, RelegateCount: 2}))
+	assert.ErrorIs(t, validateDivisions(&models.CompetitionDivisions{Count: 1}), ErrInvalidDivisions)
+	assert.ErrorIs(t, validateDivisions(&models.CompetitionDivisions{Count: maxDivisions + 1}), ErrInvalidDivisions)
+	assert.ErrorIs(t, validateDivisions(&models.CompetitionDivisions{Count: 2, RelegateCount: -1}), ErrInvalidDivisions)
+}
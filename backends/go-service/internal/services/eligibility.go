@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+var (
+	ErrNotEligible        = errors.New("not eligible for this competition")
+	ErrInvalidEligibility = errors.New("invalid eligibility rules")
+)
+
+// maxMinActiveDays bounds EligibilityRules.MinActiveDays
+const maxMinActiveDays = 366
+
+// participantActivity is what eligibility rules are checked against
+type participantActivity struct {
+	country    string
+	sources    []string // sources with synced data in the competition
+	activeDays int      // days with any steps, distance or active minutes
+}
+
+// GetEligibility reports whether a participant can currently win a prize in
+// a competition. Participants can check themselves; the creator and admins
+// can check anyone.
+func (s *CompetitionService) GetEligibility(ctx context.Context, competitionID, userID, viewerID string, isAdmin bool) (*models.EligibilityStatus, error) {
+	comp, err := s.GetCompetitionByID(ctx, competitionID, viewerID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if userID != viewerID && comp.CreatorID != viewerID && !isAdmin {
+		return nil, ErrNotCompetitionOwner
+	}
+
+	activities, err := participantActivities(ctx, s.db, competitionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	activity, ok := activities[userID]
+	if !ok {
+		return nil, ErrNotParticipant
+	}
+
+	reasons := eligibilityReasons(comp.Eligibility, activity)
+	return &models.EligibilityStatus{
+		CompetitionID: competitionID,
+		UserID:        userID,
+		Eligible:      len(reasons) == 0,
+		Reasons:       reasons,
+		ActiveDays:    activity.activeDays,
+		Sources:       activity.sources,
+	}, nil
+}
+
+// evaluateEligibility checks every participant of comp against its rules and
+// returns the reasons keyed by user for those who cannot win a prize
+func evaluateEligibility(ctx context.Context, db dbExecutor, comp *models.Competition) (map[string][]string, error) {
+	ineligible := map[string][]string{}
+	if comp.Eligibility == nil {
+		return ineligible, nil
+	}
+
+	activities, err := participantActivities(ctx, db, comp.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	for userID, activity := range activities {
+		if reasons := eligibilityReasons(comp.Eligibility, activity); len(reasons) > 0 {
+			ineligible[userID] = reasons
+		}
+	}
+	return ineligible, nil
+}
+
+// checkJoinEligibility rejects users whose profile country a competition
+// does not accept. Source and activity rules can only be judged once the
+// competition is under way.
+func checkJoinEligibility(ctx context.Context, db dbExecutor, comp *models.Competition, userID string) error {
+	if comp.Eligibility == nil || len(comp.Eligibility.Countries) == 0 {
+		return nil
+	}
+
+	var country string
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(country, '') FROM public.users WHERE id = $1`, userID).Scan(&country)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get user country: %w", err)
+	}
+
+	if reason := countryIneligibility(comp.Eligibility, country); reason != "" {
+		return fmt.Errorf("%w: %s", ErrNotEligible, reason)
+	}
+	return nil
+}
+
+// participantActivities loads the activity of a competition's participants,
+// or of just userID when it is set
+func participantActivities(ctx context.Context, db dbExecutor, competitionID, userID string) (map[string]*participantActivity, error) {
+	query := `
+		SELECT cp.user_id, COALESCE(u.country, ''),
+			COALESCE(ARRAY_AGG(DISTINCT fd.source) FILTER (WHERE fd.source IS NOT NULL), '{}'),
+			COUNT(DISTINCT fd.date) FILTER (WHERE fd.steps > 0 OR fd.distance > 0 OR fd.active_minutes > 0)
+		FROM public.competition_participants cp
+		LEFT JOIN public.users u ON u.id = cp.user_id
+		LEFT JOIN public.fitness_data fd ON fd.competition_id = cp.competition_id AND fd.user_id = cp.user_id
+		WHERE cp.competition_id = $1 AND ($2 = '' OR cp.user_id::text = $2)
+		GROUP BY cp.user_id, u.country
+	`
+	rows, err := db.QueryContext(ctx, query, competitionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query participant activity: %w", err)
+	}
+	defer rows.Close()
+
+	activities := map[string]*participantActivity{}
+	for rows.Next() {
+		var id string
+		var sources pq.StringArray
+		activity := &participantActivity{}
+		if err := rows.Scan(&id, &activity.country, &sources, &activity.activeDays); err != nil {
+			return nil, fmt.Errorf("failed to scan participant activity: %w", err)
+		}
+		activity.sources = []string(sources)
+		sort.Strings(activity.sources)
+		activities[id] = activity
+	}
+	return activities, rows.Err()
+}
+
+// eligibilityReasons lists why a participant with activity cannot win a
+// prize under rules, or nil if they can
+func eligibilityReasons(rules *models.EligibilityRules, activity *participantActivity) []string {
+	if rules == nil {
+		return nil
+	}
+
+	var reasons []string
+	if reason := countryIneligibility(rules, activity.country); reason != "" {
+		reasons = append(reasons, reason)
+	}
+	if len(rules.Sources) > 0 {
+		for _, source := range activity.sources {
+			if !containsFold(rules.Sources, source) {
+				reasons = append(reasons, fmt.Sprintf("synced data from %s, which this competition does not accept", source))
+			}
+		}
+	}
+	if activity.activeDays < rules.MinActiveDays {
+		reasons = append(reasons, fmt.Sprintf("active on %d of the %d days required", activity.activeDays, rules.MinActiveDays))
+	}
+	return reasons
+}
+
+// countryIneligibility explains why country is not accepted by rules, or
+// returns "" if it is
+func countryIneligibility(rules *models.EligibilityRules, country string) string {
+	if len(rules.Countries) == 0 || containsFold(rules.Countries, country) {
+		return ""
+	}
+	if country == "" {
+		return "profile has no country, and this competition is limited to " + strings.Join(rules.Countries, ", ")
+	}
+	return fmt.Sprintf("profile country %s is not one of %s", country, strings.Join(rules.Countries, ", "))
+}
+
+// validateEligibility checks eligibility rules, which may be nil
+func validateEligibility(rules *models.EligibilityRules) error {
+	if rules == nil {
+		return nil
+	}
+	for _, list := range [][]string{rules.Sources, rules.Countries} {
+		for _, v := range list {
+			if strings.TrimSpace(v) == "" {
+				return fmt.Errorf("%w: sources and countries cannot be empty", ErrInvalidEligibility)
+			}
+		}
+	}
+	if rules.MinActiveDays < 0 || rules.MinActiveDays > maxMinActiveDays {
+		return fmt.Errorf("%w: min active days must be between 0 and %d", ErrInvalidEligibility, maxMinActiveDays)
+	}
+	return nil
+}
+
+// eligibleEntries returns the entries of participants who can win a prize
+func eligibleEntries(entries []models.LeaderboardEntry) []models.LeaderboardEntry {
+	eligible := make([]models.LeaderboardEntry, 0, len(entries))
+	for _, e := range entries {
+		if len(e.IneligibleReasons) == 0 {
+			eligible = append(eligible, e)
+		}
+	}
+	return eligible
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), strings.TrimSpace(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// eligibilityColumns holds the eligibility columns shared by competitions and
+// competition templates
+type eligibilityColumns struct {
+	sources       pq.StringArray
+	countries     pq.StringArray
+	minActiveDays int
+}
+
+func (e eligibilityColumns) rules() *models.EligibilityRules {
+	if len(e.sources) == 0 && len(e.countries) == 0 && e.minActiveDays == 0 {
+		return nil
+	}
+	return &models.EligibilityRules{
+		Sources:       []string(e.sources),
+		Countries:     []string(e.countries),
+		MinActiveDays: e.minActiveDays,
+	}
+}
+
+// eligibilityArgs returns the eligibility column values to store for rules
+func eligibilityArgs(rules *models.EligibilityRules) (sources, countries pq.StringArray, minActiveDays int) {
+	if rules == nil {
+		return
+	}
+	return pq.StringArray(rules.Sources), pq.StringArray(rules.Countries), rules.MinActiveDays
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEligibilityReasons(t *testing.T) {
+	rules := &models.EligibilityRules{
+		Sources:       []string{"fitbit", "garmin"},
+		Countries:     []string{"NZ", "AU"},
+		MinActiveDays: 5,
+	}
+
+	assert.Nil(t, eligibilityReasons(nil, &participantActivity{}), "no rules means everyone is eligible")
+
+	eligible := &participantActivity{country: "nz", sources: []string{"Fitbit"}, activeDays: 5}
+	assert.Empty(t, eligibilityReasons(rules, eligible), "matching is case-insensitive")
+
+	ineligible := &participantActivity{country: "US", sources: []string{"fitbit", "google_fit"}, activeDays: 2}
+	assert.Equal(t, []string{
+		"profile country US is not one of NZ, AU",
+		"synced data from google_fit, which this competition does not accept",
+		"active on 2 of the 5 days required",
+	}, eligibilityReasons(rules, ineligible))
+
+	assert.Contains(t, countryIneligibility(rules, ""), "profile has no country")
+	assert.Empty(t, countryIneligibility(&models.EligibilityRules{MinActiveDays: 3}, ""))
+}
+
+func TestValidateEligibility(t *testing.T) {
+	assert.NoError(t, validateEligibility(nil))
+	assert.NoError(t, validateEligibility(&models.EligibilityRules{Sources: []string{"fitbit"}, MinActiveDays: 10}))
+	assert.ErrorIs(t, validateEligibility(&models.EligibilityRules{Countries: []string{" "}}), ErrInvalidEligibility)
+	assert.ErrorIs(t, validateEligibility(&models.EligibilityRules{MinActiveDays: -1}), ErrInvalidEligibility)
+}
+
+func TestEligibleEntries(t *testing.T) {
+	entries := []models.LeaderboardEntry{
+		{UserID: "user-1", IneligibleReasons: []string{"active on 0 of the 3 days required"}},
+		{UserID: "user-2"},
+	}
+	eligible := eligibleEntries(entries)
+	assert.Len(t, eligible, 1)
+	assert.Equal(t, "user-2", eligible[0].UserID)
+}
+
+func TestEligibilityColumns(t *testing.T) {
+	assert.Nil(t, eligibilityColumns{}.rules())
+
+	rules := &models.EligibilityRules{Countries: []string{"NZ"}, MinActiveDays: 3}
+	sources, countries, minActiveDays := eligibilityArgs(rules)
+	assert.Equal(t, rules, eligibilityColumns{sources: sources, countries: countries, minActiveDays: minActiveDays}.rules())
+}
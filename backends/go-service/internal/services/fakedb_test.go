@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeQuery is one statement a fakeDB expects, answered with rows, a count
+// of affected rows or an error. Statements are matched in order by a
+// substring of their SQL.
+type fakeQuery struct {
+	match    string
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+	err      error
+}
+
+// fakeDB is a database/sql driver that plays back a script of statements, so
+// code that talks to Postgres can be tested without one. Transactions always
+// succeed and are not part of the script.
+type fakeDB struct {
+	t      *testing.T
+	mu     sync.Mutex
+	script []fakeQuery
+
+	// args holds the arguments of each statement run, in order
+	args [][]driver.Value
+}
+
+// newFakeDB returns a database that expects exactly the given statements
+func newFakeDB(t *testing.T, script ...fakeQuery) (*sql.DB, *fakeDB) {
+	f := &fakeDB{t: t, script: script}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() {
+		db.Close()
+		if len(f.script) > 0 {
+			t.Errorf("fake database: %d expected statements never ran, next %q", len(f.script), f.script[0].match)
+		}
+	})
+	return db, f
+}
+
+func (f *fakeDB) next(query string, args []driver.NamedValue) (*fakeQuery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	f.args = append(f.args, values)
+
+	if len(f.script) == 0 {
+		f.t.Errorf("fake database: unexpected statement %q", query)
+		return nil, fmt.Errorf("unexpected statement")
+	}
+	q := f.script[0]
+	if !strings.Contains(query, q.match) {
+		f.t.Errorf("fake database: expected a statement containing %q, got %q", q.match, query)
+		return nil, fmt.Errorf("unexpected statement")
+	}
+	f.script = f.script[1:]
+	return &q, q.err
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)             { return &fakeConn{db: f}, nil }
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fake database: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error                                                { return nil }
+func (c *fakeConn) Rollback() error                                              { return nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	q, err := c.db.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(q.affected), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, err := c.db.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: q.columns, rows: q.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if r.columns == nil && len(r.rows) > 0 {
+		// Columns only need names when the caller asks for them
+		return make([]string, len(r.rows[0]))
+	}
+	return r.columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
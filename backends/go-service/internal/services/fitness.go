@@ -2,31 +2,79 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/yourusername/health-competition-go/internal/models"
 )
 
+// ErrInvalidSync is returned for a sync for a day that can't count: one in
+// the future or outside the competition
+var ErrInvalidSync = errors.New("invalid fitness sync")
+
+// fitnessCacheTTL is how long daily and aggregated fitness data stay cached
+const fitnessCacheTTL = 30 * 24 * time.Hour
+
+// FitnessService records synced fitness data. Postgres public.fitness_data is
+// the system of record, holding each source's value per day, and
+// public.fitness_daily the day's sources merged into one record. Redis caches
+// what the API reads back. Without a
+// database (db is nil) the data only lives in Redis. Leaderboard scores are
+// only ever set from the synced totals.
 type FitnessService struct {
+	db          *sql.DB
 	cache       *CacheService
+	leaderboard *LeaderboardService
 	supabaseURL string
 }
 
-func NewFitnessService(cache *CacheService, supabaseURL string) *FitnessService {
+func NewFitnessService(db *sql.DB, cache *CacheService, leaderboard *LeaderboardService, supabaseURL string) *FitnessService {
 	return &FitnessService{
+		db:          db,
 		cache:       cache,
+		leaderboard: leaderboard,
 		supabaseURL: supabaseURL,
 	}
 }
 
-// SyncFitnessData syncs fitness data from external sources
-func (s *FitnessService) SyncFitnessData(ctx context.Context, req *models.FitnessSyncRequest) error {
-	// Store fitness data in cache
-	fitnessKey := s.getFitnessDataKey(req.UserID, req.CompetitionID, req.Date)
-	
+// SyncFitnessData records the value of a day for one source, replacing what
+// that source synced for the day before. The day is the one req.Date falls on
+// in the competition's time zone. Aggregates move by the difference against
+// the stored value, so re-sending a cumulative daily total doesn't count it
+// twice, and a sync with an already applied SyncID changes nothing. Records
+// that trip the anti-cheat rules are flagged, clamped or quarantined. The
+// user's leaderboard score is then recomputed from their totals. Only the
+// competition's participants can sync to it, and only for its days up to
+// today.
+func (s *FitnessService) SyncFitnessData(ctx context.Context, req *models.FitnessSyncRequest) (*models.FitnessSyncResult, error) {
+	loc, err := competitionLocation(ctx, s.cache, s.db, req.CompetitionID)
+	if err != nil {
+		return nil, err
+	}
+	var window *competitionWindow
+	if s.db != nil {
+		if err := checkParticipant(ctx, s.db, req.CompetitionID, req.UserID); err != nil {
+			return nil, err
+		}
+		if window, err = loadCompetitionWindow(ctx, s.db, req.CompetitionID, loc); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	if req.Date.IsZero() {
+		req.Date = now
+	}
+	day := competitionDay(req.Date, loc)
+	if err := checkFitnessDay(day, loc, window, now); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSync, err)
+	}
+
 	fitnessData := &models.FitnessData{
-		ID:            fmt.Sprintf("%s-%s-%d", req.UserID, req.CompetitionID, req.Date.Unix()),
+		ID:            fmt.Sprintf("%s-%s-%d", req.UserID, req.CompetitionID, day.Unix()),
 		UserID:        req.UserID,
 		CompetitionID: req.CompetitionID,
 		Steps:         req.Steps,
@@ -34,31 +82,220 @@ func (s *FitnessService) SyncFitnessData(ctx context.Context, req *models.Fitnes
 		Calories:      req.Calories,
 		ActiveMinutes: req.ActiveMinutes,
 		Source:        req.Source,
-		Date:          req.Date,
+		Date:          day,
 		SyncedAt:      time.Now(),
 		CreatedAt:     time.Now(),
 	}
 
-	err := s.cache.Set(ctx, fitnessKey, fitnessData, 30*24*time.Hour)
+	var result *models.FitnessSyncResult
+	if s.db != nil {
+		result, err = s.syncToDatabase(ctx, fitnessData, req.SyncID)
+		if err == nil && result.Applied && !result.Quarantined {
+			err = s.refreshCache(ctx, req.UserID, req.CompetitionID, day)
+		}
+	} else {
+		result, err = s.syncToCache(ctx, fitnessData, req.SyncID)
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := s.publishSync(ctx, req.UserID, req.CompetitionID, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// publishSync makes an applied sync visible: anomalies raise the user's
+// leaderboard flags and, unless the record was quarantined, the score is
+// recomputed from their totals
+func (s *FitnessService) publishSync(ctx context.Context, userID, competitionID string, result *models.FitnessSyncResult) error {
+	if !result.Applied {
+		return nil
+	}
+	if result.Anomaly != nil {
+		if err := s.refreshFlags(ctx, userID, competitionID, result.Anomaly.Rules); err != nil {
+			return err
+		}
+	}
+	if result.Quarantined {
+		return nil
 	}
 
-	// Update aggregated stats
-	err = s.updateAggregatedStats(ctx, req.UserID, req.CompetitionID, fitnessData)
+	totals, err := s.GetUserStats(ctx, userID, competitionID)
 	if err != nil {
 		return err
 	}
+	return s.updateLeaderboard(ctx, totals)
+}
+
+// updateLeaderboard sets a user's competition score from their synced totals,
+// then rescores their running challenges, which count the same data. A frozen
+// leaderboard keeps its final standings; the data is still stored.
+func (s *FitnessService) updateLeaderboard(ctx context.Context, totals *models.FitnessData) error {
+	err := s.leaderboard.UpdateScore(ctx, &models.ScoreUpdateRequest{
+		UserID:        totals.UserID,
+		CompetitionID: totals.CompetitionID,
+		Steps:         totals.Steps,
+		Distance:      totals.Distance,
+		Calories:      totals.Calories,
+	})
+	if err != nil && !errors.Is(err, ErrLeaderboardFrozen) {
+		return err
+	}
+	return s.updateChallengeBoards(ctx, totals.UserID)
+}
 
+// updateChallengeBoards rescores userID on each of their running challenges.
+// Challenges need a database, so without one there is nothing to do.
+func (s *FitnessService) updateChallengeBoards(ctx context.Context, userID string) error {
+	if s.db == nil {
+		return nil
+	}
+	challenges, err := activeChallenges(ctx, s.db, userID)
+	if err != nil {
+		return err
+	}
+	for _, challenge := range challenges {
+		if _, err := refreshChallengeScore(ctx, s.db, s.leaderboard, challenge, userID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// syncToDatabase stores data as the day's value for its source, recording
+// syncID in the same transaction so a retry after a failure still applies
+func (s *FitnessService) syncToDatabase(ctx context.Context, data *models.FitnessData, syncID string) (*models.FitnessSyncResult, error) {
+	var result *models.FitnessSyncResult
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var err error
+		result, err = applySync(ctx, tx, data, syncID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applySync is syncToDatabase within the caller's transaction. The record is
+// screened for anomalies first; quarantined records are only recorded as
+// anomalies.
+func applySync(ctx context.Context, tx *sql.Tx, data *models.FitnessData, syncID string) (*models.FitnessSyncResult, error) {
+	if syncID != "" {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO public.fitness_syncs (user_id, sync_id, competition_id, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, sync_id) DO NOTHING
+		`, data.UserID, syncID, data.CompetitionID, data.SyncedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record sync: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return &models.FitnessSyncResult{}, nil
+		}
+	}
+
+	baseline, err := loadFitnessBaseline(ctx, tx, data.UserID, data.Date)
+	if err != nil {
+		return nil, err
+	}
+	anomaly := screenFitnessData(data, baseline)
+	if anomaly != nil {
+		if err := insertAnomaly(ctx, tx, anomaly); err != nil {
+			return nil, err
+		}
+		if anomaly.Action == models.AnomalyQuarantine {
+			return &models.FitnessSyncResult{Applied: true, Quarantined: true, Anomaly: anomaly}, nil
+		}
+	}
+
+	delta, err := storeFitnessData(ctx, tx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &models.FitnessSyncResult{Applied: true, Daily: data, Delta: delta, Anomaly: anomaly}, nil
+}
+
+// storeFitnessData replaces the day's value for data's source, merges the
+// day's sources again and returns how much that changed the totals
+func storeFitnessData(ctx context.Context, tx *sql.Tx, data *models.FitnessData) (models.FitnessDelta, error) {
+	if err := upsertFitnessData(ctx, tx, data); err != nil {
+		return models.FitnessDelta{}, err
+	}
+	return mergeDay(ctx, tx, data.UserID, data.CompetitionID, data.Date)
+}
+
+// syncToCache is SyncFitnessData for a service without a database. Each
+// source's daily value is kept next to the day's merged record so the day can
+// be merged again. Sources are merged with the default settings.
+func (s *FitnessService) syncToCache(ctx context.Context, data *models.FitnessData, syncID string) (*models.FitnessSyncResult, error) {
+	if syncID != "" {
+		fresh, err := s.cache.SetNX(ctx, s.getSyncKey(data.UserID, syncID), data.SyncedAt, fitnessCacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		if !fresh {
+			return &models.FitnessSyncResult{}, nil
+		}
+	}
+
+	// Without stored history there is no baseline to compare against
+	anomaly := screenFitnessData(data, fitnessBaseline{})
+	if anomaly != nil && anomaly.Action == models.AnomalyQuarantine {
+		return &models.FitnessSyncResult{Applied: true, Quarantined: true, Anomaly: anomaly}, nil
+	}
+
+	sourcesKey := s.getDaySourcesKey(data.UserID, data.CompetitionID, data.Date)
+	sources := map[string]models.FitnessData{}
+	if err := s.cache.Get(ctx, sourcesKey, &sources); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	sources[data.Source] = *data
+	if err := s.cache.Set(ctx, sourcesKey, sources, fitnessCacheTTL); err != nil {
+		return nil, err
+	}
+
+	records := make([]models.FitnessData, 0, len(sources))
+	for _, r := range sources {
+		records = append(records, r)
+	}
+	daily := mergeSources(records, defaultMergeSettings)
+	daily.ID = data.ID
+
+	fitnessKey := s.getFitnessDataKey(data.UserID, data.CompetitionID, data.Date)
+	var previous models.FitnessData
+	if err := s.cache.Get(ctx, fitnessKey, &previous); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, fitnessKey, daily, fitnessCacheTTL); err != nil {
+		return nil, err
+	}
+	delta := fitnessDelta(&previous, daily)
+
+	if err := s.updateAggregatedStats(ctx, data.UserID, data.CompetitionID, delta); err != nil {
+		return nil, err
+	}
+
+	return &models.FitnessSyncResult{Applied: true, Daily: data, Delta: delta, Anomaly: anomaly}, nil
+}
+
 // GetUserStats retrieves aggregated fitness statistics for a user
 func (s *FitnessService) GetUserStats(ctx context.Context, userID, competitionID string) (*models.FitnessData, error) {
 	statsKey := s.getUserStatsKey(userID, competitionID)
 	
 	var stats models.FitnessData
 	err := s.cache.Get(ctx, statsKey, &stats)
+	if err != nil && s.db != nil {
+		totals, err := loadFitnessTotals(ctx, s.db, userID, competitionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.cache.Set(ctx, statsKey, totals, fitnessCacheTTL); err != nil {
+			return nil, err
+		}
+		return totals, nil
+	}
 	if err != nil {
 		// Return zero stats if not found
 		return &models.FitnessData{
@@ -74,8 +311,9 @@ func (s *FitnessService) GetUserStats(ctx context.Context, userID, competitionID
 	return &stats, nil
 }
 
-// updateAggregatedStats updates the aggregated statistics for a user in a competition
-func (s *FitnessService) updateAggregatedStats(ctx context.Context, userID, competitionID string, newData *models.FitnessData) error {
+// updateAggregatedStats moves the aggregated statistics for a user in a
+// competition by delta
+func (s *FitnessService) updateAggregatedStats(ctx context.Context, userID, competitionID string, delta models.FitnessDelta) error {
 	statsKey := s.getUserStatsKey(userID, competitionID)
 
 	// Get current stats
@@ -94,14 +332,13 @@ func (s *FitnessService) updateAggregatedStats(ctx context.Context, userID, comp
 	}
 
 	// Update aggregated values
-	currentStats.Steps += newData.Steps
-	currentStats.Distance += newData.Distance
-	currentStats.Calories += newData.Calories
-	currentStats.ActiveMinutes += newData.ActiveMinutes
-	currentStats.Source = newData.Source
+	currentStats.Steps += delta.Steps
+	currentStats.Distance += delta.Distance
+	currentStats.Calories += delta.Calories
+	currentStats.ActiveMinutes += delta.ActiveMinutes
 
 	// Save updated stats
-	err = s.cache.Set(ctx, statsKey, currentStats, 30*24*time.Hour)
+	err = s.cache.Set(ctx, statsKey, currentStats, fitnessCacheTTL)
 	if err != nil {
 		return err
 	}
@@ -109,12 +346,28 @@ func (s *FitnessService) updateAggregatedStats(ctx context.Context, userID, comp
 	return nil
 }
 
-// GetDailyStats retrieves fitness data for a specific day
+// GetDailyStats retrieves fitness data for the day date falls on in the
+// competition's time zone
 func (s *FitnessService) GetDailyStats(ctx context.Context, userID, competitionID string, date time.Time) (*models.FitnessData, error) {
-	fitnessKey := s.getFitnessDataKey(userID, competitionID, date)
+	loc, err := competitionLocation(ctx, s.cache, s.db, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	day := competitionDay(date, loc)
+	fitnessKey := s.getFitnessDataKey(userID, competitionID, day)
 	
 	var fitnessData models.FitnessData
-	err := s.cache.Get(ctx, fitnessKey, &fitnessData)
+	err = s.cache.Get(ctx, fitnessKey, &fitnessData)
+	if err != nil && s.db != nil {
+		daily, err := loadDailyFitness(ctx, s.db, userID, competitionID, day)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.cache.Set(ctx, fitnessKey, daily, fitnessCacheTTL); err != nil {
+			return nil, err
+		}
+		return daily, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -122,12 +375,153 @@ func (s *FitnessService) GetDailyStats(ctx context.Context, userID, competitionI
 	return &fitnessData, nil
 }
 
+// refreshCache reloads a user's cached data for days and their aggregated
+// data from the database after a sync
+func (s *FitnessService) refreshCache(ctx context.Context, userID, competitionID string, days ...time.Time) error {
+	for _, day := range days {
+		daily, err := loadDailyFitness(ctx, s.db, userID, competitionID, day)
+		if err != nil {
+			return err
+		}
+		if err := s.cache.Set(ctx, s.getFitnessDataKey(userID, competitionID, day), daily, fitnessCacheTTL); err != nil {
+			return err
+		}
+	}
+
+	totals, err := loadFitnessTotals(ctx, s.db, userID, competitionID)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, s.getUserStatsKey(userID, competitionID), totals, fitnessCacheTTL)
+}
+
+// checkParticipant returns ErrNotParticipant unless userID has joined the
+// competition
+func checkParticipant(ctx context.Context, db dbExecutor, competitionID, userID string) error {
+	var joined bool
+	query := `SELECT EXISTS(SELECT 1 FROM public.competition_participants WHERE competition_id = $1 AND user_id = $2)`
+	if err := db.QueryRowContext(ctx, query, competitionID, userID).Scan(&joined); err != nil {
+		return fmt.Errorf("failed to check participation: %w", err)
+	}
+	if !joined {
+		return ErrNotParticipant
+	}
+	return nil
+}
+
+// upsertFitnessData stores data as the value for its user, competition, day
+// and source, replacing any earlier sync. The stored row's ID and creation
+// time are read back into data.
+func upsertFitnessData(ctx context.Context, db dbExecutor, data *models.FitnessData) error {
+	query := `
+		INSERT INTO public.fitness_data (user_id, competition_id, steps, distance, calories, active_minutes, source, date, synced_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (user_id, competition_id, date, source) DO UPDATE SET
+			steps = EXCLUDED.steps,
+			distance = EXCLUDED.distance,
+			calories = EXCLUDED.calories,
+			active_minutes = EXCLUDED.active_minutes,
+			synced_at = EXCLUDED.synced_at
+		RETURNING id, created_at
+	`
+	err := db.QueryRowContext(ctx, query,
+		data.UserID, data.CompetitionID, data.Steps, data.Distance, data.Calories, data.ActiveMinutes,
+		data.Source, data.Date.Format("2006-01-02"), data.SyncedAt,
+	).Scan(&data.ID, &data.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store fitness data: %w", err)
+	}
+	return nil
+}
+
+// loadDailyFitness returns a user's merged record for one competition day.
+// It returns sql.ErrNoRows when nothing was synced that day.
+func loadDailyFitness(ctx context.Context, db dbExecutor, userID, competitionID string, day time.Time) (*models.FitnessData, error) {
+	query := `
+		SELECT d.steps, d.distance, d.calories, d.active_minutes,
+			COALESCE(d.steps_source, ''), COALESCE(d.distance_source, ''),
+			COALESCE(d.calories_source, ''), COALESCE(d.active_minutes_source, ''),
+			COALESCE(fd.synced_at, d.merged_at), COALESCE(fd.created_at, d.merged_at)
+		FROM public.fitness_daily d
+		CROSS JOIN LATERAL (
+			SELECT MAX(synced_at) AS synced_at, MIN(created_at) AS created_at
+			FROM public.fitness_data
+			WHERE user_id = d.user_id AND competition_id = d.competition_id AND date = d.date
+		) fd
+		WHERE d.user_id = $1 AND d.competition_id = $2 AND d.date = $3
+	`
+	daily := &models.FitnessData{
+		ID:            fmt.Sprintf("%s-%s-%d", userID, competitionID, day.Unix()),
+		UserID:        userID,
+		CompetitionID: competitionID,
+		Date:          day,
+		MetricSources: &models.MetricSources{},
+	}
+	m := daily.MetricSources
+	err := db.QueryRowContext(ctx, query, userID, competitionID, day.Format("2006-01-02")).Scan(
+		&daily.Steps, &daily.Distance, &daily.Calories, &daily.ActiveMinutes,
+		&m.Steps, &m.Distance, &m.Calories, &m.ActiveMinutes,
+		&daily.SyncedAt, &daily.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	daily.Source = strings.Join(chosenSources(m), ",")
+	return daily, nil
+}
+
+// loadFitnessTotals sums a user's merged days in a competition. Only the
+// competition's days count, in its time zone, so data stored before its dates
+// changed can't count towards it.
+func loadFitnessTotals(ctx context.Context, db dbExecutor, userID, competitionID string) (*models.FitnessData, error) {
+	query := `
+		SELECT COALESCE(SUM(d.steps), 0), COALESCE(SUM(d.distance), 0), COALESCE(SUM(d.calories), 0),
+			COALESCE(SUM(d.active_minutes), 0)
+		FROM public.fitness_daily d
+		JOIN public.competitions c ON c.id = d.competition_id
+		WHERE d.user_id = $1 AND d.competition_id = $2
+			AND d.date BETWEEN (c.start_date AT TIME ZONE c.time_zone)::date AND (c.end_date AT TIME ZONE c.time_zone)::date
+	`
+	totals := &models.FitnessData{UserID: userID, CompetitionID: competitionID}
+	if err := db.QueryRowContext(ctx, query, userID, competitionID).Scan(
+		&totals.Steps, &totals.Distance, &totals.Calories, &totals.ActiveMinutes,
+	); err != nil {
+		return nil, fmt.Errorf("failed to sum fitness data: %w", err)
+	}
+	return totals, nil
+}
+
 // Helper methods
+
+// getFitnessDataKey keys a competition day as returned by competitionDay
 func (s *FitnessService) getFitnessDataKey(userID, competitionID string, date time.Time) string {
 	dateStr := date.Format("2006-01-02")
 	return fmt.Sprintf("fitness:%s:%s:%s", userID, competitionID, dateStr)
 }
 
+// getDaySourcesKey keys each source's value for a competition day
+func (s *FitnessService) getDaySourcesKey(userID, competitionID string, date time.Time) string {
+	return fmt.Sprintf("fitness_sources:%s:%s:%s", userID, competitionID, date.Format("2006-01-02"))
+}
+
+func (s *FitnessService) getSyncKey(userID, syncID string) string {
+	return fmt.Sprintf("fitness_sync:%s:%s", userID, syncID)
+}
+
+func (s *FitnessService) getImportKey(userID, competitionID, externalID string) string {
+	return fmt.Sprintf("activity_import:%s:%s:%s", userID, competitionID, externalID)
+}
+
 func (s *FitnessService) getUserStatsKey(userID, competitionID string) string {
 	return fmt.Sprintf("fitness_stats:%s:%s", userID, competitionID)
 }
+
+// fitnessDelta returns how much replacing previous with current changes totals
+func fitnessDelta(previous, current *models.FitnessData) models.FitnessDelta {
+	return models.FitnessDelta{
+		Steps:         current.Steps - previous.Steps,
+		Distance:      current.Distance - previous.Distance,
+		Calories:      current.Calories - previous.Calories,
+		ActiveMinutes: current.ActiveMinutes - previous.ActiveMinutes,
+	}
+}
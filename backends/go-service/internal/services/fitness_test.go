@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitnessService_SyncWithoutDatabase(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	service := NewFitnessService(nil, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+
+	require.NoError(t, cacheCompetitionTimeZone(ctx, cache, "comp-1", "Pacific/Auckland"))
+
+	// 20:00 UTC on June 2 is June 3 in Auckland
+	synced := time.Date(2024, 6, 2, 20, 0, 0, 0, time.UTC)
+	_, err := service.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+		UserID:        "user-1",
+		CompetitionID: "comp-1",
+		Steps:         8000,
+		Source:        "fitbit",
+		Date:          synced,
+	})
+	require.NoError(t, err)
+
+	daily, err := service.GetDailyStats(ctx, "user-1", "comp-1", synced)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8000), daily.Steps)
+	assert.Equal(t, "2024-06-03", daily.Date.Format("2006-01-02"))
+
+	stats, err := service.GetUserStats(ctx, "user-1", "comp-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8000), stats.Steps)
+}
+
+func TestFitnessService_SyncRequiresParticipation(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "FROM public.competition_participants", rows: [][]driver.Value{{false}}},
+	)
+	cache := NewCacheService(client)
+	service := NewFitnessService(db, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+	require.NoError(t, cacheCompetitionTimeZone(ctx, cache, "comp-1", "UTC"))
+
+	_, err := service.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+		UserID:        "user-1",
+		CompetitionID: "comp-1",
+		Steps:         8000,
+		Source:        "fitbit",
+	})
+	assert.ErrorIs(t, err, ErrNotParticipant)
+	assert.Equal(t, []driver.Value{"comp-1", "user-1"}, fake.args[0])
+
+	// Nothing reaches the leaderboard
+	board, err := service.leaderboard.GetLeaderboard(ctx, "comp-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, board.Entries)
+}
+
+func TestFitnessService_SyncIsIdempotent(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	service := NewFitnessService(nil, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+	day := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	sync := func(steps int64, source, syncID string) *models.FitnessSyncResult {
+		result, err := service.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+			UserID:        "user-1",
+			CompetitionID: "comp-1",
+			Steps:         steps,
+			Source:        source,
+			Date:          day,
+			SyncID:        syncID,
+		})
+		require.NoError(t, err)
+		return result
+	}
+	totalSteps := func() int64 {
+		stats, err := service.GetUserStats(ctx, "user-1", "comp-1")
+		require.NoError(t, err)
+		return stats.Steps
+	}
+
+	t.Run("re-syncing a cumulative daily value doesn't double count", func(t *testing.T) {
+		assert.Equal(t, int64(5000), sync(5000, "fitbit", "").Delta.Steps)
+		assert.Equal(t, int64(3000), sync(8000, "fitbit", "").Delta.Steps)
+		assert.Equal(t, int64(0), sync(8000, "fitbit", "").Delta.Steps)
+		assert.Equal(t, int64(8000), totalSteps())
+	})
+
+	t.Run("a second source is merged, not added", func(t *testing.T) {
+		assert.Equal(t, int64(0), sync(2000, "strava", "").Delta.Steps)
+		assert.Equal(t, int64(8000), totalSteps())
+
+		daily, err := service.GetDailyStats(ctx, "user-1", "comp-1", day)
+		require.NoError(t, err)
+		assert.Equal(t, int64(8000), daily.Steps)
+		assert.Equal(t, "fitbit", daily.MetricSources.Steps)
+	})
+
+	t.Run("a lower value corrects the total down", func(t *testing.T) {
+		assert.Equal(t, int64(-500), sync(7500, "fitbit", "").Delta.Steps)
+		assert.Equal(t, int64(7500), totalSteps())
+	})
+
+	t.Run("a retried sync ID is a no-op", func(t *testing.T) {
+		assert.True(t, sync(9000, "fitbit", "sync-1").Applied)
+		retry := sync(12000, "fitbit", "sync-1")
+		assert.False(t, retry.Applied)
+		assert.Equal(t, int64(9000), totalSteps())
+	})
+}
+
+func TestFitnessDelta(t *testing.T) {
+	previous := &models.FitnessData{Steps: 8000, Distance: 6.2, ActiveMinutes: 40}
+	current := &models.FitnessData{Steps: 9000, Distance: 6.2, Calories: 120, ActiveMinutes: 35}
+	assert.Equal(t, models.FitnessDelta{Steps: 1000, Calories: 120, ActiveMinutes: -5}, fitnessDelta(previous, current))
+}
+
+func TestFitnessService_Backfill(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	service := NewFitnessService(nil, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 12, 0, 0, 0, time.UTC) }
+
+	result, err := service.BackfillFitnessData(ctx, &models.FitnessBackfillRequest{
+		UserID:        "user-1",
+		CompetitionID: "comp-1",
+		Records: []models.FitnessBackfillRecord{
+			{Steps: 5000, Source: "fitbit", Date: day(1), SyncID: "b-1"},
+			{Steps: 6000, Source: "fitbit", Date: day(2)},
+			{Steps: 1000, Source: "strava", Date: day(2)},
+			{Steps: 7000, Source: "fitbit", Date: day(2)},
+			{Steps: -1, Source: "fitbit", Date: day(3)},
+			{Steps: 4000, Date: day(4)},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Accepted)
+	assert.Equal(t, 3, result.Rejected)
+	assert.Equal(t, int64(11000), result.Totals.Steps)
+
+	statuses := make([]string, len(result.Records))
+	for i, r := range result.Records {
+		statuses[i] = r.Status
+	}
+	assert.Equal(t, []string{
+		models.BackfillAccepted, models.BackfillAccepted, models.BackfillAccepted,
+		models.BackfillRejected, models.BackfillRejected, models.BackfillRejected,
+	}, statuses)
+	assert.Equal(t, "same day and source as record 1", result.Records[3].Error)
+
+	t.Run("applied sync IDs are reported as duplicates", func(t *testing.T) {
+		result, err := service.BackfillFitnessData(ctx, &models.FitnessBackfillRequest{
+			UserID:        "user-1",
+			CompetitionID: "comp-1",
+			Records:       []models.FitnessBackfillRecord{{Steps: 9000, Source: "fitbit", Date: day(1), SyncID: "b-1"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.BackfillDuplicate, result.Records[0].Status)
+		assert.Equal(t, int64(11000), result.Totals.Steps)
+	})
+
+	t.Run("empty backfills are invalid", func(t *testing.T) {
+		_, err := service.BackfillFitnessData(ctx, &models.FitnessBackfillRequest{UserID: "user-1", CompetitionID: "comp-1"})
+		assert.ErrorIs(t, err, ErrInvalidBackfill)
+	})
+}
+
+func TestFitnessService_SyncRejectsDaysOutsideTheCompetition(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 30, 18, 0, 0, 0, time.UTC)
+	// Each sync checks participation, then loads the competition's dates
+	checks := []fakeQuery{
+		{match: "FROM public.competition_participants", rows: [][]driver.Value{{true}}},
+		{match: "SELECT start_date, end_date", rows: [][]driver.Value{{start, end}}},
+	}
+	db, _ := newFakeDB(t, append(checks, checks...)...)
+	cache := NewCacheService(client)
+	service := NewFitnessService(db, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+	require.NoError(t, cacheCompetitionTimeZone(ctx, cache, "comp-1", "UTC"))
+
+	for _, date := range []time.Time{start.AddDate(0, 0, -1), end.AddDate(0, 0, 1)} {
+		_, err := service.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+			UserID:        "user-1",
+			CompetitionID: "comp-1",
+			Steps:         8000,
+			Source:        "fitbit",
+			Date:          date,
+		})
+		assert.ErrorIs(t, err, ErrInvalidSync, date)
+	}
+}
+
+func TestFitnessService_SyncRejectsFutureDays(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	service := NewFitnessService(nil, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+
+	_, err := service.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+		UserID:        "user-1",
+		CompetitionID: "comp-1",
+		Steps:         8000,
+		Source:        "fitbit",
+		Date:          time.Now().AddDate(0, 0, 2),
+	})
+	assert.ErrorIs(t, err, ErrInvalidSync)
+
+	stats, err := service.GetUserStats(ctx, "user-1", "comp-1")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Steps)
+}
+
+func TestFitnessService_BackfillRequiresParticipation(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "FROM public.competition_participants", rows: [][]driver.Value{{false}}},
+	)
+	cache := NewCacheService(client)
+	service := NewFitnessService(db, cache, NewLeaderboardService(cache, client), "")
+	ctx := context.Background()
+	require.NoError(t, cacheCompetitionTimeZone(ctx, cache, "comp-1", "UTC"))
+
+	_, err := service.BackfillFitnessData(ctx, &models.FitnessBackfillRequest{
+		UserID:        "user-1",
+		CompetitionID: "comp-1",
+		Records:       []models.FitnessBackfillRecord{{Steps: 5000, Source: "fitbit", Date: time.Now()}},
+	})
+	assert.ErrorIs(t, err, ErrNotParticipant)
+	assert.Equal(t, []driver.Value{"comp-1", "user-1"}, fake.args[0])
+}
+
+func TestValidateBackfillRecord(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	window := &competitionWindow{
+		first: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		last:  time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+	}
+	record := func(date time.Time) models.FitnessBackfillRecord {
+		return models.FitnessBackfillRecord{Steps: 100, Source: "fitbit", Date: date}
+	}
+
+	day, err := validateBackfillRecord(record(time.Date(2024, 6, 5, 23, 0, 0, 0, time.UTC)), time.UTC, window, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-05", day.Format("2006-01-02"))
+
+	_, err = validateBackfillRecord(record(time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)), time.UTC, window, now)
+	assert.EqualError(t, err, "2024-05-31 is outside the competition (2024-06-01 to 2024-06-30)")
+
+	_, err = validateBackfillRecord(record(time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC)), time.UTC, window, now)
+	assert.EqualError(t, err, "2024-06-11 is in the future")
+
+	_, err = validateBackfillRecord(models.FitnessBackfillRecord{Source: "fitbit"}, time.UTC, nil, now)
+	assert.EqualError(t, err, "date is required")
+}
+
+func TestFitnessService_SyncUpdatesLeaderboard(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	leaderboard := NewLeaderboardService(cache, client)
+	service := NewFitnessService(nil, cache, leaderboard, "")
+	ctx := context.Background()
+	day := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	sync := func(steps int64, source string) {
+		_, err := service.SyncFitnessData(ctx, &models.FitnessSyncRequest{
+			UserID:        "user-1",
+			CompetitionID: "comp-1",
+			Steps:         steps,
+			Source:        source,
+			Date:          day,
+		})
+		require.NoError(t, err)
+	}
+	score := func() float64 {
+		score, err := leaderboard.GetScore(ctx, "comp-1", "user-1")
+		require.NoError(t, err)
+		return score
+	}
+
+	sync(6000, "fitbit")
+	sync(7000, "apple_health")
+	assert.Equal(t, float64(7000), score())
+
+	// Re-syncing the same daily value doesn't inflate the score
+	sync(6000, "fitbit")
+	assert.Equal(t, float64(7000), score())
+
+	t.Run("syncs still store data once the leaderboard is frozen", func(t *testing.T) {
+		require.NoError(t, leaderboard.FreezeLeaderboard(ctx, "comp-1"))
+		sync(9000, "fitbit")
+		assert.Equal(t, float64(7000), score())
+
+		stats, err := service.GetUserStats(ctx, "user-1", "comp-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(9000), stats.Steps)
+	})
+}
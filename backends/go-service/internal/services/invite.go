@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+var (
+	ErrInviteRequired  = errors.New("an invite code is required to join this competition")
+	ErrInviteNotFound  = errors.New("invite code not found")
+	ErrInviteExpired   = errors.New("invite code has expired or been revoked")
+	ErrInviteExhausted = errors.New("invite code has reached its usage limit")
+	ErrInvalidInvite   = errors.New("invalid invite")
+)
+
+// inviteCodeAlphabet omits characters that are easily confused when read
+// aloud or typed (0/O, 1/I/L)
+const inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const inviteCodeLength = 8
+
+const inviteColumns = `id, competition_id, code, created_by, expires_at, max_uses, uses, revoked_at, created_at`
+
+// CreateInvite generates a new invite code for a competition on behalf of its
+// creator or an admin
+func (s *CompetitionService) CreateInvite(ctx context.Context, competitionID, actorID string, isAdmin bool, req *models.CreateInviteRequest) (*models.CompetitionInvite, error) {
+	if req.MaxUses != nil && *req.MaxUses <= 0 {
+		return nil, fmt.Errorf("%w: max uses must be positive", ErrInvalidInvite)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidInvite)
+	}
+
+	comp, err := s.getOwnedCompetition(ctx, competitionID, actorID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if comp.Status == "completed" || comp.Status == "cancelled" {
+		return nil, ErrCompetitionClosed
+	}
+
+	invite := &models.CompetitionInvite{
+		ID:            uuid.New().String(),
+		CompetitionID: competitionID,
+		CreatedBy:     actorID,
+		ExpiresAt:     req.ExpiresAt,
+		MaxUses:       req.MaxUses,
+		CreatedAt:     time.Now(),
+	}
+
+	query := `
+		INSERT INTO public.competition_invites (id, competition_id, code, created_by, expires_at, max_uses, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	// Codes are random enough that a collision is rare; retry a few times
+	// rather than fail the request when one happens
+	for attempt := 0; ; attempt++ {
+		invite.Code, err = generateInviteCode()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = s.db.ExecContext(ctx, query,
+			invite.ID, invite.CompetitionID, invite.Code, invite.CreatedBy, invite.ExpiresAt, invite.MaxUses, invite.CreatedAt,
+		)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create invite: %w", err)
+		}
+		break
+	}
+
+	invite.Link = s.inviteLink(invite.Code)
+	return invite, nil
+}
+
+// GetInvites lists a competition's invite codes for its creator or an admin
+func (s *CompetitionService) GetInvites(ctx context.Context, competitionID, actorID string, isAdmin bool) ([]models.CompetitionInvite, error) {
+	if _, err := s.getOwnedCompetition(ctx, competitionID, actorID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + inviteColumns + `
+		FROM public.competition_invites
+		WHERE competition_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, competitionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invites: %w", err)
+	}
+	defer rows.Close()
+
+	invites := []models.CompetitionInvite{}
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invite: %w", err)
+		}
+		invite.Link = s.inviteLink(invite.Code)
+		invites = append(invites, *invite)
+	}
+
+	return invites, nil
+}
+
+// RevokeInvite stops an invite code from being used
+func (s *CompetitionService) RevokeInvite(ctx context.Context, competitionID, inviteID, actorID string, isAdmin bool) error {
+	if _, err := s.getOwnedCompetition(ctx, competitionID, actorID, isAdmin); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE public.competition_invites
+		SET revoked_at = NOW()
+		WHERE id = $1 AND competition_id = $2 AND revoked_at IS NULL
+	`
+	res, err := s.db.ExecContext(ctx, query, inviteID, competitionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invite: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInviteNotFound
+	}
+
+	return nil
+}
+
+// GetCompetitionByInviteCode returns the competition an invite code points at
+// so a client can show it before the user joins
+func (s *CompetitionService) GetCompetitionByInviteCode(ctx context.Context, code string) (*models.Competition, error) {
+	query := `SELECT ` + inviteColumns + ` FROM public.competition_invites WHERE code = $1`
+	invite, err := scanInvite(s.db.QueryRowContext(ctx, query, normalizeInviteCode(code)))
+	if err == sql.ErrNoRows {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+	if err := checkInvite(invite, time.Now()); err != nil {
+		return nil, err
+	}
+
+	compQuery := `SELECT ` + competitionColumns + `, ` + competitionParticipantCount + ` FROM public.competitions c WHERE c.id = $1`
+	var comp models.Competition
+	err = scanCompetition(s.db.QueryRowContext(ctx, compQuery, invite.CompetitionID), &comp, &comp.ParticipantCount)
+	if err == sql.ErrNoRows {
+		return nil, ErrCompetitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get competition: %w", err)
+	}
+
+	return &comp, nil
+}
+
+// redeemInvite locks an invite code, checks it is still usable and counts a
+// use. The use is undone if tx is rolled back.
+func (s *CompetitionService) redeemInvite(ctx context.Context, tx *sql.Tx, code string, now time.Time) (*models.CompetitionInvite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM public.competition_invites WHERE code = $1 FOR UPDATE`
+	invite, err := scanInvite(tx.QueryRowContext(ctx, query, normalizeInviteCode(code)))
+	if err == sql.ErrNoRows {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	if err := checkInvite(invite, now); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE public.competition_invites SET uses = uses + 1 WHERE id = $1`, invite.ID); err != nil {
+		return nil, fmt.Errorf("failed to redeem invite: %w", err)
+	}
+	invite.Uses++
+
+	return invite, nil
+}
+
+// getOwnedCompetition loads a competition and checks the actor may manage it
+func (s *CompetitionService) getOwnedCompetition(ctx context.Context, competitionID, actorID string, isAdmin bool) (*models.Competition, error) {
+	comp, err := s.GetCompetitionByID(ctx, competitionID, actorID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if comp.CreatorID != actorID && !isAdmin {
+		return nil, ErrNotCompetitionOwner
+	}
+	return comp, nil
+}
+
+func (s *CompetitionService) inviteLink(code string) string {
+	if s.inviteBaseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(s.inviteBaseURL, "/") + "/" + code
+}
+
+// checkInvite reports whether an invite can still be used at now
+func checkInvite(invite *models.CompetitionInvite, now time.Time) error {
+	if invite.RevokedAt != nil || (invite.ExpiresAt != nil && !now.Before(*invite.ExpiresAt)) {
+		return ErrInviteExpired
+	}
+	if invite.MaxUses != nil && invite.Uses >= *invite.MaxUses {
+		return ErrInviteExhausted
+	}
+	return nil
+}
+
+func generateInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	// The alphabet has 31 characters, so the modulo bias is negligible for
+	// codes that only need to be hard to guess, not uniformly distributed
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// normalizeInviteCode accepts codes typed in lower case or with spaces
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
+
+func scanInvite(row rowScanner) (*models.CompetitionInvite, error) {
+	var invite models.CompetitionInvite
+	var expiresAt, revokedAt sql.NullTime
+	var maxUses sql.NullInt64
+	if err := row.Scan(
+		&invite.ID, &invite.CompetitionID, &invite.Code, &invite.CreatedBy,
+		&expiresAt, &maxUses, &invite.Uses, &revokedAt, &invite.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		invite.ExpiresAt = &expiresAt.Time
+	}
+	if maxUses.Valid {
+		n := int(maxUses.Int64)
+		invite.MaxUses = &n
+	}
+	if revokedAt.Valid {
+		invite.RevokedAt = &revokedAt.Time
+	}
+	return &invite, nil
+}
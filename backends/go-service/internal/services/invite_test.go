@@ -0,0 +1,65 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckInvite(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	two := 2
+
+	tests := []struct {
+		name    string
+		invite  models.CompetitionInvite
+		wantErr error
+	}{
+		{"no limits", models.CompetitionInvite{Uses: 100}, nil},
+		{"not yet expired", models.CompetitionInvite{ExpiresAt: &future}, nil},
+		{"expired", models.CompetitionInvite{ExpiresAt: &past}, ErrInviteExpired},
+		{"expires now", models.CompetitionInvite{ExpiresAt: &now}, ErrInviteExpired},
+		{"revoked", models.CompetitionInvite{RevokedAt: &past}, ErrInviteExpired},
+		{"uses left", models.CompetitionInvite{MaxUses: &two, Uses: 1}, nil},
+		{"used up", models.CompetitionInvite{MaxUses: &two, Uses: 2}, ErrInviteExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, checkInvite(&tt.invite, now))
+		})
+	}
+}
+
+func TestGenerateInviteCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code, err := generateInviteCode()
+		require.NoError(t, err)
+		assert.Len(t, code, inviteCodeLength)
+		for _, c := range code {
+			assert.True(t, strings.ContainsRune(inviteCodeAlphabet, c), "unexpected character %q", c)
+		}
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+func TestNormalizeInviteCode(t *testing.T) {
+	assert.Equal(t, "ABCD2345", normalizeInviteCode(" abcd 2345 "))
+}
+
+func TestCompetitionService_InviteLink(t *testing.T) {
+	s := &CompetitionService{}
+	assert.Empty(t, s.inviteLink("ABCD2345"))
+
+	s.inviteBaseURL = "https://app.example.com/invite/"
+	assert.Equal(t, "https://app.example.com/invite/ABCD2345", s.inviteLink("ABCD2345"))
+}
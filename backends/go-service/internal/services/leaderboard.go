@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/yourusername/health-competition-go/internal/models"
@@ -10,6 +13,17 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+var ErrLeaderboardFrozen = errors.New("leaderboard is frozen")
+
+// Goal-mode finishers are ranked above everyone still in progress by giving
+// them a sorted set score of goalFinisherBase plus a bonus that shrinks the
+// later they finished. Both parts stay well within float64's exact integer
+// range, and progress values never come close to goalFinisherBase.
+const (
+	goalFinisherBase  = 1e15
+	goalFinisherClock = 1e13 // unix milliseconds, good until the year 2286
+)
+
 type LeaderboardService struct {
 	cache       *CacheService
 	redisClient *redis.Client
@@ -46,21 +60,37 @@ func (s *LeaderboardService) GetLeaderboard(ctx context.Context, competitionID s
 			}
 		}
 
+		// Finishers' sorted set scores encode completion time; report
+		// their progress instead
+		score := int64(entry.Score)
+		if entry.Score >= goalFinisherBase {
+			score = userDetails.Score
+		}
+
 		leaderboardEntry := models.LeaderboardEntry{
 			UserID:        userID,
 			UserName:      userDetails.UserName,
 			CompetitionID: competitionID,
-			Score:         int64(entry.Score),
+			Score:         score,
 			Rank:          i + 1,
 			Steps:         userDetails.Steps,
 			Distance:      userDetails.Distance,
 			Calories:      userDetails.Calories,
 			LastSyncedAt:  userDetails.LastSyncedAt,
 			UpdatedAt:     time.Now(),
+			Progress:      userDetails.Progress,
+			CompletedAt:   userDetails.CompletedAt,
 		}
 		leaderboardEntries = append(leaderboardEntries, leaderboardEntry)
 	}
 
+	if err := s.annotateEligibility(ctx, competitionID, leaderboardEntries); err != nil {
+		return nil, err
+	}
+	if err := s.annotateFlags(ctx, competitionID, leaderboardEntries); err != nil {
+		return nil, err
+	}
+
 	totalCount, err := s.redisClient.ZCard(ctx, key).Result()
 	if err != nil {
 		totalCount = int64(len(entries))
@@ -78,17 +108,27 @@ func (s *LeaderboardService) GetLeaderboard(ctx context.Context, competitionID s
 func (s *LeaderboardService) UpdateScore(ctx context.Context, req *models.ScoreUpdateRequest) error {
 	key := s.getLeaderboardKey(req.CompetitionID)
 
-	// Calculate total score (you can customize this formula)
-	score := float64(req.Steps)
+	frozen, err := s.IsFrozen(ctx, req.CompetitionID)
+	if err != nil {
+		return err
+	}
+	if frozen {
+		return ErrLeaderboardFrozen
+	}
 
-	// Update score in sorted set
-	err := s.cache.ZAdd(ctx, key, score, req.UserID)
+	goal, err := s.GetGoal(ctx, req.CompetitionID)
 	if err != nil {
 		return err
 	}
 
-	// Store detailed user data
-	userDetailsKey := s.getUserDetailsKey(req.CompetitionID, req.UserID)
+	metric, err := s.GetMetric(ctx, req.CompetitionID)
+	if err != nil {
+		return err
+	}
+
+	// Calculate total score (you can customize this formula)
+	score := metricValue(metric, req)
+
 	userDetails := &models.LeaderboardEntry{
 		UserID:        req.UserID,
 		CompetitionID: req.CompetitionID,
@@ -100,13 +140,36 @@ func (s *LeaderboardService) UpdateScore(ctx context.Context, req *models.ScoreU
 		UpdatedAt:     time.Now(),
 	}
 
+	// Goal mode ranks by completion time, then by progress towards the target
+	if goal != nil {
+		progress := metricValue(goal.Metric, req)
+		completedAt, err := s.recordCompletion(ctx, req.CompetitionID, req.UserID, goal, progress, time.Now())
+		if err != nil {
+			return err
+		}
+
+		userDetails.Progress = progress
+		userDetails.Score = int64(progress)
+		userDetails.CompletedAt = completedAt
+		score = goalRankScore(progress, completedAt)
+	}
+
+	// Update score in sorted set
+	err = s.cache.ZAdd(ctx, key, score, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Store detailed user data
+	userDetailsKey := s.getUserDetailsKey(req.CompetitionID, req.UserID)
+
 	err = s.cache.Set(ctx, userDetailsKey, userDetails, 24*time.Hour)
 	if err != nil {
 		return err
 	}
 
 	// Publish update to Redis pub/sub for WebSocket broadcasting
-	s.publishLeaderboardUpdate(ctx, req.CompetitionID, req.UserID, int64(score))
+	s.publishLeaderboardUpdate(ctx, req.CompetitionID, req.UserID, userDetails.Score)
 
 	return nil
 }
@@ -124,10 +187,135 @@ func (s *LeaderboardService) GetUserRank(ctx context.Context, competitionID, use
 	return int(rank) + 1, nil
 }
 
-// CalculatePrizes calculates prize distribution for a competition
+// RemoveUser removes a user and their cached details from a leaderboard
+func (s *LeaderboardService) RemoveUser(ctx context.Context, competitionID, userID string) error {
+	if err := s.cache.ZRem(ctx, s.getLeaderboardKey(competitionID), userID); err != nil {
+		return err
+	}
+	if err := s.cache.ZRem(ctx, s.getFinishersKey(competitionID), userID); err != nil {
+		return err
+	}
+	return s.cache.Delete(ctx, s.getUserDetailsKey(competitionID, userID))
+}
+
+// FreezeLeaderboard stops further score updates for a competition
+func (s *LeaderboardService) FreezeLeaderboard(ctx context.Context, competitionID string) error {
+	return s.cache.Set(ctx, s.getFrozenKey(competitionID), true, 0)
+}
+
+// IsFrozen reports whether a competition's leaderboard has been frozen
+func (s *LeaderboardService) IsFrozen(ctx context.Context, competitionID string) (bool, error) {
+	return s.cache.Exists(ctx, s.getFrozenKey(competitionID))
+}
+
+// SetGoal configures goal-mode ranking for a competition; a nil goal restores
+// plain score ranking
+func (s *LeaderboardService) SetGoal(ctx context.Context, competitionID string, goal *models.CompetitionGoal) error {
+	if goal == nil {
+		return s.cache.Delete(ctx, s.getGoalKey(competitionID))
+	}
+	return s.cache.Set(ctx, s.getGoalKey(competitionID), goal, 0)
+}
+
+// GetGoal returns a competition's goal, or nil for score-mode competitions
+func (s *LeaderboardService) GetGoal(ctx context.Context, competitionID string) (*models.CompetitionGoal, error) {
+	var goal models.CompetitionGoal
+	err := s.cache.Get(ctx, s.getGoalKey(competitionID), &goal)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// SetMetric ranks a leaderboard by metric (steps, distance or calories)
+// instead of steps; an empty metric restores the default
+func (s *LeaderboardService) SetMetric(ctx context.Context, competitionID, metric string) error {
+	if metric == "" {
+		return s.cache.Delete(ctx, s.getMetricKey(competitionID))
+	}
+	return s.cache.Set(ctx, s.getMetricKey(competitionID), metric, 0)
+}
+
+// GetMetric returns the metric a leaderboard is ranked by, or "" for steps
+func (s *LeaderboardService) GetMetric(ctx context.Context, competitionID string) (string, error) {
+	var metric string
+	err := s.cache.Get(ctx, s.getMetricKey(competitionID), &metric)
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return metric, nil
+}
+
+// GetScore returns a user's sorted set score, or 0 if they have not synced
+func (s *LeaderboardService) GetScore(ctx context.Context, competitionID, userID string) (float64, error) {
+	score, err := s.cache.ZScore(ctx, s.getLeaderboardKey(competitionID), userID)
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return score, err
+}
+
+// FinisherCount returns how many participants have reached a competition's
+// goal
+func (s *LeaderboardService) FinisherCount(ctx context.Context, competitionID string) (int64, error) {
+	return s.redisClient.ZCard(ctx, s.getFinishersKey(competitionID)).Result()
+}
+
+// recordCompletion records the first time a user's progress reaches the goal
+// target and returns their completion time, or nil if they have not finished.
+// A completion is never undone, even if later syncs report less progress.
+func (s *LeaderboardService) recordCompletion(ctx context.Context, competitionID, userID string, goal *models.CompetitionGoal, progress float64, now time.Time) (*time.Time, error) {
+	key := s.getFinishersKey(competitionID)
+
+	if progress >= goal.Target {
+		// NX keeps the earliest completion if syncs race
+		if err := s.redisClient.ZAddNX(ctx, key, redis.Z{Score: float64(now.UnixMilli()), Member: userID}).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	millis, err := s.cache.ZScore(ctx, key, userID)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	completedAt := time.UnixMilli(int64(millis)).UTC()
+	return &completedAt, nil
+}
+
+// metricValue returns the value of metric in req, defaulting to steps
+func metricValue(metric string, req *models.ScoreUpdateRequest) float64 {
+	switch metric {
+	case "distance":
+		return req.Distance
+	case "calories":
+		return req.Calories
+	default:
+		return float64(req.Steps)
+	}
+}
+
+// goalRankScore returns the sorted set score for a goal-mode participant
+func goalRankScore(progress float64, completedAt *time.Time) float64 {
+	if completedAt == nil {
+		return progress
+	}
+	return goalFinisherBase + goalFinisherClock - float64(completedAt.UnixMilli())
+}
+
+// CalculatePrizes calculates prize distribution for a competition. Ineligible
+// participants are skipped, so prizes go to the top three eligible ones.
 func (s *LeaderboardService) CalculatePrizes(ctx context.Context, competitionID string, prizePool float64) ([]models.Prize, error) {
-	// Get top 3 winners
-	leaderboard, err := s.GetLeaderboard(ctx, competitionID, 3)
+	leaderboard, err := s.GetLeaderboard(ctx, competitionID, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -136,59 +324,207 @@ func (s *LeaderboardService) CalculatePrizes(ctx context.Context, competitionID
 		return nil, fmt.Errorf("no participants in competition")
 	}
 
-	// Prize distribution: 60%, 30%, 10%
+	prizes := topThreePrizes(competitionID, 0, eligibleEntries(leaderboard.Entries), prizePool)
+
+	// Cache prizes
+	prizesKey := s.getPrizesKey(competitionID)
+	s.cache.Set(ctx, prizesKey, prizes, 7*24*time.Hour)
+
+	return prizes, nil
+}
+
+// topThreePrizes splits prizePool 60/30/10 between the first three entries.
+// division is recorded on each prize when it is non-zero.
+func topThreePrizes(competitionID string, division int, entries []models.LeaderboardEntry, prizePool float64) []models.Prize {
 	distribution := models.PrizeDistribution{
 		Rank1Percentage: 0.60,
 		Rank2Percentage: 0.30,
 		Rank3Percentage: 0.10,
 	}
+	shares := []float64{distribution.Rank1Percentage, distribution.Rank2Percentage, distribution.Rank3Percentage}
 
-	prizes := make([]models.Prize, 0)
-
-	// Rank 1
-	if len(leaderboard.Entries) > 0 {
+	prizes := make([]models.Prize, 0, len(shares))
+	for i, share := range shares {
+		if i >= len(entries) {
+			break
+		}
+		id := fmt.Sprintf("prize-%s-%d", competitionID, i+1)
+		if division > 0 {
+			id = fmt.Sprintf("prize-%s-d%d-%d", competitionID, division, i+1)
+		}
 		prizes = append(prizes, models.Prize{
-			ID:            fmt.Sprintf("prize-%s-1", competitionID),
+			ID:            id,
 			CompetitionID: competitionID,
-			UserID:        leaderboard.Entries[0].UserID,
-			Rank:          1,
-			Amount:        prizePool * distribution.Rank1Percentage,
+			UserID:        entries[i].UserID,
+			Rank:          i + 1,
+			Division:      division,
+			Amount:        prizePool * share,
 			Status:        "pending",
 			CreatedAt:     time.Now(),
 		})
 	}
+	return prizes
+}
 
-	// Rank 2
-	if len(leaderboard.Entries) > 1 {
-		prizes = append(prizes, models.Prize{
-			ID:            fmt.Sprintf("prize-%s-2", competitionID),
-			CompetitionID: competitionID,
-			UserID:        leaderboard.Entries[1].UserID,
-			Rank:          2,
-			Amount:        prizePool * distribution.Rank2Percentage,
-			Status:        "pending",
-			CreatedAt:     time.Now(),
-		})
+// GetLeaderboardForUsers ranks only the given users of a competition, such as
+// the members of one division. Users who have not synced yet rank last with a
+// score of zero.
+func (s *LeaderboardService) GetLeaderboardForUsers(ctx context.Context, competitionID string, userIDs []string) (*models.Leaderboard, error) {
+	leaderboard := &models.Leaderboard{
+		CompetitionID: competitionID,
+		Entries:       []models.LeaderboardEntry{},
+		TotalCount:    len(userIDs),
+		UpdatedAt:     time.Now(),
+	}
+	if len(userIDs) == 0 {
+		return leaderboard, nil
 	}
 
-	// Rank 3
-	if len(leaderboard.Entries) > 2 {
-		prizes = append(prizes, models.Prize{
-			ID:            fmt.Sprintf("prize-%s-3", competitionID),
+	scores, err := s.redisClient.ZMScore(ctx, s.getLeaderboardKey(competitionID), userIDs...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	type ranked struct {
+		userID string
+		score  float64
+	}
+	members := make([]ranked, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = ranked{userID: userID, score: scores[i]}
+	}
+	// Ties keep a stable order so ranks don't flicker between requests
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].score != members[j].score {
+			return members[i].score > members[j].score
+		}
+		return members[i].userID < members[j].userID
+	})
+
+	for i, m := range members {
+		entry := models.LeaderboardEntry{
+			UserID:        m.userID,
+			UserName:      "Unknown",
 			CompetitionID: competitionID,
-			UserID:        leaderboard.Entries[2].UserID,
-			Rank:          3,
-			Amount:        prizePool * distribution.Rank3Percentage,
-			Status:        "pending",
-			CreatedAt:     time.Now(),
-		})
+			Score:         int64(m.score),
+			Rank:          i + 1,
+			UpdatedAt:     time.Now(),
+		}
+		if details, err := s.getUserDetails(ctx, competitionID, m.userID); err == nil {
+			entry.UserName = details.UserName
+			entry.Score = details.Score
+			entry.Steps = details.Steps
+			entry.Distance = details.Distance
+			entry.Calories = details.Calories
+			entry.LastSyncedAt = details.LastSyncedAt
+			entry.Progress = details.Progress
+			entry.CompletedAt = details.CompletedAt
+		}
+		leaderboard.Entries = append(leaderboard.Entries, entry)
 	}
 
-	// Cache prizes
-	prizesKey := s.getPrizesKey(competitionID)
-	s.cache.Set(ctx, prizesKey, prizes, 7*24*time.Hour)
+	if err := s.annotateEligibility(ctx, competitionID, leaderboard.Entries); err != nil {
+		return nil, err
+	}
+	if err := s.annotateFlags(ctx, competitionID, leaderboard.Entries); err != nil {
+		return nil, err
+	}
 
-	return prizes, nil
+	return leaderboard, nil
+}
+
+// SetIneligibility replaces the participants of a competition who cannot win
+// a prize, keyed by user with the reasons why. Everyone else is eligible.
+func (s *LeaderboardService) SetIneligibility(ctx context.Context, competitionID string, reasons map[string][]string) error {
+	key := s.getIneligibleKey(competitionID)
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		for userID, r := range reasons {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			pipe.HSet(ctx, key, userID, data)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetIneligibility returns the reasons participants of a competition cannot
+// win a prize, keyed by user
+func (s *LeaderboardService) GetIneligibility(ctx context.Context, competitionID string) (map[string][]string, error) {
+	fields, err := s.redisClient.HGetAll(ctx, s.getIneligibleKey(competitionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := make(map[string][]string, len(fields))
+	for userID, data := range fields {
+		var r []string
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return nil, err
+		}
+		reasons[userID] = r
+	}
+	return reasons, nil
+}
+
+// annotateEligibility sets IneligibleReasons on entries of ineligible users
+func (s *LeaderboardService) annotateEligibility(ctx context.Context, competitionID string, entries []models.LeaderboardEntry) error {
+	reasons, err := s.GetIneligibility(ctx, competitionID)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].IneligibleReasons = reasons[entries[i].UserID]
+	}
+	return nil
+}
+
+// SetFlags replaces the anomaly flags shown on a participant's entry; no
+// flags clears them
+func (s *LeaderboardService) SetFlags(ctx context.Context, competitionID, userID string, flags []string) error {
+	key := s.getFlagsKey(competitionID)
+	if len(flags) == 0 {
+		return s.redisClient.HDel(ctx, key, userID).Err()
+	}
+	data, err := json.Marshal(flags)
+	if err != nil {
+		return err
+	}
+	return s.redisClient.HSet(ctx, key, userID, data).Err()
+}
+
+// GetFlags returns the anomaly flags of a competition's participants, keyed
+// by user
+func (s *LeaderboardService) GetFlags(ctx context.Context, competitionID string) (map[string][]string, error) {
+	fields, err := s.redisClient.HGetAll(ctx, s.getFlagsKey(competitionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string][]string, len(fields))
+	for userID, data := range fields {
+		var f []string
+		if err := json.Unmarshal([]byte(data), &f); err != nil {
+			return nil, err
+		}
+		flags[userID] = f
+	}
+	return flags, nil
+}
+
+// annotateFlags sets Flags on entries of users with records under review
+func (s *LeaderboardService) annotateFlags(ctx context.Context, competitionID string, entries []models.LeaderboardEntry) error {
+	flags, err := s.GetFlags(ctx, competitionID)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].Flags = flags[entries[i].UserID]
+	}
+	return nil
 }
 
 // Helper methods
@@ -200,6 +536,30 @@ func (s *LeaderboardService) getUserDetailsKey(competitionID, userID string) str
 	return fmt.Sprintf("user_details:%s:%s", competitionID, userID)
 }
 
+func (s *LeaderboardService) getFrozenKey(competitionID string) string {
+	return fmt.Sprintf("leaderboard_frozen:%s", competitionID)
+}
+
+func (s *LeaderboardService) getGoalKey(competitionID string) string {
+	return fmt.Sprintf("leaderboard_goal:%s", competitionID)
+}
+
+func (s *LeaderboardService) getMetricKey(competitionID string) string {
+	return fmt.Sprintf("leaderboard_metric:%s", competitionID)
+}
+
+func (s *LeaderboardService) getFinishersKey(competitionID string) string {
+	return fmt.Sprintf("leaderboard_finishers:%s", competitionID)
+}
+
+func (s *LeaderboardService) getIneligibleKey(competitionID string) string {
+	return fmt.Sprintf("leaderboard_ineligible:%s", competitionID)
+}
+
+func (s *LeaderboardService) getFlagsKey(competitionID string) string {
+	return fmt.Sprintf("leaderboard_flags:%s", competitionID)
+}
+
 func (s *LeaderboardService) getPrizesKey(competitionID string) string {
 	return fmt.Sprintf("prizes:%s", competitionID)
 }
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/yourusername/health-competition-go/internal/models"
 
@@ -283,3 +284,199 @@ func BenchmarkLeaderboardService_GetLeaderboard(b *testing.B) {
 		service.GetLeaderboard(ctx, competitionID, 10)
 	}
 }
+
+func TestLeaderboardService_FreezeLeaderboard(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cacheService := NewCacheService(client)
+	service := NewLeaderboardService(cacheService, client)
+
+	ctx := context.Background()
+	competitionID := "test-comp-1"
+
+	req := &models.ScoreUpdateRequest{
+		UserID:        "user-1",
+		CompetitionID: competitionID,
+		Steps:         10000,
+	}
+	require.NoError(t, service.UpdateScore(ctx, req))
+
+	require.NoError(t, service.FreezeLeaderboard(ctx, competitionID))
+
+	frozen, err := service.IsFrozen(ctx, competitionID)
+	assert.NoError(t, err)
+	assert.True(t, frozen)
+
+	// Further updates are rejected and the final score is kept
+	req.Steps = 20000
+	assert.ErrorIs(t, service.UpdateScore(ctx, req), ErrLeaderboardFrozen)
+
+	leaderboard, err := service.GetLeaderboard(ctx, competitionID, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10000), leaderboard.Entries[0].Score)
+}
+
+func TestLeaderboardService_GoalMode(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cacheService := NewCacheService(client)
+	service := NewLeaderboardService(cacheService, client)
+
+	ctx := context.Background()
+	competitionID := "goal-comp-1"
+
+	require.NoError(t, service.SetGoal(ctx, competitionID, &models.CompetitionGoal{Metric: "distance", Target: 100}))
+
+	update := func(userID string, distance float64) {
+		require.NoError(t, service.UpdateScore(ctx, &models.ScoreUpdateRequest{
+			UserID:        userID,
+			CompetitionID: competitionID,
+			Steps:         int64(distance * 1300),
+			Distance:      distance,
+		}))
+		// Completion times are recorded to the millisecond
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	update("first", 100)
+	update("second", 150)
+	update("close", 95)
+	update("far", 20)
+
+	// A later, smaller sync does not undo a completion
+	update("first", 99)
+
+	leaderboard, err := service.GetLeaderboard(ctx, competitionID, 10)
+	require.NoError(t, err)
+	require.Len(t, leaderboard.Entries, 4)
+
+	assert.Equal(t, "first", leaderboard.Entries[0].UserID)
+	assert.NotNil(t, leaderboard.Entries[0].CompletedAt)
+	assert.Equal(t, int64(99), leaderboard.Entries[0].Score)
+
+	assert.Equal(t, "second", leaderboard.Entries[1].UserID)
+	assert.NotNil(t, leaderboard.Entries[1].CompletedAt)
+	assert.True(t, leaderboard.Entries[0].CompletedAt.Before(*leaderboard.Entries[1].CompletedAt))
+
+	assert.Equal(t, "close", leaderboard.Entries[2].UserID)
+	assert.Nil(t, leaderboard.Entries[2].CompletedAt)
+	assert.Equal(t, 95.0, leaderboard.Entries[2].Progress)
+	assert.Equal(t, "far", leaderboard.Entries[3].UserID)
+
+	finishers, err := service.FinisherCount(ctx, competitionID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), finishers)
+
+	// Clearing the goal goes back to plain step scoring
+	require.NoError(t, service.SetGoal(ctx, competitionID, nil))
+	goal, err := service.GetGoal(ctx, competitionID)
+	require.NoError(t, err)
+	assert.Nil(t, goal)
+}
+
+func TestLeaderboardService_Metric(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cacheService := NewCacheService(client)
+	service := NewLeaderboardService(cacheService, client)
+
+	ctx := context.Background()
+	boardID := challengeBoardID("challenge-1")
+
+	require.NoError(t, service.SetMetric(ctx, boardID, "distance"))
+
+	require.NoError(t, service.UpdateScore(ctx, &models.ScoreUpdateRequest{UserID: "walker", CompetitionID: boardID, Steps: 20000, Distance: 12.5}))
+	require.NoError(t, service.UpdateScore(ctx, &models.ScoreUpdateRequest{UserID: "runner", CompetitionID: boardID, Steps: 15000, Distance: 16}))
+
+	leaderboard, err := service.GetLeaderboard(ctx, boardID, 2)
+	require.NoError(t, err)
+	require.Len(t, leaderboard.Entries, 2)
+	assert.Equal(t, "runner", leaderboard.Entries[0].UserID)
+
+	score, err := service.GetScore(ctx, boardID, "walker")
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, score)
+
+	score, err = service.GetScore(ctx, boardID, "nobody")
+	require.NoError(t, err)
+	assert.Zero(t, score)
+
+	// Other leaderboards still rank by steps
+	metric, err := service.GetMetric(ctx, "test-comp-1")
+	require.NoError(t, err)
+	assert.Empty(t, metric)
+}
+
+func TestLeaderboardService_GetLeaderboardForUsers(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cacheService := NewCacheService(client)
+	service := NewLeaderboardService(cacheService, client)
+
+	ctx := context.Background()
+	competitionID := "division-comp"
+
+	for userID, steps := range map[string]int64{"pro": 30000, "mid": 12000, "casual": 4000, "other": 8000} {
+		require.NoError(t, service.UpdateScore(ctx, &models.ScoreUpdateRequest{UserID: userID, CompetitionID: competitionID, Steps: steps}))
+	}
+
+	leaderboard, err := service.GetLeaderboardForUsers(ctx, competitionID, []string{"casual", "idle", "other"})
+	require.NoError(t, err)
+	require.Len(t, leaderboard.Entries, 3)
+
+	assert.Equal(t, "other", leaderboard.Entries[0].UserID)
+	assert.Equal(t, int64(8000), leaderboard.Entries[0].Score)
+	assert.Equal(t, "casual", leaderboard.Entries[1].UserID)
+	assert.Equal(t, 2, leaderboard.Entries[1].Rank)
+	assert.Equal(t, "idle", leaderboard.Entries[2].UserID, "users without a score rank last")
+	assert.Zero(t, leaderboard.Entries[2].Score)
+}
+
+func TestLeaderboardService_Ineligibility(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	service := NewLeaderboardService(NewCacheService(client), client)
+	ctx := context.Background()
+	competitionID := "test-comp-1"
+
+	for userID, steps := range map[string]int64{"user-1": 18000, "user-2": 15000, "user-3": 12000, "user-4": 9000} {
+		require.NoError(t, service.UpdateScore(ctx, &models.ScoreUpdateRequest{
+			UserID:        userID,
+			CompetitionID: competitionID,
+			Steps:         steps,
+		}))
+	}
+
+	reasons := map[string][]string{"user-1": {"active on 2 of the 5 days required"}}
+	require.NoError(t, service.SetIneligibility(ctx, competitionID, reasons))
+
+	t.Run("ineligible users stay on the board with their reasons", func(t *testing.T) {
+		board, err := service.GetLeaderboard(ctx, competitionID, 10)
+		require.NoError(t, err)
+		require.Len(t, board.Entries, 4)
+		assert.Equal(t, "user-1", board.Entries[0].UserID)
+		assert.Equal(t, reasons["user-1"], board.Entries[0].IneligibleReasons)
+		assert.Empty(t, board.Entries[1].IneligibleReasons)
+	})
+
+	t.Run("prizes skip ineligible users", func(t *testing.T) {
+		prizes, err := service.CalculatePrizes(ctx, competitionID, 1000)
+		require.NoError(t, err)
+		require.Len(t, prizes, 3)
+		assert.Equal(t, "user-2", prizes[0].UserID)
+		assert.Equal(t, 600.0, prizes[0].Amount)
+		assert.Equal(t, "user-4", prizes[2].UserID)
+	})
+
+	t.Run("setting again replaces the previous reasons", func(t *testing.T) {
+		require.NoError(t, service.SetIneligibility(ctx, competitionID, nil))
+		got, err := service.GetIneligibility(ctx, competitionID)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
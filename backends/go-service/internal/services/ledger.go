@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+// Ledger entry types
+const (
+	LedgerTypePrize             = "prize"
+	LedgerTypeWithdrawalHold    = "withdrawal_hold"
+	LedgerTypeWithdrawalRelease = "withdrawal_release"
+	LedgerTypeChallengeStake    = "challenge_stake"
+	LedgerTypeChallengeRelease  = "challenge_release"
+	LedgerTypeChallengePayout   = "challenge_payout"
+)
+
+// LedgerService tracks user balances as an append-only list of credits and debits
+type LedgerService struct {
+	db *sql.DB
+}
+
+func NewLedgerService(db *sql.DB) *LedgerService {
+	return &LedgerService{
+		db: db,
+	}
+}
+
+// GetBalance returns the user's current withdrawable balance
+func (s *LedgerService) GetBalance(ctx context.Context, userID string) (float64, error) {
+	return ledgerBalance(ctx, s.db, userID)
+}
+
+// GetEntries retrieves the most recent ledger entries for a user
+func (s *LedgerService) GetEntries(ctx context.Context, userID string, limit int) ([]models.LedgerEntry, error) {
+	query := `
+		SELECT id, user_id, amount, type, reference_id, description, created_at
+		FROM public.ledger_entries
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.LedgerEntry
+	for rows.Next() {
+		var e models.LedgerEntry
+		var referenceID sql.NullString
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Amount, &e.Type, &referenceID, &e.Description, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		e.ReferenceID = referenceID.String
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// ledgerBalance sums a user's ledger entries using the given executor
+func ledgerBalance(ctx context.Context, db dbExecutor, userID string) (float64, error) {
+	var balance float64
+	query := `SELECT COALESCE(SUM(amount), 0) FROM public.ledger_entries WHERE user_id = $1`
+	if err := db.QueryRowContext(ctx, query, userID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return balance, nil
+}
+
+// recordLedgerEntry appends an entry to the ledger using the given executor
+func recordLedgerEntry(ctx context.Context, db dbExecutor, userID string, amount float64, entryType, referenceID, description string) error {
+	query := `
+		INSERT INTO public.ledger_entries (id, user_id, amount, type, reference_id, description, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6, $7)
+	`
+
+	_, err := db.ExecContext(ctx, query, uuid.New().String(), userID, amount, entryType, referenceID, description, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record ledger entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+// ErrInvalidMergeSettings is returned for source merge settings that cannot
+// be applied
+var ErrInvalidMergeSettings = errors.New("invalid source merge settings")
+
+// defaultMergeSettings applies to users who never chose; taking the highest
+// value keeps a phone and a watch from counting the same walk twice
+var defaultMergeSettings = models.SourceMergeSettings{Policy: models.MergeMax}
+
+// mergeMetric reads and writes one metric of a record and its chosen source
+type mergeMetric struct {
+	value  func(d *models.FitnessData) float64
+	copy   func(to, from *models.FitnessData)
+	source func(m *models.MetricSources) *string
+}
+
+var mergeMetrics = []mergeMetric{
+	{
+		value:  func(d *models.FitnessData) float64 { return float64(d.Steps) },
+		copy:   func(to, from *models.FitnessData) { to.Steps = from.Steps },
+		source: func(m *models.MetricSources) *string { return &m.Steps },
+	},
+	{
+		value:  func(d *models.FitnessData) float64 { return d.Distance },
+		copy:   func(to, from *models.FitnessData) { to.Distance = from.Distance },
+		source: func(m *models.MetricSources) *string { return &m.Distance },
+	},
+	{
+		value:  func(d *models.FitnessData) float64 { return d.Calories },
+		copy:   func(to, from *models.FitnessData) { to.Calories = from.Calories },
+		source: func(m *models.MetricSources) *string { return &m.Calories },
+	},
+	{
+		value:  func(d *models.FitnessData) float64 { return float64(d.ActiveMinutes) },
+		copy:   func(to, from *models.FitnessData) { to.ActiveMinutes = from.ActiveMinutes },
+		source: func(m *models.MetricSources) *string { return &m.ActiveMinutes },
+	},
+}
+
+// mergeSources turns the records of one day's sources into the day's record.
+// Each metric is taken whole from one source, recorded in MetricSources;
+// sources without a value for a metric are never chosen for it.
+func mergeSources(records []models.FitnessData, settings models.SourceMergeSettings) *models.FitnessData {
+	if len(records) == 0 {
+		return nil
+	}
+
+	// Most preferred first: sources in the priority list in order, then the
+	// rest alphabetically
+	ranked := make([]models.FitnessData, len(records))
+	copy(ranked, records)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		ri, rj := sourceRank(settings.Priority, ranked[i].Source), sourceRank(settings.Priority, ranked[j].Source)
+		if ri != rj {
+			return ri < rj
+		}
+		return ranked[i].Source < ranked[j].Source
+	})
+
+	merged := &models.FitnessData{
+		UserID:        ranked[0].UserID,
+		CompetitionID: ranked[0].CompetitionID,
+		Date:          ranked[0].Date,
+		MetricSources: &models.MetricSources{},
+	}
+	for _, r := range ranked {
+		if r.SyncedAt.After(merged.SyncedAt) {
+			merged.SyncedAt = r.SyncedAt
+		}
+		if merged.CreatedAt.IsZero() || (!r.CreatedAt.IsZero() && r.CreatedAt.Before(merged.CreatedAt)) {
+			merged.CreatedAt = r.CreatedAt
+		}
+	}
+
+	for _, metric := range mergeMetrics {
+		var chosen *models.FitnessData
+		if settings.Policy == models.MergePerMetric {
+			want := *metric.source(&settings.MetricSources)
+			for i := range ranked {
+				if want != "" && ranked[i].Source == want && metric.value(&ranked[i]) > 0 {
+					chosen = &ranked[i]
+					break
+				}
+			}
+		}
+		if chosen == nil {
+			for i := range ranked {
+				if metric.value(&ranked[i]) <= 0 {
+					continue
+				}
+				// Priority and per-metric fallback take the first source
+				// reporting the metric; max keeps looking for a higher value
+				if chosen == nil || (settings.Policy == models.MergeMax && metric.value(&ranked[i]) > metric.value(chosen)) {
+					chosen = &ranked[i]
+				}
+			}
+		}
+		if chosen != nil {
+			metric.copy(merged, chosen)
+			*metric.source(merged.MetricSources) = chosen.Source
+		}
+	}
+
+	merged.Source = strings.Join(chosenSources(merged.MetricSources), ",")
+	return merged
+}
+
+// sourceRank is source's position in priority, or after all of it
+func sourceRank(priority []string, source string) int {
+	for i, p := range priority {
+		if p == source {
+			return i
+		}
+	}
+	return len(priority)
+}
+
+// chosenSources lists the distinct sources a merged record was taken from
+func chosenSources(m *models.MetricSources) []string {
+	var sources []string
+	for _, s := range []string{m.Steps, m.Distance, m.Calories, m.ActiveMinutes} {
+		if s != "" && !containsFold(sources, s) {
+			sources = append(sources, s)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// validateMergeSettings checks settings and fills in the default policy
+func validateMergeSettings(settings *models.SourceMergeSettings) error {
+	if settings.Policy == "" {
+		settings.Policy = models.MergeMax
+	}
+	switch settings.Policy {
+	case models.MergeMax, models.MergePriority, models.MergePerMetric:
+	default:
+		return fmt.Errorf("%w: policy must be max, priority or per_metric", ErrInvalidMergeSettings)
+	}
+
+	for i, source := range settings.Priority {
+		if strings.TrimSpace(source) == "" {
+			return fmt.Errorf("%w: priority sources cannot be empty", ErrInvalidMergeSettings)
+		}
+		if sourceRank(settings.Priority[:i], source) < i {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidMergeSettings, source)
+		}
+	}
+	if settings.Policy == models.MergePerMetric && len(chosenSources(&settings.MetricSources)) == 0 {
+		return fmt.Errorf("%w: per_metric needs a source for at least one metric", ErrInvalidMergeSettings)
+	}
+	return nil
+}
+
+// GetMergeSettings returns how a user's sources are merged
+func (s *FitnessService) GetMergeSettings(ctx context.Context, userID string) (*models.SourceMergeSettings, error) {
+	settings, err := loadMergeSettings(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SetMergeSettings changes how a user's sources are merged and re-merges the
+// days they have synced to upcoming and active competitions, updating their
+// totals and leaderboard scores. Days of completed and cancelled competitions
+// are left as merged: prizes and divisions were settled on them.
+func (s *FitnessService) SetMergeSettings(ctx context.Context, userID string, settings *models.SourceMergeSettings) error {
+	if err := validateMergeSettings(settings); err != nil {
+		return err
+	}
+
+	// Days touched, by competition
+	days := map[string][]time.Time{}
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO public.fitness_source_settings (user_id, merge_policy, source_priority,
+				steps_source, distance_source, calories_source, active_minutes_source, updated_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NOW())
+			ON CONFLICT (user_id) DO UPDATE SET
+				merge_policy = EXCLUDED.merge_policy,
+				source_priority = EXCLUDED.source_priority,
+				steps_source = EXCLUDED.steps_source,
+				distance_source = EXCLUDED.distance_source,
+				calories_source = EXCLUDED.calories_source,
+				active_minutes_source = EXCLUDED.active_minutes_source,
+				updated_at = EXCLUDED.updated_at
+		`
+		m := settings.MetricSources
+		if _, err := tx.ExecContext(ctx, query, userID, settings.Policy, pq.StringArray(settings.Priority),
+			m.Steps, m.Distance, m.Calories, m.ActiveMinutes); err != nil {
+			return fmt.Errorf("failed to save source merge settings: %w", err)
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT DISTINCT fd.competition_id, fd.date
+			FROM public.fitness_data fd
+			JOIN public.competitions c ON c.id = fd.competition_id
+			WHERE fd.user_id = $1 AND c.status IN ('upcoming', 'active')
+		`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to query synced days: %w", err)
+		}
+		for rows.Next() {
+			var competitionID string
+			var date time.Time
+			if err := rows.Scan(&competitionID, &date); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan synced day: %w", err)
+			}
+			days[competitionID] = append(days[competitionID], date)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for competitionID, dates := range days {
+			for _, date := range dates {
+				if _, err := mergeDay(ctx, tx, userID, competitionID, date); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for competitionID, dates := range days {
+		// Dates come back as UTC midnight; the cache keys days in the
+		// competition's zone
+		loc, err := competitionLocation(ctx, s.cache, s.db, competitionID)
+		if err != nil {
+			return err
+		}
+		for i, date := range dates {
+			dates[i] = wallClockIn(date, loc)
+		}
+		if err := s.refreshCache(ctx, userID, competitionID, dates...); err != nil {
+			return err
+		}
+		totals, err := s.GetUserStats(ctx, userID, competitionID)
+		if err != nil {
+			return err
+		}
+		if err := s.updateLeaderboard(ctx, totals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadMergeSettings returns a user's merge settings, or the defaults
+func loadMergeSettings(ctx context.Context, db dbExecutor, userID string) (models.SourceMergeSettings, error) {
+	query := `
+		SELECT merge_policy, source_priority, COALESCE(steps_source, ''), COALESCE(distance_source, ''),
+			COALESCE(calories_source, ''), COALESCE(active_minutes_source, '')
+		FROM public.fitness_source_settings
+		WHERE user_id = $1
+	`
+	var settings models.SourceMergeSettings
+	var priority pq.StringArray
+	m := &settings.MetricSources
+	err := db.QueryRowContext(ctx, query, userID).Scan(
+		&settings.Policy, &priority, &m.Steps, &m.Distance, &m.Calories, &m.ActiveMinutes,
+	)
+	if err == sql.ErrNoRows {
+		return defaultMergeSettings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to get source merge settings: %w", err)
+	}
+	settings.Priority = []string(priority)
+	return settings, nil
+}
+
+// mergeDay rebuilds a user's merged record for one competition day from its
+// sources and returns how much that changed their totals
+func mergeDay(ctx context.Context, tx *sql.Tx, userID, competitionID string, day time.Time) (models.FitnessDelta, error) {
+	settings, err := loadMergeSettings(ctx, tx, userID)
+	if err != nil {
+		return models.FitnessDelta{}, err
+	}
+
+	date := day.Format("2006-01-02")
+	rows, err := tx.QueryContext(ctx, `
+		SELECT source, steps, distance, calories, active_minutes, synced_at, created_at
+		FROM public.fitness_data
+		WHERE user_id = $1 AND competition_id = $2 AND date = $3
+	`, userID, competitionID, date)
+	if err != nil {
+		return models.FitnessDelta{}, fmt.Errorf("failed to query source data: %w", err)
+	}
+	var records []models.FitnessData
+	for rows.Next() {
+		r := models.FitnessData{UserID: userID, CompetitionID: competitionID, Date: day}
+		if err := rows.Scan(&r.Source, &r.Steps, &r.Distance, &r.Calories, &r.ActiveMinutes, &r.SyncedAt, &r.CreatedAt); err != nil {
+			rows.Close()
+			return models.FitnessDelta{}, fmt.Errorf("failed to scan source data: %w", err)
+		}
+		records = append(records, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.FitnessDelta{}, err
+	}
+
+	var previous models.FitnessData
+	err = tx.QueryRowContext(ctx, `
+		SELECT steps, distance, calories, active_minutes
+		FROM public.fitness_daily
+		WHERE user_id = $1 AND competition_id = $2 AND date = $3
+		FOR UPDATE
+	`, userID, competitionID, date).Scan(&previous.Steps, &previous.Distance, &previous.Calories, &previous.ActiveMinutes)
+	if err != nil && err != sql.ErrNoRows {
+		return models.FitnessDelta{}, fmt.Errorf("failed to get merged fitness data: %w", err)
+	}
+
+	merged := mergeSources(records, settings)
+	if merged == nil {
+		merged = &models.FitnessData{MetricSources: &models.MetricSources{}}
+	}
+	m := merged.MetricSources
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO public.fitness_daily (user_id, competition_id, date, steps, distance, calories, active_minutes,
+			steps_source, distance_source, calories_source, active_minutes_source, merge_policy, merged_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), $12, NOW())
+		ON CONFLICT (user_id, competition_id, date) DO UPDATE SET
+			steps = EXCLUDED.steps,
+			distance = EXCLUDED.distance,
+			calories = EXCLUDED.calories,
+			active_minutes = EXCLUDED.active_minutes,
+			steps_source = EXCLUDED.steps_source,
+			distance_source = EXCLUDED.distance_source,
+			calories_source = EXCLUDED.calories_source,
+			active_minutes_source = EXCLUDED.active_minutes_source,
+			merge_policy = EXCLUDED.merge_policy,
+			merged_at = EXCLUDED.merged_at
+	`, userID, competitionID, date, merged.Steps, merged.Distance, merged.Calories, merged.ActiveMinutes,
+		m.Steps, m.Distance, m.Calories, m.ActiveMinutes, settings.Policy)
+	if err != nil {
+		return models.FitnessDelta{}, fmt.Errorf("failed to store merged fitness data: %w", err)
+	}
+
+	return fitnessDelta(&previous, merged), nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeSources(t *testing.T) {
+	phone := models.FitnessData{Source: "google_fit", Steps: 9000, Distance: 6500, Calories: 300}
+	watch := models.FitnessData{Source: "fitbit", Steps: 8000, Distance: 7000, ActiveMinutes: 55}
+	records := []models.FitnessData{phone, watch}
+
+	t.Run("max takes each metric's highest value", func(t *testing.T) {
+		merged := mergeSources(records, models.SourceMergeSettings{Policy: models.MergeMax})
+		assert.Equal(t, int64(9000), merged.Steps)
+		assert.Equal(t, 7000.0, merged.Distance)
+		assert.Equal(t, 300.0, merged.Calories)
+		assert.Equal(t, 55, merged.ActiveMinutes)
+		assert.Equal(t, models.MetricSources{Steps: "google_fit", Distance: "fitbit", Calories: "google_fit", ActiveMinutes: "fitbit"}, *merged.MetricSources)
+		assert.Equal(t, "fitbit,google_fit", merged.Source)
+	})
+
+	t.Run("priority prefers the first source reporting each metric", func(t *testing.T) {
+		merged := mergeSources(records, models.SourceMergeSettings{Policy: models.MergePriority, Priority: []string{"fitbit"}})
+		assert.Equal(t, int64(8000), merged.Steps)
+		assert.Equal(t, 7000.0, merged.Distance)
+		assert.Equal(t, 300.0, merged.Calories, "falls back to a source that has calories")
+		assert.Equal(t, "google_fit", merged.MetricSources.Calories)
+	})
+
+	t.Run("per metric takes the chosen source, else priority", func(t *testing.T) {
+		merged := mergeSources(records, models.SourceMergeSettings{
+			Policy:        models.MergePerMetric,
+			Priority:      []string{"google_fit"},
+			MetricSources: models.MetricSources{Steps: "fitbit", ActiveMinutes: "google_fit"},
+		})
+		assert.Equal(t, int64(8000), merged.Steps)
+		assert.Equal(t, 6500.0, merged.Distance)
+		assert.Equal(t, 55, merged.ActiveMinutes, "google_fit has no active minutes")
+	})
+
+	t.Run("ties go to the preferred source", func(t *testing.T) {
+		merged := mergeSources([]models.FitnessData{
+			{Source: "strava", Steps: 5000},
+			{Source: "apple_health", Steps: 5000},
+		}, models.SourceMergeSettings{Policy: models.MergeMax, Priority: []string{"strava"}})
+		assert.Equal(t, "strava", merged.MetricSources.Steps)
+	})
+
+	assert.Nil(t, mergeSources(nil, defaultMergeSettings))
+}
+
+func TestValidateMergeSettings(t *testing.T) {
+	settings := &models.SourceMergeSettings{}
+	require.NoError(t, validateMergeSettings(settings))
+	assert.Equal(t, models.MergeMax, settings.Policy)
+
+	for _, invalid := range []models.SourceMergeSettings{
+		{Policy: "sum"},
+		{Policy: models.MergePriority, Priority: []string{"fitbit", "fitbit"}},
+		{Policy: models.MergePriority, Priority: []string{" "}},
+		{Policy: models.MergePerMetric},
+	} {
+		assert.ErrorIs(t, validateMergeSettings(&invalid), ErrInvalidMergeSettings, "%+v", invalid)
+	}
+}
+
+func TestSetMergeSettings_LeavesSettledCompetitionsAlone(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "INSERT INTO public.fitness_source_settings", affected: 1},
+		// Only days of upcoming and active competitions are merged again
+		fakeQuery{match: "c.status IN ('upcoming', 'active')"},
+	)
+	cache := NewCacheService(client)
+	service := NewFitnessService(db, cache, NewLeaderboardService(cache, client), "")
+
+	require.NoError(t, service.SetMergeSettings(context.Background(), "user-1", &models.SourceMergeSettings{Policy: models.MergeMax}))
+	assert.Equal(t, "user-1", fake.args[1][0])
+}
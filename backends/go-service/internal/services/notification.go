@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+type NotificationService struct {
+	db    *sql.DB
+	cache *CacheService
+}
+
+func NewNotificationService(db *sql.DB, cache *CacheService) *NotificationService {
+	return &NotificationService{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// NotifyParticipants stores a notification for every participant of a
+// competition and publishes it to each user's Redis channel
+func (s *NotificationService) NotifyParticipants(ctx context.Context, db dbExecutor, competitionID, notificationType, title, message string) error {
+	query := `
+		INSERT INTO public.notifications (user_id, type, title, message, competition_id, created_at)
+		SELECT cp.user_id, $2, $3, $4, cp.competition_id, $5
+		FROM public.competition_participants cp
+		WHERE cp.competition_id = $1
+		RETURNING id, user_id, created_at
+	`
+
+	rows, err := db.QueryContext(ctx, query, competitionID, notificationType, title, message, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to create notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		n := models.Notification{
+			Type:          notificationType,
+			Title:         title,
+			Message:       message,
+			CompetitionID: competitionID,
+		}
+		if err := rows.Scan(&n.ID, &n.UserID, &n.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to create notifications: %w", err)
+	}
+
+	for _, n := range notifications {
+		s.publish(ctx, &n)
+	}
+
+	return nil
+}
+
+// NotifyUser stores a single notification and publishes it
+func (s *NotificationService) NotifyUser(ctx context.Context, db dbExecutor, n *models.Notification) error {
+	query := `
+		INSERT INTO public.notifications (user_id, type, title, message, competition_id, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6)
+		RETURNING id
+	`
+
+	n.CreatedAt = time.Now()
+	if err := db.QueryRowContext(ctx, query,
+		n.UserID, n.Type, n.Title, n.Message, n.CompetitionID, n.CreatedAt,
+	).Scan(&n.ID); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	s.publish(ctx, n)
+	return nil
+}
+
+// GetUserNotifications retrieves the most recent notifications for a user
+func (s *NotificationService) GetUserNotifications(ctx context.Context, userID string, limit int) ([]models.Notification, error) {
+	query := `
+		SELECT id, user_id, type, title, message, competition_id, read_at, created_at
+		FROM public.notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var competitionID sql.NullString
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Message, &competitionID, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		n.CompetitionID = competitionID.String
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
+
+// MarkRead marks a user's notification as read
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID string) error {
+	query := `UPDATE public.notifications SET read_at = $1 WHERE id = $2 AND user_id = $3 AND read_at IS NULL`
+	if _, err := s.db.ExecContext(ctx, query, time.Now(), notificationID, userID); err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return nil
+}
+
+// publish pushes a notification to the user's channel; delivery is best effort
+func (s *NotificationService) publish(ctx context.Context, n *models.Notification) {
+	channel := fmt.Sprintf("notifications:%s", n.UserID)
+	s.cache.Publish(ctx, channel, n)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+// privateParticipantName replaces the name of users with a private profile
+const privateParticipantName = "Private participant"
+
+// competitionParticipantCount is the participant count column read alongside
+// competitionColumns; queries must alias public.competitions as c
+const competitionParticipantCount = `(SELECT COUNT(*) FROM public.competition_participants pcc WHERE pcc.competition_id = c.id)`
+
+// GetParticipants lists a page of a competition's participants in join order
+// with their current rank. The competition must be visible to the viewer.
+func (s *CompetitionService) GetParticipants(ctx context.Context, competitionID, viewerID string, isAdmin bool, limit, offset int) (*models.ParticipantPage, error) {
+	comp, err := s.GetCompetitionByID(ctx, competitionID, viewerID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT cp.user_id, COALESCE(u.name, 'User'), COALESCE(u.avatar, ''), COALESCE(u.country, ''),
+			COALESCE(u.profile_visibility, 'public'), cp.joined_at, cp.division
+		FROM public.competition_participants cp
+		LEFT JOIN public.users u ON u.id = cp.user_id
+		WHERE cp.competition_id = $1
+		ORDER BY cp.joined_at, cp.id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, competitionID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query participants: %w", err)
+	}
+	defer rows.Close()
+
+	page := &models.ParticipantPage{
+		CompetitionID: competitionID,
+		Participants:  []models.CompetitionParticipant{},
+		TotalCount:    comp.ParticipantCount,
+		Limit:         limit,
+		Offset:        offset,
+	}
+	for rows.Next() {
+		var p models.CompetitionParticipant
+		var visibility string
+		var division sql.NullInt64
+		if err := rows.Scan(&p.UserID, &p.Name, &p.Avatar, &p.Country, &visibility, &p.JoinedAt, &division); err != nil {
+			return nil, fmt.Errorf("failed to scan participant: %w", err)
+		}
+		if division.Valid {
+			d := int(division.Int64)
+			p.Division = &d
+		}
+		applyProfilePrivacy(&p, visibility, viewerID, isAdmin)
+		page.Participants = append(page.Participants, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read participants: %w", err)
+	}
+
+	for i := range page.Participants {
+		rank, err := s.leaderboard.GetUserRank(ctx, competitionID, page.Participants[i].UserID)
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get participant rank: %w", err)
+		}
+		page.Participants[i].Rank = &rank
+	}
+
+	return page, nil
+}
+
+// applyProfilePrivacy hides the profile details of a participant who made
+// their profile private, unless the viewer is that participant or an admin
+func applyProfilePrivacy(p *models.CompetitionParticipant, visibility, viewerID string, isAdmin bool) {
+	if visibility != models.ProfilePrivate {
+		return
+	}
+	p.Private = true
+	if isAdmin || p.UserID == viewerID {
+		return
+	}
+	p.Name = privateParticipantName
+	p.Avatar = ""
+	p.Country = ""
+}
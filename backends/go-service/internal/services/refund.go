@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/health-competition-go/internal/models"
+)
+
+// createEntryFeeRefunds records pending refund transactions for percent of the
+// paid entry fees of the given participants (all participants when userID is
+// empty). A zero refund is recorded as completed so the outcome is still kept.
+func (s *CompetitionService) createEntryFeeRefunds(ctx context.Context, tx *sql.Tx, competitionID, userID string, percent float64, description string) ([]models.Transaction, error) {
+	query := `
+		SELECT t.id, t.user_id, t.amount
+		FROM public.competition_participants cp
+		INNER JOIN public.transactions t ON t.id = cp.entry_transaction_id
+		WHERE cp.competition_id = $1 AND ($2 = '' OR cp.user_id::text = $2) AND t.status = 'completed'
+	`
+
+	rows, err := tx.QueryContext(ctx, query, competitionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paid entries: %w", err)
+	}
+
+	type paidEntry struct {
+		transactionID string
+		userID        string
+		amount        float64
+	}
+	var entries []paidEntry
+	for rows.Next() {
+		var e paidEntry
+		if err := rows.Scan(&e.transactionID, &e.userID, &e.amount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan paid entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	refunds := make([]models.Transaction, 0, len(entries))
+	for _, e := range entries {
+		refund := models.Transaction{
+			ID:            uuid.New().String(),
+			UserID:        e.userID,
+			CompetitionID: competitionID,
+			Type:          "refund",
+			Amount:        math.Round(e.amount*percent) / 100,
+			Status:        "pending",
+			Description:   description,
+			PaymentMethod: s.payments.Name(),
+			CreatedAt:     time.Now(),
+		}
+		if refund.Amount <= 0 {
+			refund.Amount = 0
+			refund.Status = "completed"
+			refund.CompletedAt = &refund.CreatedAt
+		}
+
+		insertQuery := `
+			INSERT INTO public.transactions (id, user_id, competition_id, type, amount, status, description, payment_method, parent_transaction_id, created_at, completed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`
+		if _, err := tx.ExecContext(ctx, insertQuery,
+			refund.ID, refund.UserID, refund.CompetitionID, refund.Type, refund.Amount, refund.Status,
+			refund.Description, refund.PaymentMethod, e.transactionID, refund.CreatedAt, refund.CompletedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to create refund transaction: %w", err)
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, nil
+}
+
+// processRefunds sends pending refunds to the payment provider and records
+// the outcome on each transaction. It returns the number of failed refunds.
+func (s *CompetitionService) processRefunds(ctx context.Context, refunds []models.Transaction) int {
+	failed := 0
+	for i := range refunds {
+		if refunds[i].Status != "pending" {
+			continue
+		}
+		if err := s.processRefund(ctx, &refunds[i]); err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// processRefund refunds the charge behind a pending refund transaction
+func (s *CompetitionService) processRefund(ctx context.Context, refund *models.Transaction) error {
+	var chargeRef string
+	chargeQuery := `
+		SELECT COALESCE(p.transaction_ref, '')
+		FROM public.transactions r
+		INNER JOIN public.transactions p ON p.id = r.parent_transaction_id
+		WHERE r.id = $1
+	`
+	if err := s.db.QueryRowContext(ctx, chargeQuery, refund.ID).Scan(&chargeRef); err != nil {
+		return s.failRefund(ctx, refund, fmt.Errorf("failed to get original charge: %w", err))
+	}
+
+	result, err := s.payments.Refund(ctx, chargeRef, refund.Amount)
+	if err != nil {
+		return s.failRefund(ctx, refund, err)
+	}
+
+	updateQuery := `
+		UPDATE public.transactions
+		SET status = 'completed', transaction_ref = $1, completed_at = $2
+		WHERE id = $3
+	`
+	if _, err := s.db.ExecContext(ctx, updateQuery, result.Reference, result.CompletedAt, refund.ID); err != nil {
+		return fmt.Errorf("failed to complete refund: %w", err)
+	}
+
+	refund.Status = "completed"
+	refund.TransactionRef = result.Reference
+	refund.CompletedAt = &result.CompletedAt
+	return nil
+}
+
+// failRefund marks a refund transaction failed and returns cause
+func (s *CompetitionService) failRefund(ctx context.Context, refund *models.Transaction, cause error) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE public.transactions SET status = 'failed' WHERE id = $1`, refund.ID); err != nil {
+		return fmt.Errorf("%v (and failed to mark refund failed: %w)", cause, err)
+	}
+	refund.Status = "failed"
+	return cause
+}
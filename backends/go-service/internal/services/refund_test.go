@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/payments"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaveRefundPercent(t *testing.T) {
+	policy := models.RefundPolicy{BeforeStartPercent: 80, AfterStartPercent: 25}
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status string
+		now    time.Time
+		want   float64
+	}{
+		{"before the start", "upcoming", start.Add(-time.Hour), 80},
+		{"at the start, before the scheduler runs", "upcoming", start, 25},
+		{"after the start", "upcoming", start.Add(time.Hour), 25},
+		{"marked active", "active", start.Add(-time.Hour), 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := &models.Competition{Status: tt.status, StartDate: start}
+			assert.Equal(t, tt.want, leaveRefundPercent(policy, competitionStarted(comp, tt.now)))
+		})
+	}
+}
+
+func TestCreateEntryFeeRefunds(t *testing.T) {
+	tests := []struct {
+		name       string
+		fee        float64
+		percent    float64
+		wantAmount float64
+		wantStatus string
+	}{
+		{"full refund", 10, 100, 10, "pending"},
+		{"rounded to the cent", 9.99, 33, 3.30, "pending"},
+		{"half a cent rounds up", 0.05, 50, 0.03, "pending"},
+		{"no refund is recorded as completed", 10, 0, 0, "completed"},
+		{"a refund under half a cent is none", 0.01, 10, 0, "completed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t,
+				fakeQuery{match: "SELECT t.id, t.user_id, t.amount", rows: [][]driver.Value{{"charge-1", "user-1", tt.fee}}},
+				fakeQuery{match: "INSERT INTO public.transactions", affected: 1},
+			)
+			s := &CompetitionService{db: db, payments: payments.NewFakePaymentProvider()}
+			ctx := context.Background()
+
+			tx, err := db.BeginTx(ctx, nil)
+			require.NoError(t, err)
+			defer tx.Rollback()
+
+			refunds, err := s.createEntryFeeRefunds(ctx, tx, "comp-1", "user-1", tt.percent, "Refund")
+			require.NoError(t, err)
+			require.Len(t, refunds, 1)
+
+			refund := refunds[0]
+			assert.Equal(t, "refund", refund.Type)
+			assert.Equal(t, "user-1", refund.UserID)
+			assert.InDelta(t, tt.wantAmount, refund.Amount, 1e-9)
+			assert.Equal(t, tt.wantStatus, refund.Status)
+			assert.Equal(t, tt.wantStatus == "completed", refund.CompletedAt != nil)
+
+			// The refund points back at the entry fee it reverses
+			assert.Equal(t, "charge-1", fake.args[1][8])
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+)
+
+// CompetitionScheduler moves competitions through upcoming -> active ->
+// completed as their start and end dates pass. Each transition locks the
+// competition row and re-checks its status, so several instances can run the
+// scheduler at once and side effects still happen exactly once. It also
+// creates the next instance of recurring competition templates, settles
+// one-on-one challenges that have expired or ended, and assigns and closes
+// out skill divisions as competitions start and end, and finishes withdrawal
+// payouts whose outcome was never recorded.
+type CompetitionScheduler struct {
+	db            *sql.DB
+	leaderboard   *LeaderboardService
+	notifications *NotificationService
+	templates     *TemplateService
+	challenges    *ChallengeService
+	divisions     *DivisionService
+	withdrawals   *WithdrawalService
+	logger        *utils.Logger
+	interval      time.Duration
+}
+
+func NewCompetitionScheduler(db *sql.DB, leaderboard *LeaderboardService, notifications *NotificationService, templates *TemplateService, challenges *ChallengeService, divisions *DivisionService, withdrawals *WithdrawalService, logger *utils.Logger, interval time.Duration) *CompetitionScheduler {
+	return &CompetitionScheduler{
+		db:            db,
+		leaderboard:   leaderboard,
+		notifications: notifications,
+		templates:     templates,
+		challenges:    challenges,
+		divisions:     divisions,
+		withdrawals:   withdrawals,
+		logger:        logger,
+		interval:      interval,
+	}
+}
+
+// Run processes due transitions every interval until ctx is cancelled
+func (s *CompetitionScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			s.logger.Errorf("Competition scheduler tick failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick applies every transition that is due at the current time
+func (s *CompetitionScheduler) Tick(ctx context.Context) error {
+	now := time.Now()
+
+	if err := s.endFinishedGoalCompetitions(ctx, now); err != nil {
+		return err
+	}
+
+	// Competitions past their end date complete, even if they never started
+	dueQuery := `
+		SELECT id FROM public.competitions
+		WHERE status IN ('upcoming', 'active') AND end_date <= $1
+		ORDER BY end_date
+		LIMIT 100
+	`
+	completed, err := s.dueCompetitions(ctx, dueQuery, now)
+	if err != nil {
+		return err
+	}
+	for _, id := range completed {
+		if err := s.transition(ctx, id, now, "completed", s.onCompleted); err != nil {
+			s.logger.Errorf("Failed to complete competition %s: %v", id, err)
+		}
+	}
+
+	startQuery := `
+		SELECT id FROM public.competitions
+		WHERE status = 'upcoming' AND start_date <= $1 AND end_date > $1
+		ORDER BY start_date
+		LIMIT 100
+	`
+	started, err := s.dueCompetitions(ctx, startQuery, now)
+	if err != nil {
+		return err
+	}
+	for _, id := range started {
+		if err := s.transition(ctx, id, now, "active", s.onStarted); err != nil {
+			s.logger.Errorf("Failed to start competition %s: %v", id, err)
+		}
+	}
+
+	if err := s.createTemplateInstances(ctx, now); err != nil {
+		return err
+	}
+
+	if err := s.settleChallenges(ctx, now); err != nil {
+		return err
+	}
+
+	if s.withdrawals != nil {
+		return s.withdrawals.ReconcileWithdrawals(ctx, now)
+	}
+	return nil
+}
+
+// settleChallenges expires unanswered challenges and resolves finished ones
+func (s *CompetitionScheduler) settleChallenges(ctx context.Context, now time.Time) error {
+	if s.challenges == nil {
+		return nil
+	}
+
+	ids, err := s.challenges.DueChallengeIDs(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.challenges.SettleChallenge(ctx, id, now); err != nil {
+			s.logger.Errorf("Failed to settle challenge %s: %v", id, err)
+		}
+	}
+
+	return nil
+}
+
+// createTemplateInstances finishes setting up earlier instances whose
+// subscribers weren't all enrolled, then creates any recurring competition
+// instances that are due
+func (s *CompetitionScheduler) createTemplateInstances(ctx context.Context, now time.Time) error {
+	if s.templates == nil {
+		return nil
+	}
+
+	if err := s.templates.FinishInstances(ctx); err != nil {
+		s.logger.Errorf("Failed to finish template instances: %v", err)
+	}
+
+	ids, err := s.templates.ActiveTemplateIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		comp, err := s.templates.CreateNextInstance(ctx, id, now)
+		if err != nil {
+			s.logger.Errorf("Failed to create instance of template %s: %v", id, err)
+			continue
+		}
+		if comp != nil {
+			s.logger.Infof("Created competition %s from template %s", comp.ID, id)
+		}
+	}
+
+	return nil
+}
+
+// endFinishedGoalCompetitions brings forward the end date of goal-mode
+// competitions that have reached their finisher limit, so the completion
+// below picks them up like any other competition that has ended
+func (s *CompetitionScheduler) endFinishedGoalCompetitions(ctx context.Context, now time.Time) error {
+	query := `
+		SELECT id, goal_end_after_finishers FROM public.competitions
+		WHERE status = 'active' AND mode = 'goal' AND goal_end_after_finishers IS NOT NULL AND end_date > $1
+	`
+	rows, err := s.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return fmt.Errorf("failed to query goal competitions: %w", err)
+	}
+
+	limits := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var limit int64
+		if err := rows.Scan(&id, &limit); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan goal competition: %w", err)
+		}
+		limits[id] = limit
+	}
+	rows.Close()
+
+	for id, limit := range limits {
+		finishers, err := s.leaderboard.FinisherCount(ctx, id)
+		if err != nil {
+			s.logger.Errorf("Failed to count finishers for competition %s: %v", id, err)
+			continue
+		}
+		if finishers < limit {
+			continue
+		}
+
+		endQuery := `UPDATE public.competitions SET end_date = $1 WHERE id = $2 AND status = 'active' AND end_date > $1`
+		if _, err := s.db.ExecContext(ctx, endQuery, now, id); err != nil {
+			s.logger.Errorf("Failed to end goal competition %s early: %v", id, err)
+			continue
+		}
+		s.logger.Infof("Competition %s reached %d finishers and ends early", id, finishers)
+	}
+
+	return nil
+}
+
+func (s *CompetitionScheduler) dueCompetitions(ctx context.Context, query string, now time.Time) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due competitions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan competition id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// transition locks a single competition, re-checks that the transition is
+// still due and applies it together with its side effects. Rows locked by
+// another instance are skipped.
+func (s *CompetitionScheduler) transition(ctx context.Context, competitionID string, now time.Time, status string, sideEffects func(ctx context.Context, tx *sql.Tx, comp *models.Competition) error) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `SELECT ` + competitionColumns + `
+			FROM public.competitions c
+			WHERE c.id = $1
+			FOR UPDATE SKIP LOCKED
+		`
+
+		var comp models.Competition
+		err := scanCompetition(tx.QueryRowContext(ctx, query, competitionID), &comp)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock competition: %w", err)
+		}
+
+		if nextCompetitionStatus(&comp, now) != status || comp.Status == status {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE public.competitions SET status = $1 WHERE id = $2`, status, comp.ID); err != nil {
+			return fmt.Errorf("failed to update competition status: %w", err)
+		}
+
+		if err := sideEffects(ctx, tx, &comp); err != nil {
+			return err
+		}
+
+		s.logger.Infof("Competition %s moved from %s to %s", comp.ID, comp.Status, status)
+		return nil
+	})
+}
+
+func (s *CompetitionScheduler) onStarted(ctx context.Context, tx *sql.Tx, comp *models.Competition) error {
+	if s.divisions != nil {
+		if err := s.divisions.AssignDivisions(ctx, tx, comp); err != nil {
+			return err
+		}
+	}
+
+	return s.notifications.NotifyParticipants(ctx, tx, comp.ID, "competition_started",
+		"Competition started",
+		fmt.Sprintf("%s has started. Good luck!", comp.Name),
+	)
+}
+
+// onCompleted freezes the leaderboard, judges prize eligibility, snapshots
+// the final standings and pays out prizes before notifying participants
+func (s *CompetitionScheduler) onCompleted(ctx context.Context, tx *sql.Tx, comp *models.Competition) error {
+	if err := s.leaderboard.FreezeLeaderboard(ctx, comp.ID); err != nil {
+		return fmt.Errorf("failed to freeze leaderboard: %w", err)
+	}
+
+	ineligible, err := evaluateEligibility(ctx, tx, comp)
+	if err != nil {
+		return err
+	}
+	if err := s.leaderboard.SetIneligibility(ctx, comp.ID, ineligible); err != nil {
+		return fmt.Errorf("failed to record prize eligibility: %w", err)
+	}
+
+	leaderboard, err := s.leaderboard.GetLeaderboard(ctx, comp.ID, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get final leaderboard: %w", err)
+	}
+
+	snapshotQuery := `
+		INSERT INTO public.leaderboard_entries (user_id, user_name, competition_id, score, rank, steps, distance, calories, last_synced_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, competition_id) DO UPDATE SET
+			score = EXCLUDED.score, rank = EXCLUDED.rank, steps = EXCLUDED.steps,
+			distance = EXCLUDED.distance, calories = EXCLUDED.calories, last_synced_at = EXCLUDED.last_synced_at
+	`
+	for _, e := range leaderboard.Entries {
+		if _, err := tx.ExecContext(ctx, snapshotQuery,
+			e.UserID, e.UserName, comp.ID, e.Score, e.Rank, e.Steps, e.Distance, e.Calories, e.LastSyncedAt,
+		); err != nil {
+			return fmt.Errorf("failed to save leaderboard entry: %w", err)
+		}
+	}
+
+	var prizes []models.Prize
+	switch {
+	case comp.Divisions != nil && s.divisions != nil:
+		// Each division is ranked and rewarded on its own
+		prizes, err = s.divisions.CloseSeason(ctx, tx, comp)
+		if err != nil {
+			return fmt.Errorf("failed to close division season: %w", err)
+		}
+	case comp.PrizePool > 0 && len(leaderboard.Entries) > 0:
+		prizes, err = s.leaderboard.CalculatePrizes(ctx, comp.ID, comp.PrizePool)
+		if err != nil {
+			return fmt.Errorf("failed to calculate prizes: %w", err)
+		}
+	}
+
+	if err := awardPrizes(ctx, tx, comp, prizes); err != nil {
+		return err
+	}
+
+	return s.notifications.NotifyParticipants(ctx, tx, comp.ID, "competition_completed",
+		"Competition ended",
+		fmt.Sprintf("%s has ended. Check the final leaderboard to see where you placed.", comp.Name),
+	)
+}
+
+// awardPrizes records each prize as distributed and credits it to the
+// winner's ledger balance, where it can be withdrawn. A prize that was
+// already recorded is skipped, so it is never credited twice.
+func awardPrizes(ctx context.Context, tx dbExecutor, comp *models.Competition, prizes []models.Prize) error {
+	prizeQuery := `
+		INSERT INTO public.prizes (id, competition_id, user_id, rank, division, amount, status, distributed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'distributed', $7, $7)
+		ON CONFLICT (competition_id, division, rank) DO NOTHING
+		RETURNING id
+	`
+	now := time.Now()
+	for _, p := range prizes {
+		amount := math.Round(p.Amount*100) / 100
+		if amount <= 0 {
+			continue
+		}
+
+		var prizeID string
+		err := tx.QueryRowContext(ctx, prizeQuery,
+			uuid.New().String(), comp.ID, p.UserID, p.Rank, p.Division, amount, now,
+		).Scan(&prizeID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save prize: %w", err)
+		}
+
+		description := fmt.Sprintf("%s prize in %s", ordinal(p.Rank), comp.Name)
+		if p.Division > 0 {
+			description = fmt.Sprintf("%s prize in division %d of %s", ordinal(p.Rank), p.Division, comp.Name)
+		}
+		if err := recordLedgerEntry(ctx, tx, p.UserID, amount, LedgerTypePrize, prizeID, description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextCompetitionStatus returns the status a competition should have at now
+func nextCompetitionStatus(comp *models.Competition, now time.Time) string {
+	switch {
+	case !now.Before(comp.EndDate):
+		return "completed"
+	case !now.Before(comp.StartDate):
+		return "active"
+	default:
+		return "upcoming"
+	}
+}
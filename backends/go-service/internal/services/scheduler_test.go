@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextCompetitionStatus(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(7 * 24 * time.Hour)
+	comp := &models.Competition{StartDate: start, EndDate: end}
+
+	assert.Equal(t, "upcoming", nextCompetitionStatus(comp, start.Add(-time.Second)))
+	assert.Equal(t, "active", nextCompetitionStatus(comp, start))
+	assert.Equal(t, "active", nextCompetitionStatus(comp, end.Add(-time.Second)))
+	assert.Equal(t, "completed", nextCompetitionStatus(comp, end))
+	assert.Equal(t, "completed", nextCompetitionStatus(comp, end.Add(time.Hour)))
+}
+
+func TestAwardPrizes(t *testing.T) {
+	comp := &models.Competition{ID: "11111111-1111-1111-1111-111111111111", Name: "June Steps"}
+	prizes := []models.Prize{
+		{UserID: "winner", Rank: 1, Amount: 60.004},
+		{UserID: "runner-up", Rank: 2, Amount: 30},
+		{UserID: "third", Rank: 3, Division: 2, Amount: 10},
+	}
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "INSERT INTO public.prizes", rows: [][]driver.Value{{"prize-1"}}},
+		fakeQuery{match: "INSERT INTO public.ledger_entries"},
+		// The second prize was recorded by an earlier attempt
+		fakeQuery{match: "INSERT INTO public.prizes"},
+		fakeQuery{match: "INSERT INTO public.prizes", rows: [][]driver.Value{{"prize-3"}}},
+		fakeQuery{match: "INSERT INTO public.ledger_entries"},
+	)
+
+	require.NoError(t, awardPrizes(context.Background(), db, comp, prizes))
+
+	credits := fake.args[1]
+	assert.Equal(t, "winner", credits[1])
+	assert.Equal(t, 60.0, credits[2])
+	assert.Equal(t, LedgerTypePrize, credits[3])
+	assert.Equal(t, "prize-1", credits[4])
+	assert.Equal(t, "1st prize in June Steps", credits[5])
+
+	credits = fake.args[4]
+	assert.Equal(t, "third", credits[1])
+	assert.Equal(t, "3rd prize in division 2 of June Steps", credits[5])
+}
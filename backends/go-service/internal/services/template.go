@@ -0,0 +1,645 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("competition template not found")
+	ErrInvalidTemplate  = errors.New("invalid competition template")
+)
+
+// defaultCreateAheadHours is how long before an instance ends the next one
+// is created when a template does not say otherwise
+const defaultCreateAheadHours = 24
+
+const templateColumns = `
+	t.id, t.name, t.description, t.entry_fee, t.prize_pool, t.visibility, t.max_participants,
+	t.recurrence_frequency, t.recurrence_interval, t.first_start_date, t.create_ahead_hours,
+	t.creator_id, t.active, t.created_at,
+	t.mode, t.goal_metric, t.goal_target, t.goal_end_after_finishers,
+	t.division_count, t.division_promote, t.division_relegate,
+	t.time_zone,
+	t.eligible_sources, t.eligible_countries, t.min_active_days
+`
+
+// TemplateService manages recurring competition templates and creates their
+// instances. Instances are regular competitions linked back to the template.
+type TemplateService struct {
+	db            *sql.DB
+	competitions  *CompetitionService
+	notifications *NotificationService
+	logger        *utils.Logger
+}
+
+func NewTemplateService(db *sql.DB, competitions *CompetitionService, notifications *NotificationService, logger *utils.Logger) *TemplateService {
+	return &TemplateService{
+		db:            db,
+		competitions:  competitions,
+		notifications: notifications,
+		logger:        logger,
+	}
+}
+
+// CreateTemplate stores a new template and creates its first instance. The
+// template is returned even if the instance fails; the scheduler creates it
+// on a later tick.
+func (s *TemplateService) CreateTemplate(ctx context.Context, req *models.CreateTemplateRequest) (*models.CompetitionTemplate, error) {
+	tpl := &models.CompetitionTemplate{
+		ID:               uuid.New().String(),
+		Name:             req.Name,
+		Description:      req.Description,
+		EntryFee:         req.EntryFee,
+		PrizePool:        req.PrizePool,
+		Visibility:       req.Visibility,
+		MaxParticipants:  req.MaxParticipants,
+		Recurrence:       req.Recurrence,
+		FirstStartDate:   req.FirstStartDate,
+		CreateAheadHours: req.CreateAheadHours,
+		CreatorID:        req.CreatorID,
+		Active:           true,
+		CreatedAt:        time.Now(),
+		Mode:             req.Mode,
+		Goal:             req.Goal,
+		Divisions:        req.Divisions,
+		TimeZone:         req.TimeZone,
+		Eligibility:      req.Eligibility,
+	}
+	if tpl.Visibility == "" {
+		tpl.Visibility = models.VisibilityPublic
+	}
+	if tpl.Recurrence.Interval == 0 {
+		tpl.Recurrence.Interval = 1
+	}
+	if tpl.CreateAheadHours == 0 {
+		tpl.CreateAheadHours = defaultCreateAheadHours
+	}
+	if tpl.Mode == "" {
+		tpl.Mode = models.ModeScore
+	}
+	if tpl.TimeZone == "" {
+		tpl.TimeZone = defaultTimeZone
+	}
+
+	if err := validateTemplate(tpl); err != nil {
+		return nil, err
+	}
+	loc, _ := loadTimeZone(tpl.TimeZone)
+	tpl.FirstStartDate = wallClockIn(tpl.FirstStartDate, loc)
+
+	query := `
+		INSERT INTO public.competition_templates (
+			id, name, description, entry_fee, prize_pool, visibility, max_participants,
+			recurrence_frequency, recurrence_interval, first_start_date, create_ahead_hours,
+			creator_id, active, created_at,
+			mode, goal_metric, goal_target, goal_end_after_finishers,
+			division_count, division_promote, division_relegate, time_zone,
+			eligible_sources, eligible_countries, min_active_days
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
+	`
+	metric, target, endAfterFinishers := goalArgs(tpl.Goal)
+	divisionCount, promote, relegate := divisionArgs(tpl.Divisions)
+	sources, countries, minActiveDays := eligibilityArgs(tpl.Eligibility)
+	if _, err := s.db.ExecContext(ctx, query,
+		tpl.ID, tpl.Name, tpl.Description, tpl.EntryFee, tpl.PrizePool, tpl.Visibility, tpl.MaxParticipants,
+		tpl.Recurrence.Frequency, tpl.Recurrence.Interval, tpl.FirstStartDate, tpl.CreateAheadHours,
+		tpl.CreatorID, tpl.Active, tpl.CreatedAt,
+		tpl.Mode, metric, target, endAfterFinishers,
+		divisionCount, promote, relegate, tpl.TimeZone,
+		sources, countries, minActiveDays,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	// Create the first instance now rather than on the next scheduler tick.
+	// The template is already stored, so an error would only make the client
+	// retry and create it twice.
+	if _, err := s.CreateNextInstance(ctx, tpl.ID, time.Now()); err != nil {
+		s.logger.Warnf("Failed to create the first instance of template %s: %v", tpl.ID, err)
+	}
+
+	return tpl, nil
+}
+
+// GetTemplates lists active public templates plus every template the viewer
+// created, marking the ones the viewer is subscribed to
+func (s *TemplateService) GetTemplates(ctx context.Context, viewerID string) ([]models.CompetitionTemplate, error) {
+	query := `SELECT ` + templateColumns + `,
+			EXISTS(SELECT 1 FROM public.competition_template_subscriptions ts WHERE ts.template_id = t.id AND ts.user_id::text = $1)
+		FROM public.competition_templates t
+		WHERE (t.active AND t.visibility = 'public') OR t.creator_id::text = $1
+		ORDER BY t.created_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.CompetitionTemplate{}
+	for rows.Next() {
+		var tpl models.CompetitionTemplate
+		if err := scanTemplate(rows, &tpl, &tpl.Subscribed); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, tpl)
+	}
+
+	return templates, nil
+}
+
+// GetTemplate retrieves a single template. Private templates are only
+// visible to their creator and subscribers.
+func (s *TemplateService) GetTemplate(ctx context.Context, id, viewerID string) (*models.CompetitionTemplate, error) {
+	query := `SELECT ` + templateColumns + `,
+			EXISTS(SELECT 1 FROM public.competition_template_subscriptions ts WHERE ts.template_id = t.id AND ts.user_id::text = $2)
+		FROM public.competition_templates t
+		WHERE t.id = $1
+	`
+
+	var tpl models.CompetitionTemplate
+	err := scanTemplate(s.db.QueryRowContext(ctx, query, id, viewerID), &tpl, &tpl.Subscribed)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	if tpl.Visibility == models.VisibilityPrivate && tpl.CreatorID != viewerID && !tpl.Subscribed {
+		return nil, ErrTemplateNotFound
+	}
+
+	return &tpl, nil
+}
+
+// UpdateTemplate applies a partial update on behalf of the creator or an
+// admin. Existing instances keep their settings.
+func (s *TemplateService) UpdateTemplate(ctx context.Context, id, actorID string, isAdmin bool, req *models.UpdateTemplateRequest) (*models.CompetitionTemplate, error) {
+	var tpl models.CompetitionTemplate
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `SELECT ` + templateColumns + ` FROM public.competition_templates t WHERE t.id = $1 FOR UPDATE`
+		err := scanTemplate(tx.QueryRowContext(ctx, query, id), &tpl)
+		if err == sql.ErrNoRows {
+			return ErrTemplateNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get template: %w", err)
+		}
+
+		if tpl.CreatorID != actorID && !isAdmin {
+			return ErrNotCompetitionOwner
+		}
+
+		applyTemplateUpdate(&tpl, req)
+		if err := validateTemplate(&tpl); err != nil {
+			return err
+		}
+
+		updateQuery := `
+			UPDATE public.competition_templates
+			SET name = $1, description = $2, entry_fee = $3, prize_pool = $4, visibility = $5,
+				max_participants = $6, create_ahead_hours = $7, active = $8
+			WHERE id = $9
+		`
+		if _, err := tx.ExecContext(ctx, updateQuery,
+			tpl.Name, tpl.Description, tpl.EntryFee, tpl.PrizePool, tpl.Visibility,
+			tpl.MaxParticipants, tpl.CreateAheadHours, tpl.Active, tpl.ID,
+		); err != nil {
+			return fmt.Errorf("failed to update template: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &tpl, nil
+}
+
+// Subscribe opts a user in to being enrolled in every future instance of a
+// template. Private templates can only be subscribed to by their creator and
+// by participants of one of their instances.
+func (s *TemplateService) Subscribe(ctx context.Context, templateID, userID string) error {
+	var visibility, creatorID string
+	var participant bool
+	query := `
+		SELECT t.visibility, t.creator_id::text, EXISTS(
+			SELECT 1 FROM public.competition_participants cp
+			JOIN public.competitions c ON c.id = cp.competition_id
+			WHERE c.template_id = t.id AND cp.user_id::text = $2
+		)
+		FROM public.competition_templates t
+		WHERE t.id = $1 AND t.active
+	`
+	err := s.db.QueryRowContext(ctx, query, templateID, userID).Scan(&visibility, &creatorID, &participant)
+	if err == sql.ErrNoRows {
+		return ErrTemplateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+
+	if visibility == models.VisibilityPrivate && creatorID != userID && !participant {
+		return ErrTemplateNotFound
+	}
+
+	insertQuery := `
+		INSERT INTO public.competition_template_subscriptions (template_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (template_id, user_id) DO NOTHING
+	`
+	if _, err := s.db.ExecContext(ctx, insertQuery, templateID, userID); err != nil {
+		return fmt.Errorf("failed to subscribe to template: %w", err)
+	}
+
+	return nil
+}
+
+// Unsubscribe stops enrolling a user in future instances of a template
+func (s *TemplateService) Unsubscribe(ctx context.Context, templateID, userID string) error {
+	query := `DELETE FROM public.competition_template_subscriptions WHERE template_id = $1 AND user_id = $2`
+	if _, err := s.db.ExecContext(ctx, query, templateID, userID); err != nil {
+		return fmt.Errorf("failed to unsubscribe from template: %w", err)
+	}
+	return nil
+}
+
+// ActiveTemplateIDs returns the templates the scheduler should check for a
+// due instance
+func (s *TemplateService) ActiveTemplateIDs(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM public.competition_templates WHERE active ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan template id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// CreateNextInstance creates the template's next instance if it is due at
+// now and enrols subscribers in it. It returns nil when nothing was due or
+// another scheduler instance holds the template. Instances are unique per
+// template and start date, so running this concurrently never duplicates one.
+// If setting up the instance fails after it is created, FinishInstances
+// retries the setup.
+func (s *TemplateService) CreateNextInstance(ctx context.Context, templateID string, now time.Time) (*models.Competition, error) {
+	var comp *models.Competition
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `SELECT ` + templateColumns + `
+			FROM public.competition_templates t
+			WHERE t.id = $1 AND t.active
+			FOR UPDATE SKIP LOCKED
+		`
+		var tpl models.CompetitionTemplate
+		err := scanTemplate(tx.QueryRowContext(ctx, query, templateID), &tpl)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock template: %w", err)
+		}
+
+		var latest sql.NullTime
+		latestQuery := `SELECT MAX(start_date) FROM public.competitions WHERE template_id = $1`
+		if err := tx.QueryRowContext(ctx, latestQuery, templateID).Scan(&latest); err != nil {
+			return fmt.Errorf("failed to get latest instance: %w", err)
+		}
+
+		var latestStart *time.Time
+		if latest.Valid {
+			latestStart = &latest.Time
+		}
+		start, due := nextInstanceStart(&tpl, latestStart, now)
+		if !due {
+			return nil
+		}
+
+		comp = newTemplateInstance(&tpl, start, now)
+		err = insertCompetition(ctx, tx, comp)
+		if errors.Is(err, ErrDuplicateInstance) {
+			comp = nil
+			return nil
+		}
+		return err
+	})
+	if err != nil || comp == nil {
+		return nil, err
+	}
+
+	return comp, s.finishInstance(ctx, comp)
+}
+
+// FinishInstances retries the setup of instances whose subscribers were
+// never all enrolled, for example because the server stopped or enrolment
+// failed right after the instance was created. Enrolling is idempotent, so
+// subscribers who already joined are skipped.
+func (s *TemplateService) FinishInstances(ctx context.Context) error {
+	query := `SELECT ` + competitionColumns + `
+		FROM public.competitions c
+		WHERE c.template_id IS NOT NULL AND c.enrolled_at IS NULL AND c.status IN ('upcoming', 'active')
+		ORDER BY c.created_at
+		LIMIT 100
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query unfinished instances: %w", err)
+	}
+	var instances []*models.Competition
+	for rows.Next() {
+		var comp models.Competition
+		if err := scanCompetition(rows, &comp); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan instance: %w", err)
+		}
+		instances = append(instances, &comp)
+	}
+	rows.Close()
+
+	for _, comp := range instances {
+		if err := s.finishInstance(ctx, comp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishInstance configures a new instance's leaderboard, enrols its
+// subscribers and then marks it enrolled
+func (s *TemplateService) finishInstance(ctx context.Context, comp *models.Competition) error {
+	if err := s.competitions.leaderboard.SetGoal(ctx, comp.ID, comp.Goal); err != nil {
+		return fmt.Errorf("failed to configure leaderboard: %w", err)
+	}
+	if err := cacheCompetitionTimeZone(ctx, s.competitions.cache, comp.ID, comp.TimeZone); err != nil {
+		return fmt.Errorf("failed to cache time zone: %w", err)
+	}
+
+	if err := s.enrolSubscribers(ctx, comp); err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE public.competitions SET enrolled_at = NOW() WHERE id = $1`, comp.ID); err != nil {
+		return fmt.Errorf("failed to mark instance enrolled: %w", err)
+	}
+	return nil
+}
+
+// enrolSubscribers joins every subscriber to a new instance. A failed
+// enrolment, for example a declined entry fee, is reported to the user
+// rather than failing the instance.
+func (s *TemplateService) enrolSubscribers(ctx context.Context, comp *models.Competition) error {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_id FROM public.competition_template_subscriptions WHERE template_id = $1 ORDER BY created_at`,
+		comp.TemplateID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query subscribers: %w", err)
+	}
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan subscriber: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+
+	for _, userID := range userIDs {
+		n := &models.Notification{UserID: userID, CompetitionID: comp.ID}
+
+		result, err := s.competitions.autoEnrol(ctx, comp.ID, userID)
+		switch {
+		case errors.Is(err, ErrAlreadyJoined):
+			continue
+		case err != nil:
+			n.Type = "auto_enrol_failed"
+			n.Title = "Couldn't enter you automatically"
+			n.Message = fmt.Sprintf("We couldn't enter you into %s: %v", comp.Name, err)
+		case result.Status == "waitlisted":
+			n.Type = "auto_enrolled"
+			n.Title = "You're on the waitlist"
+			n.Message = fmt.Sprintf("%s is full, so you're number %d on the waitlist.", comp.Name, result.WaitlistPosition)
+		default:
+			n.Type = "auto_enrolled"
+			n.Title = "You're in!"
+			n.Message = fmt.Sprintf("You've been entered into %s.", comp.Name)
+		}
+
+		if err := s.notifications.NotifyUser(ctx, s.db, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newTemplateInstance builds the competition for the instance of tpl that
+// starts at start, carrying over the template's settings
+func newTemplateInstance(tpl *models.CompetitionTemplate, start, now time.Time) *models.Competition {
+	comp := &models.Competition{
+		ID:          uuid.New().String(),
+		Name:        fmt.Sprintf("%s (%s)", tpl.Name, start.Format("Jan 2, 2006")),
+		Description: tpl.Description,
+		EntryFee:    tpl.EntryFee,
+		PrizePool:   tpl.PrizePool,
+		StartDate:   start,
+		EndDate:     advanceRecurrence(tpl.Recurrence, start, 1),
+		Type:        tpl.Recurrence.Frequency,
+		CreatorID:   tpl.CreatorID,
+		CreatedAt:   now,
+
+		Visibility:      tpl.Visibility,
+		MaxParticipants: tpl.MaxParticipants,
+		TemplateID:      tpl.ID,
+
+		Mode: tpl.Mode,
+		Goal: tpl.Goal,
+
+		Divisions: tpl.Divisions,
+
+		TimeZone: tpl.TimeZone,
+
+		Eligibility: tpl.Eligibility,
+	}
+	comp.Status = nextCompetitionStatus(comp, now)
+	return comp
+}
+
+// nextInstanceStart returns the start of the instance to create after the
+// one starting at latest (or the first instance when latest is nil) and
+// whether it is due at now. The next instance is due once the latest one is
+// within CreateAheadHours of ending. Periods that ended while the scheduler
+// was not running are skipped rather than created retroactively.
+func nextInstanceStart(tpl *models.CompetitionTemplate, latest *time.Time, now time.Time) (time.Time, bool) {
+	// Occurrences are computed from the first start date rather than the
+	// previous instance so monthly templates don't drift after short months
+	k := 0
+	if latest != nil {
+		for !occurrence(tpl, k).After(*latest) {
+			k++
+		}
+	}
+	for !advanceRecurrence(tpl.Recurrence, occurrence(tpl, k), 1).After(now) {
+		k++
+	}
+
+	start := occurrence(tpl, k)
+	if latest == nil {
+		return start, true
+	}
+
+	createAt := start.Add(-time.Duration(tpl.CreateAheadHours) * time.Hour)
+	return start, !now.Before(createAt)
+}
+
+// occurrence returns the start of the k-th instance of tpl
+func occurrence(tpl *models.CompetitionTemplate, k int) time.Time {
+	return advanceRecurrence(tpl.Recurrence, tpl.FirstStartDate, k)
+}
+
+// advanceRecurrence moves t forward by n periods of rule
+func advanceRecurrence(rule models.RecurrenceRule, t time.Time, n int) time.Time {
+	steps := rule.Interval * n
+	switch rule.Frequency {
+	case "daily":
+		return t.AddDate(0, 0, steps)
+	case "weekly":
+		return t.AddDate(0, 0, 7*steps)
+	default:
+		return t.AddDate(0, steps, 0)
+	}
+}
+
+// validateTemplate checks a template's settings
+func validateTemplate(tpl *models.CompetitionTemplate) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidTemplate, fmt.Sprintf(format, args...))
+	}
+
+	if tpl.Name == "" {
+		return invalid("name is required")
+	}
+	switch tpl.Recurrence.Frequency {
+	case "daily", "weekly", "monthly":
+	default:
+		return invalid("recurrence frequency must be daily, weekly or monthly")
+	}
+	if tpl.Recurrence.Interval < 1 {
+		return invalid("recurrence interval must be at least 1")
+	}
+	if tpl.FirstStartDate.IsZero() {
+		return invalid("first start date is required")
+	}
+	if tpl.EntryFee < 0 || tpl.PrizePool < 0 {
+		return invalid("entry fee and prize pool cannot be negative")
+	}
+	if !validVisibility(tpl.Visibility) {
+		return invalid("visibility must be one of public, unlisted or private")
+	}
+	if tpl.MaxParticipants != nil && *tpl.MaxParticipants <= 0 {
+		return invalid("max participants must be positive")
+	}
+	if err := validateGoal(tpl.Mode, tpl.Goal); err != nil {
+		return invalid("%v", err)
+	}
+	if err := validateDivisions(tpl.Divisions); err != nil {
+		return invalid("%v", err)
+	}
+	if _, err := loadTimeZone(tpl.TimeZone); err != nil {
+		return invalid("%v", err)
+	}
+	if err := validateEligibility(tpl.Eligibility); err != nil {
+		return invalid("%v", err)
+	}
+
+	period := advanceRecurrence(tpl.Recurrence, tpl.FirstStartDate, 1).Sub(tpl.FirstStartDate)
+	ahead := time.Duration(tpl.CreateAheadHours) * time.Hour
+	if tpl.CreateAheadHours < 1 || ahead >= period {
+		return invalid("create ahead hours must be at least 1 and shorter than one period")
+	}
+
+	return nil
+}
+
+// applyTemplateUpdate copies the set fields of req onto tpl
+func applyTemplateUpdate(tpl *models.CompetitionTemplate, req *models.UpdateTemplateRequest) {
+	if req.Name != nil {
+		tpl.Name = *req.Name
+	}
+	if req.Description != nil {
+		tpl.Description = *req.Description
+	}
+	if req.EntryFee != nil {
+		tpl.EntryFee = *req.EntryFee
+	}
+	if req.PrizePool != nil {
+		tpl.PrizePool = *req.PrizePool
+	}
+	if req.Visibility != nil {
+		tpl.Visibility = *req.Visibility
+	}
+	if req.MaxParticipants != nil {
+		if *req.MaxParticipants == 0 {
+			tpl.MaxParticipants = nil
+		} else {
+			limit := *req.MaxParticipants
+			tpl.MaxParticipants = &limit
+		}
+	}
+	if req.CreateAheadHours != nil {
+		tpl.CreateAheadHours = *req.CreateAheadHours
+	}
+	if req.Active != nil {
+		tpl.Active = *req.Active
+	}
+}
+
+// scanTemplate scans templateColumns followed by any extra columns
+func scanTemplate(row rowScanner, tpl *models.CompetitionTemplate, extra ...interface{}) error {
+	var goal goalColumns
+	var divisions divisionColumns
+	var eligibility eligibilityColumns
+	dest := []interface{}{
+		&tpl.ID, &tpl.Name, &tpl.Description, &tpl.EntryFee, &tpl.PrizePool, &tpl.Visibility, &tpl.MaxParticipants,
+		&tpl.Recurrence.Frequency, &tpl.Recurrence.Interval, &tpl.FirstStartDate, &tpl.CreateAheadHours,
+		&tpl.CreatorID, &tpl.Active, &tpl.CreatedAt,
+		&tpl.Mode, &goal.metric, &goal.target, &goal.endAfterFinishers,
+		&divisions.count, &divisions.promote, &divisions.relegate,
+		&tpl.TimeZone,
+		&eligibility.sources, &eligibility.countries, &eligibility.minActiveDays,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	tpl.Goal = goal.goal()
+	tpl.Divisions = divisions.divisions()
+	tpl.Eligibility = eligibility.rules()
+	// Recurrences step in local time so instances keep their wall clock
+	// start across daylight saving changes
+	if loc, err := loadTimeZone(tpl.TimeZone); err == nil {
+		tpl.FirstStartDate = tpl.FirstStartDate.In(loc)
+	}
+	return nil
+}
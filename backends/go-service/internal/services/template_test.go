@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvanceRecurrence(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, start.AddDate(0, 0, 2), advanceRecurrence(models.RecurrenceRule{Frequency: "daily", Interval: 1}, start, 2))
+	assert.Equal(t, start.AddDate(0, 0, 28), advanceRecurrence(models.RecurrenceRule{Frequency: "weekly", Interval: 2}, start, 2))
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), advanceRecurrence(models.RecurrenceRule{Frequency: "monthly", Interval: 1}, start, 2))
+}
+
+func TestNextInstanceStart(t *testing.T) {
+	first := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC) // a Monday
+	week := 7 * 24 * time.Hour
+	tpl := &models.CompetitionTemplate{
+		Recurrence:       models.RecurrenceRule{Frequency: "weekly", Interval: 1},
+		FirstStartDate:   first,
+		CreateAheadHours: 24,
+	}
+
+	t.Run("first instance is created immediately", func(t *testing.T) {
+		start, due := nextInstanceStart(tpl, nil, first.Add(-30*24*time.Hour))
+		assert.True(t, due)
+		assert.Equal(t, first, start)
+	})
+
+	t.Run("first instance skips periods that already ended", func(t *testing.T) {
+		start, due := nextInstanceStart(tpl, nil, first.Add(2*week+time.Hour))
+		assert.True(t, due)
+		assert.Equal(t, first.Add(2*week), start)
+	})
+
+	t.Run("next instance waits until shortly before the current one ends", func(t *testing.T) {
+		_, due := nextInstanceStart(tpl, &first, first.Add(week-25*time.Hour))
+		assert.False(t, due)
+
+		start, due := nextInstanceStart(tpl, &first, first.Add(week-24*time.Hour))
+		assert.True(t, due)
+		assert.Equal(t, first.Add(week), start)
+	})
+
+	t.Run("missed periods are not created retroactively", func(t *testing.T) {
+		start, due := nextInstanceStart(tpl, &first, first.Add(3*week+time.Hour))
+		assert.True(t, due)
+		assert.Equal(t, first.Add(3*week), start)
+	})
+
+	t.Run("monthly occurrences do not drift after short months", func(t *testing.T) {
+		monthly := &models.CompetitionTemplate{
+			Recurrence:       models.RecurrenceRule{Frequency: "monthly", Interval: 1},
+			FirstStartDate:   time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC),
+			CreateAheadHours: 24,
+		}
+		latest := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC) // Feb 30 normalised
+		start, _ := nextInstanceStart(monthly, &latest, latest)
+		assert.Equal(t, time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC), start)
+	})
+}
+
+func TestNewTemplateInstance(t *testing.T) {
+	limit := 50
+	tpl := &models.CompetitionTemplate{
+		ID:              "tpl-1",
+		Name:            "Weekly Steps",
+		Description:     "Most steps wins",
+		EntryFee:        5,
+		PrizePool:       100,
+		Visibility:      models.VisibilityUnlisted,
+		MaxParticipants: &limit,
+		Recurrence:      models.RecurrenceRule{Frequency: "weekly", Interval: 1},
+		CreatorID:       "user-1",
+	}
+	start := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	comp := newTemplateInstance(tpl, start, start.Add(-24*time.Hour))
+
+	assert.Equal(t, "Weekly Steps (Jun 3, 2024)", comp.Name)
+	assert.Equal(t, start.AddDate(0, 0, 7), comp.EndDate)
+	assert.Equal(t, "weekly", comp.Type)
+	assert.Equal(t, "upcoming", comp.Status)
+	assert.Equal(t, tpl.ID, comp.TemplateID)
+	assert.Equal(t, tpl.EntryFee, comp.EntryFee)
+	assert.Equal(t, tpl.PrizePool, comp.PrizePool)
+	assert.Equal(t, tpl.Visibility, comp.Visibility)
+	assert.Equal(t, 50, *comp.MaxParticipants)
+}
+
+func TestValidateTemplate(t *testing.T) {
+	valid := func() *models.CompetitionTemplate {
+		return &models.CompetitionTemplate{
+			Name:             "Weekly Steps",
+			Visibility:       models.VisibilityPublic,
+			Mode:             models.ModeScore,
+			Recurrence:       models.RecurrenceRule{Frequency: "weekly", Interval: 1},
+			FirstStartDate:   time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+			CreateAheadHours: 24,
+		}
+	}
+
+	assert.NoError(t, validateTemplate(valid()))
+
+	tpl := valid()
+	tpl.Recurrence.Frequency = "yearly"
+	assert.ErrorIs(t, validateTemplate(tpl), ErrInvalidTemplate)
+
+	tpl = valid()
+	tpl.Recurrence.Frequency = "daily"
+	assert.ErrorIs(t, validateTemplate(tpl), ErrInvalidTemplate, "create ahead must be shorter than a day")
+
+	tpl = valid()
+	tpl.FirstStartDate = time.Time{}
+	assert.ErrorIs(t, validateTemplate(tpl), ErrInvalidTemplate)
+}
+
+func TestFinishInstances(t *testing.T) {
+	instance := &models.Competition{
+		ID: "comp-1", Name: "Weekly Steps (Jun 3, 2024)", Status: "upcoming", Visibility: "public", TemplateID: "template-1",
+		StartDate: time.Now().Add(24 * time.Hour), EndDate: time.Now().Add(8 * 24 * time.Hour),
+	}
+	newService := func(t *testing.T, script ...fakeQuery) *TemplateService {
+		client, mr := setupTestRedis(t)
+		t.Cleanup(mr.Close)
+		cache := NewCacheService(client)
+		db, _ := newFakeDB(t, script...)
+		competitions := NewCompetitionService(db, cache, NewLeaderboardService(cache, client), nil, nil, models.RefundPolicy{}, "")
+		return NewTemplateService(db, competitions, NewNotificationService(db, cache), utils.NewLogger("error"))
+	}
+
+	t.Run("enrols subscribers again and marks the instance", func(t *testing.T) {
+		service := newService(t,
+			fakeQuery{match: "c.enrolled_at IS NULL", rows: [][]driver.Value{competitionRow(instance)}},
+			fakeQuery{match: "FROM public.competition_template_subscriptions", rows: [][]driver.Value{{"user-1"}}},
+			// The subscriber was enrolled before the setup failed
+			fakeQuery{match: "FOR UPDATE", rows: [][]driver.Value{competitionRow(instance)}},
+			fakeQuery{match: "SELECT EXISTS(SELECT 1 FROM public.competition_participants", rows: [][]driver.Value{{true}}},
+			fakeQuery{match: "SET enrolled_at = NOW()", affected: 1},
+		)
+		require.NoError(t, service.FinishInstances(context.Background()))
+	})
+
+	t.Run("leaves the instance unmarked when enrolment fails", func(t *testing.T) {
+		service := newService(t,
+			fakeQuery{match: "c.enrolled_at IS NULL", rows: [][]driver.Value{competitionRow(instance)}},
+			fakeQuery{match: "FROM public.competition_template_subscriptions", err: errors.New("connection reset")},
+		)
+		assert.Error(t, service.FinishInstances(context.Background()))
+	})
+}
+
+func TestCreateTemplate_KeepsTemplateWhenFirstInstanceFails(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	db, _ := newFakeDB(t,
+		fakeQuery{match: "INSERT INTO public.competition_templates", affected: 1},
+		fakeQuery{match: "FOR UPDATE SKIP LOCKED", err: errors.New("connection reset")},
+	)
+	competitions := NewCompetitionService(db, cache, NewLeaderboardService(cache, client), nil, nil, models.RefundPolicy{}, "")
+	service := NewTemplateService(db, competitions, NewNotificationService(db, cache), utils.NewLogger("error"))
+
+	tpl, err := service.CreateTemplate(context.Background(), &models.CreateTemplateRequest{
+		Name:           "Weekly Steps",
+		Recurrence:     models.RecurrenceRule{Frequency: "weekly"},
+		FirstStartDate: time.Now().Add(48 * time.Hour),
+		CreatorID:      "user-1",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tpl.ID)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrInvalidTimeZone is returned for a time zone that is not a known IANA zone
+var ErrInvalidTimeZone = errors.New("invalid time zone")
+
+// defaultTimeZone is used for competitions created without a time zone
+const defaultTimeZone = "UTC"
+
+// loadTimeZone resolves an IANA zone name, treating an empty name as UTC
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		name = defaultTimeZone
+	}
+	// LoadLocation also accepts "Local", which would depend on the server
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, name)
+	}
+	return loc, nil
+}
+
+// wallClockIn returns the instant with t's date and clock time in loc,
+// ignoring the offset t was given in
+func wallClockIn(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// competitionDay returns midnight in loc of the day t falls on there
+func competitionDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// competitionTimeZoneKey caches each competition's zone so syncs don't need
+// a database round trip
+func competitionTimeZoneKey(competitionID string) string {
+	return fmt.Sprintf("competition_tz:%s", competitionID)
+}
+
+// cacheCompetitionTimeZone records the zone of a competition for
+// competitionLocation
+func cacheCompetitionTimeZone(ctx context.Context, cache *CacheService, competitionID, name string) error {
+	if name == "" {
+		name = defaultTimeZone
+	}
+	return cache.Set(ctx, competitionTimeZoneKey(competitionID), name, 0)
+}
+
+// competitionLocation returns the zone of a competition from the cache,
+// falling back to the database when one is given. Without a database,
+// competitions that were never cached use UTC.
+func competitionLocation(ctx context.Context, cache *CacheService, db *sql.DB, competitionID string) (*time.Location, error) {
+	var name string
+	err := cache.Get(ctx, competitionTimeZoneKey(competitionID), &name)
+	if err == redis.Nil {
+		if db == nil {
+			return time.UTC, nil
+		}
+		err = db.QueryRowContext(ctx, `SELECT time_zone FROM public.competitions WHERE id = $1`, competitionID).Scan(&name)
+		if err == sql.ErrNoRows {
+			return nil, ErrCompetitionNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get competition time zone: %w", err)
+		}
+		if err := cacheCompetitionTimeZone(ctx, cache, competitionID, name); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get competition time zone: %w", err)
+	}
+	return loadTimeZone(name)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTimeZone(t *testing.T) {
+	loc, err := loadTimeZone("")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = loadTimeZone("Pacific/Auckland")
+	require.NoError(t, err)
+	assert.Equal(t, "Pacific/Auckland", loc.String())
+
+	_, err = loadTimeZone("Mars/Olympus_Mons")
+	assert.ErrorIs(t, err, ErrInvalidTimeZone)
+	_, err = loadTimeZone("Local")
+	assert.ErrorIs(t, err, ErrInvalidTimeZone)
+}
+
+func TestCompetitionDay(t *testing.T) {
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	require.NoError(t, err)
+
+	// 20:00 UTC on June 2 is already June 3 in Auckland
+	synced := time.Date(2024, 6, 2, 20, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 6, 3, 0, 0, 0, 0, auckland), competitionDay(synced, auckland))
+	assert.Equal(t, time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC), competitionDay(synced, time.UTC))
+
+	// The offset the client sent doesn't change the day
+	sameInstant := synced.In(time.FixedZone("client", -7*3600))
+	assert.Equal(t, competitionDay(synced, auckland), competitionDay(sameInstant, auckland))
+}
+
+func TestWallClockIn(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	start := wallClockIn(time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), newYork)
+	assert.Equal(t, time.Date(2024, 6, 3, 4, 0, 0, 0, time.UTC), start.UTC())
+}
+
+func TestTemplateRecurrenceKeepsLocalStartAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// Daylight saving starts on March 10, 2024 in New York
+	tpl := &models.CompetitionTemplate{
+		Recurrence:     models.RecurrenceRule{Frequency: "weekly", Interval: 1},
+		FirstStartDate: time.Date(2024, 3, 4, 0, 0, 0, 0, newYork),
+		TimeZone:       "America/New_York",
+	}
+	next := occurrence(tpl, 1)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, newYork), next)
+	assert.Equal(t, 6*24*time.Hour+23*time.Hour, next.Sub(tpl.FirstStartDate))
+}
+
+func TestCompetitionLocation(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	cache := NewCacheService(client)
+	ctx := context.Background()
+
+	loc, err := competitionLocation(ctx, cache, nil, "comp-1")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc, "competitions without a cached zone use UTC")
+
+	require.NoError(t, cacheCompetitionTimeZone(ctx, cache, "comp-1", "Europe/Berlin"))
+	loc, err = competitionLocation(ctx, cache, nil, "comp-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", loc.String())
+}
@@ -3,12 +3,20 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/yourusername/health-competition-go/internal/models"
 )
 
+// ErrInvalidProfileVisibility is returned for an unknown profile visibility level
+var ErrInvalidProfileVisibility = errors.New("profile visibility must be public or private")
+
+// competitionToday is the current date in the time zone of the competition
+// aliased c, or in UTC for fitness data outside a competition
+const competitionToday = `(NOW() AT TIME ZONE COALESCE(c.time_zone, 'UTC'))::date`
+
 type UserService struct {
 	db    *sql.DB
 	cache *CacheService
@@ -31,7 +39,7 @@ func (s *UserService) GetDashboardStats(ctx context.Context, userID string) (*mo
 			COALESCE(SUM(steps), 0) as total_steps,
 			COALESCE(SUM(calories), 0) as total_calories,
 			COALESCE(SUM(distance), 0) as total_distance
-		FROM public.fitness_data
+		FROM public.fitness_daily
 		WHERE user_id = $1
 	`
 
@@ -64,16 +72,18 @@ func (s *UserService) GetDashboardStats(ctx context.Context, userID string) (*mo
 		stats.BestRank = 0
 	}
 
-	// Get weekly activity (last 7 days)
+	// Get weekly activity (last 7 days). Each row's date is already a day in
+	// its competition's time zone, so "today" is taken in that zone too.
 	weeklyQuery := `
 		SELECT 
-			DATE(date) as activity_date,
-			COALESCE(SUM(steps), 0) as steps,
-			COALESCE(SUM(calories), 0) as calories,
-			COALESCE(SUM(distance), 0) as distance
-		FROM public.fitness_data
-		WHERE user_id = $1 AND date >= NOW() - INTERVAL '7 days'
-		GROUP BY DATE(date)
+			fd.date as activity_date,
+			COALESCE(SUM(fd.steps), 0) as steps,
+			COALESCE(SUM(fd.calories), 0) as calories,
+			COALESCE(SUM(fd.distance), 0) as distance
+		FROM public.fitness_daily fd
+		LEFT JOIN public.competitions c ON c.id = fd.competition_id
+		WHERE fd.user_id = $1 AND fd.date > ` + competitionToday + ` - 7
+		GROUP BY fd.date
 		ORDER BY activity_date DESC
 	`
 
@@ -127,12 +137,13 @@ func (s *UserService) GetDashboardStats(ctx context.Context, userID string) (*mo
 
 	changeQuery := `
 		SELECT 
-			COALESCE(SUM(CASE WHEN date >= NOW() - INTERVAL '7 days' THEN steps ELSE 0 END), 0) as last_week_steps,
-			COALESCE(SUM(CASE WHEN date >= NOW() - INTERVAL '14 days' AND date < NOW() - INTERVAL '7 days' THEN steps ELSE 0 END), 0) as prev_week_steps,
-			COALESCE(SUM(CASE WHEN date >= NOW() - INTERVAL '7 days' THEN calories ELSE 0 END), 0) as last_week_calories,
-			COALESCE(SUM(CASE WHEN date >= NOW() - INTERVAL '14 days' AND date < NOW() - INTERVAL '7 days' THEN calories ELSE 0 END), 0) as prev_week_calories
-		FROM public.fitness_data
-		WHERE user_id = $1
+			COALESCE(SUM(CASE WHEN fd.date > ` + competitionToday + ` - 7 THEN fd.steps ELSE 0 END), 0) as last_week_steps,
+			COALESCE(SUM(CASE WHEN fd.date > ` + competitionToday + ` - 14 AND fd.date <= ` + competitionToday + ` - 7 THEN fd.steps ELSE 0 END), 0) as prev_week_steps,
+			COALESCE(SUM(CASE WHEN fd.date > ` + competitionToday + ` - 7 THEN fd.calories ELSE 0 END), 0) as last_week_calories,
+			COALESCE(SUM(CASE WHEN fd.date > ` + competitionToday + ` - 14 AND fd.date <= ` + competitionToday + ` - 7 THEN fd.calories ELSE 0 END), 0) as prev_week_calories
+		FROM public.fitness_daily fd
+		LEFT JOIN public.competitions c ON c.id = fd.competition_id
+		WHERE fd.user_id = $1
 	`
 
 	if err := s.db.QueryRowContext(ctx, changeQuery, userID).Scan(
@@ -184,6 +195,8 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID string) (*model
 			TotalDistance:   0,
 			CompetitionsWon: 0,
 			TotalPrizes:     0,
+
+			ProfileVisibility: models.ProfilePublic,
 		}, nil
 	}
 
@@ -194,6 +207,7 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID string) (*model
 			COALESCE(u.avatar, '') as avatar,
 			COALESCE(u.bio, '') as bio,
 			COALESCE(u.country, '') as country,
+			u.profile_visibility,
 			u.created_at, u.updated_at,
 			COALESCE(SUM(fd.steps), 0) as total_steps,
 			COALESCE(SUM(fd.calories), 0) as total_calories,
@@ -201,19 +215,20 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID string) (*model
 			COUNT(DISTINCT CASE WHEN c.status = 'completed' AND le.rank = 1 THEN c.id END) as competitions_won,
 			COALESCE(SUM(p.amount), 0) as total_prizes
 		FROM public.users u
-		LEFT JOIN public.fitness_data fd ON fd.user_id = u.id
+		LEFT JOIN public.fitness_daily fd ON fd.user_id = u.id
 		LEFT JOIN public.competition_participants cp ON cp.user_id = u.id
 		LEFT JOIN public.competitions c ON c.id = cp.competition_id
 		LEFT JOIN public.leaderboard_entries le ON le.user_id = u.id AND le.competition_id = c.id
 		LEFT JOIN public.prizes p ON p.user_id = u.id AND p.status = 'distributed'
 		WHERE u.id = $1
-		GROUP BY u.id, u.email, u.name, u.avatar, u.bio, u.country, u.created_at, u.updated_at
+		GROUP BY u.id, u.email, u.name, u.avatar, u.bio, u.country, u.profile_visibility, u.created_at, u.updated_at
 	`
 
 	var profile models.UserProfile
 	err = s.db.QueryRowContext(ctx, query, userID).Scan(
 		&profile.ID, &profile.Email, &profile.Name,
 		&profile.Avatar, &profile.Bio, &profile.Country,
+		&profile.ProfileVisibility,
 		&profile.JoinedAt, &profile.UpdatedAt,
 		&profile.TotalSteps, &profile.TotalCalories, &profile.TotalDistance,
 		&profile.CompetitionsWon, &profile.TotalPrizes,
@@ -227,6 +242,12 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID string) (*model
 
 // UpdateUserProfile updates user profile information
 func (s *UserService) UpdateUserProfile(ctx context.Context, userID string, req *models.UpdateProfileRequest) (*models.UserProfile, error) {
+	switch req.ProfileVisibility {
+	case "", models.ProfilePublic, models.ProfilePrivate:
+	default:
+		return nil, ErrInvalidProfileVisibility
+	}
+
 	query := `
 		UPDATE public.users
 		SET 
@@ -234,12 +255,13 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, userID string, req
 			avatar = COALESCE(NULLIF($2, ''), avatar),
 			bio = COALESCE(NULLIF($3, ''), bio),
 			country = COALESCE(NULLIF($4, ''), country),
-			updated_at = $5
-		WHERE id = $6
+			profile_visibility = COALESCE(NULLIF($5, ''), profile_visibility),
+			updated_at = $6
+		WHERE id = $7
 	`
 
 	_, err := s.db.ExecContext(ctx, query,
-		req.Name, req.Avatar, req.Bio, req.Country, time.Now(), userID,
+		req.Name, req.Avatar, req.Bio, req.Country, req.ProfileVisibility, time.Now(), userID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update profile: %w", err)
@@ -270,13 +292,14 @@ func (s *UserService) UpdateUserAvatar(ctx context.Context, userID string, avata
 func (s *UserService) GetUserActivity(ctx context.Context, userID string, days int) ([]models.DailyActivity, error) {
 	query := `
 		SELECT 
-			DATE(date) as activity_date,
-			COALESCE(SUM(steps), 0) as steps,
-			COALESCE(SUM(calories), 0) as calories,
-			COALESCE(SUM(distance), 0) as distance
-		FROM public.fitness_data
-		WHERE user_id = $1 AND date >= NOW() - INTERVAL '$2 days'
-		GROUP BY DATE(date)
+			fd.date as activity_date,
+			COALESCE(SUM(fd.steps), 0) as steps,
+			COALESCE(SUM(fd.calories), 0) as calories,
+			COALESCE(SUM(fd.distance), 0) as distance
+		FROM public.fitness_daily fd
+		LEFT JOIN public.competitions c ON c.id = fd.competition_id
+		WHERE fd.user_id = $1 AND fd.date > ` + competitionToday + ` - $2::int
+		GROUP BY fd.date
 		ORDER BY activity_date DESC
 	`
 
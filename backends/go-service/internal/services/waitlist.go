@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/payments"
+)
+
+// countParticipants returns the number of participants in a competition
+func countParticipants(ctx context.Context, db dbExecutor, competitionID string) (int, error) {
+	var participants int
+	query := `SELECT COUNT(*) FROM public.competition_participants WHERE competition_id = $1`
+	if err := db.QueryRowContext(ctx, query, competitionID).Scan(&participants); err != nil {
+		return 0, fmt.Errorf("failed to count participants: %w", err)
+	}
+	return participants, nil
+}
+
+// addToWaitlist appends a user to a competition's waitlist and returns their
+// 1-based position. Adding a user who is already waiting keeps their place.
+func addToWaitlist(ctx context.Context, db dbExecutor, competitionID, userID string) (int, error) {
+	insertQuery := `
+		INSERT INTO public.competition_waitlist (id, competition_id, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (competition_id, user_id) DO NOTHING
+	`
+	if _, err := db.ExecContext(ctx, insertQuery, uuid.New().String(), competitionID, userID); err != nil {
+		return 0, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+
+	positionQuery := `
+		SELECT COUNT(*)
+		FROM public.competition_waitlist w
+		JOIN public.competition_waitlist me ON me.competition_id = w.competition_id AND me.user_id = $2
+		WHERE w.competition_id = $1
+			AND (w.created_at, w.id) <= (me.created_at, me.id)
+	`
+	var position int
+	if err := db.QueryRowContext(ctx, positionQuery, competitionID, userID).Scan(&position); err != nil {
+		return 0, fmt.Errorf("failed to get waitlist position: %w", err)
+	}
+
+	return position, nil
+}
+
+// removeFromWaitlist drops a user from a competition's waitlist, if present
+func removeFromWaitlist(ctx context.Context, db dbExecutor, competitionID, userID string) error {
+	_, err := removeFromWaitlistIfPresent(ctx, db, competitionID, userID)
+	return err
+}
+
+// removeFromWaitlistIfPresent drops a user from a competition's waitlist and
+// reports whether they were on it
+func removeFromWaitlistIfPresent(ctx context.Context, db dbExecutor, competitionID, userID string) (bool, error) {
+	query := `DELETE FROM public.competition_waitlist WHERE competition_id = $1 AND user_id = $2`
+	res, err := db.ExecContext(ctx, query, competitionID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to leave waitlist: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// promoteFromWaitlist fills any free spots in comp from the head of its
+// waitlist, charging entry fees as it goes. Users whose payment fails lose
+// their place and are notified. It returns the promoted user IDs and the
+// charges taken, which the caller must refund if tx is rolled back.
+func (s *CompetitionService) promoteFromWaitlist(ctx context.Context, tx *sql.Tx, comp *models.Competition) ([]string, []*payments.ChargeResult, error) {
+	var promoted []string
+	var charges []*payments.ChargeResult
+
+	for {
+		if comp.MaxParticipants != nil {
+			participants, err := countParticipants(ctx, tx, comp.ID)
+			if err != nil {
+				return promoted, charges, err
+			}
+			if participants >= *comp.MaxParticipants {
+				return promoted, charges, nil
+			}
+		}
+
+		var userID string
+		nextQuery := `
+			DELETE FROM public.competition_waitlist
+			WHERE id = (
+				SELECT id FROM public.competition_waitlist
+				WHERE competition_id = $1
+				ORDER BY created_at, id
+				LIMIT 1
+				FOR UPDATE
+			)
+			RETURNING user_id
+		`
+		err := tx.QueryRowContext(ctx, nextQuery, comp.ID).Scan(&userID)
+		if err == sql.ErrNoRows {
+			return promoted, charges, nil
+		}
+		if err != nil {
+			return promoted, charges, fmt.Errorf("failed to read waitlist: %w", err)
+		}
+
+		_, charge, err := s.addParticipant(ctx, tx, comp, userID)
+		if charge != nil {
+			charges = append(charges, charge)
+		}
+		if errors.Is(err, ErrPaymentFailed) {
+			if err := s.notifications.NotifyUser(ctx, tx, &models.Notification{
+				UserID:        userID,
+				Type:          "waitlist_payment_failed",
+				Title:         "Waitlist spot released",
+				Message:       fmt.Sprintf("A spot opened up in %s but we couldn't charge your entry fee, so it was offered to the next person.", comp.Name),
+				CompetitionID: comp.ID,
+			}); err != nil {
+				return promoted, charges, err
+			}
+			continue
+		}
+		if err != nil {
+			return promoted, charges, err
+		}
+
+		if err := s.notifications.NotifyUser(ctx, tx, &models.Notification{
+			UserID:        userID,
+			Type:          "waitlist_promoted",
+			Title:         "You're in!",
+			Message:       fmt.Sprintf("A spot opened up and you've been moved from the waitlist into %s.", comp.Name),
+			CompetitionID: comp.ID,
+		}); err != nil {
+			return promoted, charges, err
+		}
+		promoted = append(promoted, userID)
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+	"github.com/yourusername/health-competition-go/pkg/webhooks"
+)
+
+var (
+	ErrUnknownWebhookVendor = errors.New("webhook vendor is not configured")
+	ErrWearableNotLinked    = errors.New("wearable account is not linked")
+)
+
+const (
+	// webhookQueueKey is the Redis list received events wait in
+	webhookQueueKey = "webhook_queue"
+
+	// webhookFailedKey keeps the events that failed maxWebhookAttempts times
+	webhookFailedKey = "webhook_queue:failed"
+
+	maxWebhookAttempts = 3
+
+	// webhookPollTimeout is how long the worker blocks waiting for events
+	// before checking whether it should stop
+	webhookPollTimeout = 5 * time.Second
+)
+
+// webhookJob is a received event waiting to be synced
+type webhookJob struct {
+	Vendor     string         `json:"vendor"`
+	Event      webhooks.Event `json:"event"`
+	ReceivedAt time.Time      `json:"received_at"`
+	Attempts   int            `json:"attempts"`
+	LastError  string         `json:"last_error,omitempty"`
+}
+
+// WebhookService ingests the daily activity wearable vendors push. Receiving
+// only verifies the vendor's signature, decodes the payload and queues its
+// events in Redis, so vendors get a quick answer. A worker then maps each
+// event's vendor user to ours and syncs the day to their active competitions
+// with the vendor as the source. Failed events are retried and, after
+// maxWebhookAttempts, set aside in webhookFailedKey.
+type WebhookService struct {
+	db      *sql.DB
+	client  *redis.Client
+	cache   *CacheService
+	fitness *FitnessService
+	vendors map[string]webhooks.Vendor
+	logger  *utils.Logger
+}
+
+func NewWebhookService(db *sql.DB, client *redis.Client, cache *CacheService, fitness *FitnessService, vendors map[string]webhooks.Vendor, logger *utils.Logger) *WebhookService {
+	return &WebhookService{
+		db:      db,
+		client:  client,
+		cache:   cache,
+		fitness: fitness,
+		vendors: vendors,
+		logger:  logger,
+	}
+}
+
+// Receive verifies and queues a vendor's push, returning how many events it
+// carried
+func (s *WebhookService) Receive(ctx context.Context, vendor string, header http.Header, body []byte) (int, error) {
+	v, ok := s.vendors[vendor]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownWebhookVendor, vendor)
+	}
+	if err := v.Verify(header, body); err != nil {
+		return 0, err
+	}
+	events, err := v.Decode(body)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	jobs := make([]interface{}, len(events))
+	for i, event := range events {
+		data, err := json.Marshal(webhookJob{Vendor: vendor, Event: event, ReceivedAt: now})
+		if err != nil {
+			return 0, err
+		}
+		jobs[i] = data
+	}
+	if err := s.client.RPush(ctx, webhookQueueKey, jobs...).Err(); err != nil {
+		return 0, fmt.Errorf("failed to queue webhook events: %w", err)
+	}
+	return len(events), nil
+}
+
+// Run syncs queued events until ctx is cancelled
+func (s *WebhookService) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if _, err := s.processNext(ctx, webhookPollTimeout); err != nil && ctx.Err() == nil {
+			s.logger.Errorf("Webhook worker failed: %v", err)
+			// Don't spin while Redis is unavailable
+			select {
+			case <-ctx.Done():
+			case <-time.After(webhookPollTimeout):
+			}
+		}
+	}
+}
+
+// processNext waits up to timeout for a queued event and syncs it, reporting
+// whether there was one. An event that fails to sync is queued again, or set
+// aside once it has failed maxWebhookAttempts times.
+func (s *WebhookService) processNext(ctx context.Context, timeout time.Duration) (bool, error) {
+	result, err := s.client.BLPop(ctx, timeout, webhookQueueKey).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var job webhookJob
+	if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
+		s.logger.Errorf("Dropping malformed webhook job: %v", err)
+		return true, nil
+	}
+
+	err = s.process(ctx, &job)
+	if errors.Is(err, ErrWearableNotLinked) {
+		s.logger.Warnf("Dropping %s event for unlinked user %s", job.Vendor, job.Event.VendorUserID)
+		return true, nil
+	}
+	if err == nil {
+		return true, nil
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	key := webhookQueueKey
+	if job.Attempts >= maxWebhookAttempts {
+		key = webhookFailedKey
+		s.logger.Errorf("Giving up on %s event for user %s after %d attempts: %v", job.Vendor, job.Event.VendorUserID, job.Attempts, err)
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return true, err
+	}
+	return true, s.client.RPush(ctx, key, data).Err()
+}
+
+// process syncs an event's day to each active competition of the user it
+// belongs to that the day falls within
+func (s *WebhookService) process(ctx context.Context, job *webhookJob) error {
+	userID, err := s.lookupWearable(ctx, job.Vendor, job.Event.VendorUserID)
+	if err != nil {
+		return err
+	}
+	competitionIDs, err := activeCompetitionIDs(ctx, s.db, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, competitionID := range competitionIDs {
+		loc, err := competitionLocation(ctx, s.cache, s.db, competitionID)
+		if err != nil {
+			return err
+		}
+		window, err := loadCompetitionWindow(ctx, s.db, competitionID, loc)
+		if err != nil {
+			return err
+		}
+		req, ok := webhookSyncRequest(job.Vendor, job.Event, userID, competitionID, loc, window, time.Now())
+		if !ok {
+			continue
+		}
+		if _, err := s.fitness.SyncFitnessData(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookSyncRequest converts a pushed event into a sync for a competition.
+// ok is false when the event's day isn't part of the competition so far.
+func webhookSyncRequest(vendor string, event webhooks.Event, userID, competitionID string, loc *time.Location, window *competitionWindow, now time.Time) (*models.FitnessSyncRequest, bool) {
+	day, err := time.ParseInLocation("2006-01-02", event.Date, loc)
+	if err != nil || day.Before(window.first) || day.After(window.last) || day.After(competitionDay(now, loc)) {
+		return nil, false
+	}
+	return &models.FitnessSyncRequest{
+		UserID:        userID,
+		CompetitionID: competitionID,
+		Steps:         event.Steps,
+		Distance:      event.Distance,
+		Calories:      event.Calories,
+		ActiveMinutes: event.ActiveMinutes,
+		Source:        vendor,
+		Date:          day,
+	}, true
+}
+
+// LinkWearable maps a vendor's user ID to one of our users, replacing any
+// earlier mapping of that vendor user
+func (s *WebhookService) LinkWearable(ctx context.Context, vendor, vendorUserID, userID string) (*models.WearableAccount, error) {
+	if _, ok := s.vendors[vendor]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWebhookVendor, vendor)
+	}
+
+	account := &models.WearableAccount{Vendor: vendor, VendorUserID: vendorUserID, UserID: userID}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO public.wearable_accounts (vendor, vendor_user_id, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (vendor, vendor_user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING created_at
+	`, vendor, vendorUserID, userID).Scan(&account.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link wearable account: %w", err)
+	}
+	return account, nil
+}
+
+// UnlinkWearable removes a vendor user's mapping; their pushes are dropped
+// from then on
+func (s *WebhookService) UnlinkWearable(ctx context.Context, vendor, vendorUserID string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM public.wearable_accounts WHERE vendor = $1 AND vendor_user_id = $2`, vendor, vendorUserID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink wearable account: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrWearableNotLinked
+	}
+	return nil
+}
+
+func (s *WebhookService) lookupWearable(ctx context.Context, vendor, vendorUserID string) (string, error) {
+	var userID string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id FROM public.wearable_accounts WHERE vendor = $1 AND vendor_user_id = $2
+	`, vendor, vendorUserID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", ErrWearableNotLinked
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up wearable account: %w", err)
+	}
+	return userID, nil
+}
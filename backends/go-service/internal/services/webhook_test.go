@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/health-competition-go/pkg/utils"
+	"github.com/yourusername/health-competition-go/pkg/webhooks"
+)
+
+func TestWebhookService_Receive(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	garmin, err := webhooks.New("garmin", "secret")
+	require.NoError(t, err)
+	service := NewWebhookService(nil, client, NewCacheService(client), nil,
+		map[string]webhooks.Vendor{"garmin": garmin}, utils.NewLogger("error"))
+	ctx := context.Background()
+
+	body := []byte(`{"dailies":[
+		{"userId":"g-1","calendarDate":"2024-06-03","steps":8000,"distanceInMeters":6100.5,"activeKilocalories":320,"moderateIntensityDurationInSeconds":1200,"vigorousIntensityDurationInSeconds":600},
+		{"userId":"g-2","calendarDate":"2024-06-03","steps":4000}
+	]}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signed := http.Header{}
+	signed.Set("X-Garmin-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	t.Run("queues one job per event", func(t *testing.T) {
+		queued, err := service.Receive(ctx, "garmin", signed, body)
+		require.NoError(t, err)
+		assert.Equal(t, 2, queued)
+
+		jobs, err := client.LRange(ctx, webhookQueueKey, 0, -1).Result()
+		require.NoError(t, err)
+		require.Len(t, jobs, 2)
+
+		var job webhookJob
+		require.NoError(t, json.Unmarshal([]byte(jobs[0]), &job))
+		assert.Equal(t, "garmin", job.Vendor)
+		assert.Equal(t, "g-1", job.Event.VendorUserID)
+		assert.Equal(t, int64(8000), job.Event.Steps)
+		assert.Equal(t, 30, job.Event.ActiveMinutes)
+		assert.Zero(t, job.Attempts)
+	})
+
+	t.Run("rejects a bad signature", func(t *testing.T) {
+		forged := http.Header{}
+		forged.Set("X-Garmin-Signature", hex.EncodeToString(make([]byte, sha256.Size)))
+		_, err := service.Receive(ctx, "garmin", forged, body)
+		assert.ErrorIs(t, err, webhooks.ErrInvalidSignature)
+	})
+
+	t.Run("rejects an unconfigured vendor", func(t *testing.T) {
+		_, err := service.Receive(ctx, "polar", signed, body)
+		assert.ErrorIs(t, err, ErrUnknownWebhookVendor)
+	})
+
+	n, err := client.LLen(ctx, webhookQueueKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n, "rejected pushes must not be queued")
+}
+
+func TestWebhookService_ProcessNextDropsMalformedJobs(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+
+	service := NewWebhookService(nil, client, NewCacheService(client), nil, nil, utils.NewLogger("error"))
+	ctx := context.Background()
+
+	processed, err := service.processNext(ctx, time.Second)
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	require.NoError(t, client.RPush(ctx, webhookQueueKey, "not json").Err())
+	processed, err = service.processNext(ctx, time.Second)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.False(t, mr.Exists(webhookQueueKey))
+	assert.False(t, mr.Exists(webhookFailedKey))
+}
+
+func TestWebhookSyncRequest(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, loc) }
+	window := &competitionWindow{first: day(1), last: day(20)}
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, loc)
+
+	event := webhooks.Event{VendorUserID: "p-1", Date: "2024-06-05", Steps: 9000, Distance: 7000, Calories: 350, ActiveMinutes: 45}
+	req, ok := webhookSyncRequest("polar", event, "user-1", "comp-1", loc, window, now)
+	require.True(t, ok)
+	assert.Equal(t, "user-1", req.UserID)
+	assert.Equal(t, "comp-1", req.CompetitionID)
+	assert.Equal(t, "polar", req.Source)
+	assert.Equal(t, day(5), req.Date)
+	assert.Equal(t, int64(9000), req.Steps)
+	assert.Equal(t, 7000.0, req.Distance)
+	assert.Equal(t, 350.0, req.Calories)
+	assert.Equal(t, 45, req.ActiveMinutes)
+
+	for _, date := range []string{"2024-05-31", "2024-06-11", "2024-06-21", "June 5"} {
+		event.Date = date
+		_, ok := webhookSyncRequest("polar", event, "user-1", "comp-1", loc, window, now)
+		assert.False(t, ok, date)
+	}
+}
@@ -0,0 +1,471 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/payments"
+)
+
+var (
+	ErrWithdrawalNotFound   = errors.New("withdrawal not found")
+	ErrWithdrawalNotPending = errors.New("withdrawal is not pending review")
+	ErrPayoutFailed         = errors.New("payout failed")
+
+	ErrInvalidWithdrawal       = errors.New("invalid withdrawal")
+	ErrWithdrawalLimitExceeded = errors.New("withdrawal limit exceeded")
+)
+
+// Supported withdrawal destinations
+var withdrawalDestinationTypes = map[string]bool{
+	"bank_account": true,
+	"upi":          true,
+	"paypal":       true,
+}
+
+// withdrawalPayoutGrace is how long an approved withdrawal's payout may be in
+// flight before ReconcileWithdrawals takes it over
+const withdrawalPayoutGrace = 10 * time.Minute
+
+const withdrawalColumns = `
+	id, user_id, amount, destination_type, destination, status, transaction_id,
+	reviewed_by, review_note, payout_ref, created_at, reviewed_at, completed_at
+`
+
+type WithdrawalService struct {
+	db      *sql.DB
+	payouts payments.PayoutProvider
+	limits  models.WithdrawalLimits
+}
+
+func NewWithdrawalService(db *sql.DB, payouts payments.PayoutProvider, limits models.WithdrawalLimits) *WithdrawalService {
+	return &WithdrawalService{
+		db:      db,
+		payouts: payouts,
+		limits:  limits,
+	}
+}
+
+// RequestWithdrawal places a hold on the user's balance and queues the
+// withdrawal for admin review
+func (s *WithdrawalService) RequestWithdrawal(ctx context.Context, userID string, req *models.CreateWithdrawalRequest) (*models.Withdrawal, error) {
+	if !withdrawalDestinationTypes[req.DestinationType] {
+		return nil, fmt.Errorf("%w: unsupported destination type: %s", ErrInvalidWithdrawal, req.DestinationType)
+	}
+	if req.Destination == "" {
+		return nil, fmt.Errorf("%w: destination is required", ErrInvalidWithdrawal)
+	}
+
+	amount := math.Round(req.Amount*100) / 100
+	now := time.Now()
+
+	withdrawal := &models.Withdrawal{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		Amount:          amount,
+		DestinationType: req.DestinationType,
+		Destination:     req.Destination,
+		Status:          "pending",
+		TransactionID:   uuid.New().String(),
+		CreatedAt:       now,
+	}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		// Lock the user row so concurrent requests see each other's holds
+		var lockedID string
+		err := tx.QueryRowContext(ctx, `SELECT id FROM public.users WHERE id = $1 FOR UPDATE`, userID).Scan(&lockedID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+
+		limits, err := s.limitsForUser(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		balance, err := ledgerBalance(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+		var dailyTotal, monthlyTotal float64
+		totalsQuery := `
+			SELECT
+				COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0),
+				COALESCE(SUM(amount) FILTER (WHERE created_at >= $3), 0)
+			FROM public.withdrawal_requests
+			WHERE user_id = $1 AND status NOT IN ('rejected', 'failed')
+		`
+		if err := tx.QueryRowContext(ctx, totalsQuery, userID, dayStart, monthStart).Scan(&dailyTotal, &monthlyTotal); err != nil {
+			return fmt.Errorf("failed to get withdrawal totals: %w", err)
+		}
+
+		if err := checkWithdrawalLimits(amount, balance, dailyTotal, monthlyTotal, limits); err != nil {
+			return err
+		}
+
+		txQuery := `
+			INSERT INTO public.transactions (id, user_id, type, amount, status, description, payment_method, created_at)
+			VALUES ($1, $2, 'withdrawal', $3, 'pending', $4, $5, $6)
+		`
+		if _, err := tx.ExecContext(ctx, txQuery,
+			withdrawal.TransactionID, userID, amount, "Withdrawal request", req.DestinationType, now,
+		); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		insertQuery := `
+			INSERT INTO public.withdrawal_requests (id, user_id, amount, destination_type, destination, status, transaction_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`
+		if _, err := tx.ExecContext(ctx, insertQuery,
+			withdrawal.ID, userID, amount, withdrawal.DestinationType, withdrawal.Destination,
+			withdrawal.Status, withdrawal.TransactionID, now,
+		); err != nil {
+			return fmt.Errorf("failed to create withdrawal: %w", err)
+		}
+
+		return recordLedgerEntry(ctx, tx, userID, -amount, LedgerTypeWithdrawalHold, withdrawal.ID, "Withdrawal hold")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
+
+// GetUserWithdrawals retrieves a user's withdrawal history
+func (s *WithdrawalService) GetUserWithdrawals(ctx context.Context, userID string) ([]models.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + `
+		FROM public.withdrawal_requests
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 50
+	`
+	return s.queryWithdrawals(ctx, query, userID)
+}
+
+// GetWithdrawals retrieves withdrawals by status for the admin review queue,
+// oldest first
+func (s *WithdrawalService) GetWithdrawals(ctx context.Context, status string, limit, offset int) ([]models.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + `
+		FROM public.withdrawal_requests
+		WHERE status = $1
+		ORDER BY created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+	return s.queryWithdrawals(ctx, query, status, limit, offset)
+}
+
+// ApproveWithdrawal approves a pending withdrawal and sends the payout.
+// If the payout fails the hold is released and ErrPayoutFailed is returned.
+// If the payout is sent but can't be recorded, the withdrawal stays approved
+// and ReconcileWithdrawals records it later.
+func (s *WithdrawalService) ApproveWithdrawal(ctx context.Context, withdrawalID, adminID, note string) (*models.Withdrawal, error) {
+	var withdrawal *models.Withdrawal
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		w, err := s.lockPendingWithdrawal(ctx, tx, withdrawalID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updateQuery := `
+			UPDATE public.withdrawal_requests
+			SET status = 'approved', reviewed_by = $1, review_note = $2, reviewed_at = $3
+			WHERE id = $4
+		`
+		if _, err := tx.ExecContext(ctx, updateQuery, adminID, note, now, withdrawalID); err != nil {
+			return fmt.Errorf("failed to approve withdrawal: %w", err)
+		}
+
+		w.Status = "approved"
+		w.ReviewedBy = adminID
+		w.ReviewNote = note
+		w.ReviewedAt = &now
+		withdrawal = w
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.payOut(ctx, withdrawal); err != nil {
+		if errors.Is(err, ErrPayoutFailed) {
+			return withdrawal, err
+		}
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+// ReconcileWithdrawals finishes approved withdrawals whose outcome was never
+// recorded, such as when the server stopped or the database failed right
+// after the payout was sent. Payouts are idempotent per withdrawal, so
+// sending one again returns the original result instead of paying twice.
+func (s *WithdrawalService) ReconcileWithdrawals(ctx context.Context, now time.Time) error {
+	query := `SELECT ` + withdrawalColumns + `
+		FROM public.withdrawal_requests
+		WHERE status = 'approved' AND reviewed_at <= $1
+		ORDER BY reviewed_at
+		LIMIT 100
+	`
+	stuck, err := s.queryWithdrawals(ctx, query, now.Add(-withdrawalPayoutGrace))
+	if err != nil {
+		return err
+	}
+
+	for i := range stuck {
+		if err := s.payOut(ctx, &stuck[i]); err != nil && !errors.Is(err, ErrPayoutFailed) {
+			return err
+		}
+	}
+	return nil
+}
+
+// payOut sends an approved withdrawal's payout and records the outcome
+func (s *WithdrawalService) payOut(ctx context.Context, w *models.Withdrawal) error {
+	result, payoutErr := s.payouts.SendPayout(ctx, &payments.PayoutRequest{
+		WithdrawalID:    w.ID,
+		UserID:          w.UserID,
+		Amount:          w.Amount,
+		DestinationType: w.DestinationType,
+		Destination:     w.Destination,
+	})
+	if payoutErr != nil {
+		if err := s.failWithdrawal(ctx, w, payoutErr.Error()); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrPayoutFailed, payoutErr)
+	}
+
+	return s.completeWithdrawal(ctx, w, result)
+}
+
+// completeWithdrawal marks an approved withdrawal as paid
+func (s *WithdrawalService) completeWithdrawal(ctx context.Context, w *models.Withdrawal, result *payments.PayoutResult) error {
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		updateQuery := `
+			UPDATE public.withdrawal_requests
+			SET status = 'paid', payout_ref = $1, completed_at = $2
+			WHERE id = $3 AND status = 'approved'
+		`
+		res, err := tx.ExecContext(ctx, updateQuery, result.Reference, result.CompletedAt, w.ID)
+		if err != nil {
+			return fmt.Errorf("failed to complete withdrawal: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			// Already recorded by another attempt
+			return nil
+		}
+
+		txQuery := `
+			UPDATE public.transactions
+			SET status = 'completed', transaction_ref = $1, completed_at = $2
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, txQuery, result.Reference, result.CompletedAt, w.TransactionID); err != nil {
+			return fmt.Errorf("failed to complete transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Status = "paid"
+	w.PayoutRef = result.Reference
+	w.CompletedAt = &result.CompletedAt
+	return nil
+}
+
+// RejectWithdrawal rejects a pending withdrawal and releases the hold
+func (s *WithdrawalService) RejectWithdrawal(ctx context.Context, withdrawalID, adminID, note string) (*models.Withdrawal, error) {
+	var withdrawal *models.Withdrawal
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		w, err := s.lockPendingWithdrawal(ctx, tx, withdrawalID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updateQuery := `
+			UPDATE public.withdrawal_requests
+			SET status = 'rejected', reviewed_by = $1, review_note = $2, reviewed_at = $3
+			WHERE id = $4
+		`
+		if _, err := tx.ExecContext(ctx, updateQuery, adminID, note, now, withdrawalID); err != nil {
+			return fmt.Errorf("failed to reject withdrawal: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE public.transactions SET status = 'failed' WHERE id = $1`, w.TransactionID); err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+
+		if err := recordLedgerEntry(ctx, tx, w.UserID, w.Amount, LedgerTypeWithdrawalRelease, w.ID, "Withdrawal rejected"); err != nil {
+			return err
+		}
+
+		w.Status = "rejected"
+		w.ReviewedBy = adminID
+		w.ReviewNote = note
+		w.ReviewedAt = &now
+		withdrawal = w
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
+
+// failWithdrawal marks an approved withdrawal as failed and releases the hold
+func (s *WithdrawalService) failWithdrawal(ctx context.Context, w *models.Withdrawal, reason string) error {
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		updateQuery := `
+			UPDATE public.withdrawal_requests
+			SET status = 'failed', review_note = $1
+			WHERE id = $2 AND status = 'approved'
+		`
+		res, err := tx.ExecContext(ctx, updateQuery, reason, w.ID)
+		if err != nil {
+			return fmt.Errorf("failed to mark withdrawal failed: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			// Already recorded by another attempt; the hold was released then
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE public.transactions SET status = 'failed' WHERE id = $1`, w.TransactionID); err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+
+		return recordLedgerEntry(ctx, tx, w.UserID, w.Amount, LedgerTypeWithdrawalRelease, w.ID, "Withdrawal payout failed")
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Status = "failed"
+	w.ReviewNote = reason
+	return nil
+}
+
+// lockPendingWithdrawal loads a withdrawal with a row lock and checks it is pending
+func (s *WithdrawalService) lockPendingWithdrawal(ctx context.Context, tx *sql.Tx, withdrawalID string) (*models.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + ` FROM public.withdrawal_requests WHERE id = $1 FOR UPDATE`
+
+	w, err := scanWithdrawal(tx.QueryRowContext(ctx, query, withdrawalID))
+	if err == sql.ErrNoRows {
+		return nil, ErrWithdrawalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get withdrawal: %w", err)
+	}
+
+	if w.Status != "pending" {
+		return nil, ErrWithdrawalNotPending
+	}
+
+	return w, nil
+}
+
+// limitsForUser returns the user's limit overrides, falling back to the defaults
+func (s *WithdrawalService) limitsForUser(ctx context.Context, db dbExecutor, userID string) (models.WithdrawalLimits, error) {
+	limits := s.limits
+
+	var daily, monthly sql.NullFloat64
+	query := `SELECT daily_limit, monthly_limit FROM public.withdrawal_limits WHERE user_id = $1`
+	err := db.QueryRowContext(ctx, query, userID).Scan(&daily, &monthly)
+	if err == sql.ErrNoRows {
+		return limits, nil
+	}
+	if err != nil {
+		return limits, fmt.Errorf("failed to get withdrawal limits: %w", err)
+	}
+
+	if daily.Valid {
+		limits.DailyLimit = daily.Float64
+	}
+	if monthly.Valid {
+		limits.MonthlyLimit = monthly.Float64
+	}
+
+	return limits, nil
+}
+
+func (s *WithdrawalService) queryWithdrawals(ctx context.Context, query string, args ...interface{}) ([]models.Withdrawal, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query withdrawals: %w", err)
+	}
+	defer rows.Close()
+
+	withdrawals := []models.Withdrawal{}
+	for rows.Next() {
+		w, err := scanWithdrawal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan withdrawal: %w", err)
+		}
+		withdrawals = append(withdrawals, *w)
+	}
+
+	return withdrawals, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWithdrawal(row rowScanner) (*models.Withdrawal, error) {
+	var w models.Withdrawal
+	var reviewedBy, reviewNote, payoutRef sql.NullString
+
+	if err := row.Scan(
+		&w.ID, &w.UserID, &w.Amount, &w.DestinationType, &w.Destination, &w.Status, &w.TransactionID,
+		&reviewedBy, &reviewNote, &payoutRef, &w.CreatedAt, &w.ReviewedAt, &w.CompletedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	w.ReviewedBy = reviewedBy.String
+	w.ReviewNote = reviewNote.String
+	w.PayoutRef = payoutRef.String
+
+	return &w, nil
+}
+
+// checkWithdrawalLimits validates a withdrawal amount against the user's
+// balance and the minimum, daily and monthly limits
+func checkWithdrawalLimits(amount, balance, dailyTotal, monthlyTotal float64, limits models.WithdrawalLimits) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: amount must be greater than 0", ErrInvalidWithdrawal)
+	}
+	if amount < limits.MinAmount {
+		return fmt.Errorf("%w: minimum withdrawal amount is %.2f", ErrInvalidWithdrawal, limits.MinAmount)
+	}
+	if amount > balance {
+		return fmt.Errorf("%w: available %.2f", ErrInsufficientBalance, balance)
+	}
+	if limits.DailyLimit > 0 && dailyTotal+amount > limits.DailyLimit {
+		return fmt.Errorf("%w: %.2f remaining today", ErrWithdrawalLimitExceeded, math.Max(limits.DailyLimit-dailyTotal, 0))
+	}
+	if limits.MonthlyLimit > 0 && monthlyTotal+amount > limits.MonthlyLimit {
+		return fmt.Errorf("%w: %.2f remaining this month", ErrWithdrawalLimitExceeded, math.Max(limits.MonthlyLimit-monthlyTotal, 0))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-competition-go/internal/models"
+	"github.com/yourusername/health-competition-go/pkg/payments"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWithdrawalLimits(t *testing.T) {
+	limits := models.WithdrawalLimits{
+		MinAmount:    10,
+		DailyLimit:   500,
+		MonthlyLimit: 2000,
+	}
+
+	tests := []struct {
+		name         string
+		amount       float64
+		balance      float64
+		dailyTotal   float64
+		monthlyTotal float64
+		wantIs       error
+		wantErr      string
+	}{
+		{"valid", 100, 1000, 0, 0, nil, ""},
+		{"zero amount", 0, 1000, 0, 0, ErrInvalidWithdrawal, "invalid withdrawal: amount must be greater than 0"},
+		{"below minimum", 5, 1000, 0, 0, ErrInvalidWithdrawal, "invalid withdrawal: minimum withdrawal amount is 10.00"},
+		{"insufficient balance", 200, 150, 0, 0, ErrInsufficientBalance, "insufficient balance: available 150.00"},
+		{"daily limit", 200, 1000, 400, 400, ErrWithdrawalLimitExceeded, "withdrawal limit exceeded: 100.00 remaining today"},
+		{"daily limit exact", 100, 1000, 400, 400, nil, ""},
+		{"monthly limit", 200, 5000, 0, 1900, ErrWithdrawalLimitExceeded, "withdrawal limit exceeded: 100.00 remaining this month"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkWithdrawalLimits(tt.amount, tt.balance, tt.dailyTotal, tt.monthlyTotal, limits)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantIs)
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckWithdrawalLimits_NoLimits(t *testing.T) {
+	err := checkWithdrawalLimits(10000, 20000, 50000, 50000, models.WithdrawalLimits{})
+	assert.NoError(t, err)
+}
+
+func TestRequestWithdrawal_InvalidDestination(t *testing.T) {
+	service := NewWithdrawalService(nil, payments.NewFakePayoutProvider(), models.WithdrawalLimits{})
+	ctx := context.Background()
+
+	_, err := service.RequestWithdrawal(ctx, "user-1", &models.CreateWithdrawalRequest{Amount: 25, DestinationType: "cheque", Destination: "PO Box 1"})
+	assert.ErrorIs(t, err, ErrInvalidWithdrawal)
+
+	_, err = service.RequestWithdrawal(ctx, "user-1", &models.CreateWithdrawalRequest{Amount: 25, DestinationType: "paypal"})
+	assert.ErrorIs(t, err, ErrInvalidWithdrawal)
+}
+
+// withdrawalRow is a withdrawal as selected by withdrawalColumns
+func withdrawalRow(status string, reviewedAt interface{}) []driver.Value {
+	return []driver.Value{
+		"withdrawal-1", "user-1", 25.0, "paypal", "me@example.com", status, "transaction-1",
+		nil, nil, nil, time.Now().Add(-time.Hour), reviewedAt, nil,
+	}
+}
+
+func TestApproveWithdrawal_PayoutFails(t *testing.T) {
+	payouts := payments.NewFakePayoutProvider()
+	payouts.FailWith(errors.New("account closed"))
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "FOR UPDATE", rows: [][]driver.Value{withdrawalRow("pending", nil)}},
+		fakeQuery{match: "SET status = 'approved'", affected: 1},
+		fakeQuery{match: "SET status = 'failed'", affected: 1},
+		fakeQuery{match: "UPDATE public.transactions SET status = 'failed'", affected: 1},
+		fakeQuery{match: "INSERT INTO public.ledger_entries", affected: 1},
+	)
+	service := NewWithdrawalService(db, payouts, models.WithdrawalLimits{})
+
+	withdrawal, err := service.ApproveWithdrawal(context.Background(), "withdrawal-1", "admin-1", "")
+	assert.ErrorIs(t, err, ErrPayoutFailed)
+	require.NotNil(t, withdrawal)
+	assert.Equal(t, "failed", withdrawal.Status)
+
+	// The hold is released with the payout's failure
+	release := fake.args[len(fake.args)-1]
+	assert.Equal(t, 25.0, release[2])
+	assert.Equal(t, LedgerTypeWithdrawalRelease, release[3])
+}
+
+func TestApproveWithdrawal_NotPending(t *testing.T) {
+	db, _ := newFakeDB(t,
+		fakeQuery{match: "FOR UPDATE", rows: [][]driver.Value{withdrawalRow("paid", time.Now())}},
+	)
+	payouts := payments.NewFakePayoutProvider()
+	service := NewWithdrawalService(db, payouts, models.WithdrawalLimits{})
+
+	_, err := service.ApproveWithdrawal(context.Background(), "withdrawal-1", "admin-1", "")
+	assert.ErrorIs(t, err, ErrWithdrawalNotPending)
+	assert.Empty(t, payouts.Payouts())
+}
+
+func TestApproveWithdrawal_UnrecordedPayoutIsReconciled(t *testing.T) {
+	payouts := payments.NewFakePayoutProvider()
+	approvedAt := time.Now().Add(-time.Hour)
+
+	db, fake := newFakeDB(t,
+		fakeQuery{match: "FOR UPDATE", rows: [][]driver.Value{withdrawalRow("pending", nil)}},
+		fakeQuery{match: "SET status = 'approved'", affected: 1},
+		fakeQuery{match: "SET status = 'paid'", err: errors.New("connection reset")},
+
+		// The scheduler later finds the withdrawal still approved
+		fakeQuery{match: "WHERE status = 'approved' AND reviewed_at <= $1", rows: [][]driver.Value{withdrawalRow("approved", approvedAt)}},
+		fakeQuery{match: "SET status = 'paid'", affected: 1},
+		fakeQuery{match: "SET status = 'completed'", affected: 1},
+	)
+	service := NewWithdrawalService(db, payouts, models.WithdrawalLimits{})
+	ctx := context.Background()
+
+	_, err := service.ApproveWithdrawal(ctx, "withdrawal-1", "admin-1", "")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrPayoutFailed)
+	require.Len(t, payouts.Payouts(), 1)
+	reference := fake.args[2][0]
+
+	require.NoError(t, service.ReconcileWithdrawals(ctx, time.Now()))
+
+	// The payout isn't sent twice and its original reference is recorded
+	assert.Len(t, payouts.Payouts(), 1)
+	assert.Equal(t, reference, fake.args[4][0])
+}
+
+func TestReconcileWithdrawals_AlreadyRecorded(t *testing.T) {
+	payouts := payments.NewFakePayoutProvider()
+	db, _ := newFakeDB(t,
+		fakeQuery{match: "WHERE status = 'approved' AND reviewed_at <= $1", rows: [][]driver.Value{withdrawalRow("approved", time.Now().Add(-time.Hour))}},
+		// Another instance recorded it in the meantime
+		fakeQuery{match: "SET status = 'paid'"},
+	)
+	service := NewWithdrawalService(db, payouts, models.WithdrawalLimits{})
+
+	require.NoError(t, service.ReconcileWithdrawals(context.Background(), time.Now()))
+}
@@ -0,0 +1,92 @@
+// Package activityfile reads recorded workouts from the GPX, TCX and Garmin
+// FIT files that watches, bike computers and fitness apps export.
+package activityfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported activity file format")
+	ErrInvalidFile       = errors.New("invalid activity file")
+)
+
+// Supported formats
+const (
+	FormatGPX = "gpx"
+	FormatTCX = "tcx"
+	FormatFIT = "fit"
+)
+
+// Activity is the summary of one recorded workout
+type Activity struct {
+	Format    string
+	Sport     string // as named by the file, e.g. running or biking; may be empty
+	Name      string
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration // time spent moving or, when not recorded, elapsed
+	Distance  float64       // meters
+	Calories  float64       // kcal; zero when the file doesn't record them
+}
+
+// FormatOf returns the format of a file from its name's extension
+func FormatOf(filename string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	switch format {
+	case FormatGPX, FormatTCX, FormatFIT:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, filepath.Ext(filename))
+	}
+}
+
+// Parse reads an activity in format from r
+func Parse(format string, r io.Reader) (*Activity, error) {
+	var activity *Activity
+	var err error
+	switch format {
+	case FormatGPX:
+		activity, err = parseGPX(r)
+	case FormatTCX:
+		activity, err = parseTCX(r)
+	case FormatFIT:
+		activity, err = parseFIT(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	activity.Format = format
+	if activity.StartTime.IsZero() || activity.EndTime.Before(activity.StartTime) {
+		return nil, fmt.Errorf("%w: no usable timestamps", ErrInvalidFile)
+	}
+	if activity.Duration <= 0 {
+		activity.Duration = activity.EndTime.Sub(activity.StartTime)
+	}
+	if activity.Distance < 0 || activity.Calories < 0 {
+		return nil, fmt.Errorf("%w: negative totals", ErrInvalidFile)
+	}
+	return activity, nil
+}
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// haversine returns the distance in meters between two points given in degrees
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
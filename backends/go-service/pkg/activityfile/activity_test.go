@@ -0,0 +1,101 @@
+package activityfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFixture(t *testing.T, name string) (*Activity, error) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	format, err := FormatOf(name)
+	require.NoError(t, err)
+	return Parse(format, bytes.NewReader(data))
+}
+
+func TestParse(t *testing.T) {
+	t.Run("gpx", func(t *testing.T) {
+		activity, err := parseFixture(t, "run.gpx")
+		require.NoError(t, err)
+		assert.Equal(t, FormatGPX, activity.Format)
+		assert.Equal(t, "Morning Run", activity.Name)
+		assert.Equal(t, "running", activity.Sport)
+		assert.Equal(t, time.Date(2024, 6, 3, 6, 0, 0, 0, time.UTC), activity.StartTime)
+		assert.Equal(t, 5*time.Minute, activity.Duration)
+		assert.InDelta(t, 1112, activity.Distance, 1)
+		assert.Zero(t, activity.Calories)
+	})
+
+	t.Run("tcx", func(t *testing.T) {
+		activity, err := parseFixture(t, "ride.tcx")
+		require.NoError(t, err)
+		assert.Equal(t, "Biking", activity.Sport)
+		assert.Equal(t, "Evening Ride", activity.Name)
+		assert.Equal(t, time.Date(2024, 6, 4, 17, 30, 0, 0, time.UTC), activity.StartTime)
+		assert.Equal(t, time.Date(2024, 6, 4, 18, 25, 0, 0, time.UTC), activity.EndTime)
+		assert.Equal(t, 50*time.Minute, activity.Duration, "the break between laps isn't counted")
+		assert.Equal(t, 20500.0, activity.Distance)
+		assert.Equal(t, 530.0, activity.Calories)
+	})
+
+	t.Run("fit", func(t *testing.T) {
+		activity, err := parseFixture(t, "walk.fit")
+		require.NoError(t, err)
+		assert.Equal(t, "walking", activity.Sport)
+		assert.Equal(t, time.Date(2024, 6, 5, 12, 0, 0, 0, time.UTC), activity.StartTime)
+		assert.Equal(t, time.Date(2024, 6, 5, 12, 40, 0, 0, time.UTC), activity.EndTime)
+		assert.Equal(t, 38*time.Minute, activity.Duration)
+		assert.Equal(t, 3200.5, activity.Distance)
+		assert.Equal(t, 180.0, activity.Calories)
+	})
+}
+
+func TestParseFIT_Records(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "walk.fit"))
+	require.NoError(t, err)
+	messages, err := decodeFIT(data)
+	require.NoError(t, err)
+
+	var records []fitMessage
+	for _, m := range messages {
+		if m.global == fitMesgRecord {
+			records = append(records, m)
+		}
+	}
+	require.NotEmpty(t, records)
+	last := records[len(records)-1]
+	start := uint64(time.Date(2024, 6, 5, 12, 0, 0, 0, time.UTC).Unix() - fitEpoch)
+	assert.Equal(t, start+40*60+3, last.values[fitFieldTimestamp], "compressed timestamps count from the last full one")
+	assert.Equal(t, uint64(320050), last.values[fitRecordDistance])
+	_, hasHeartRate := last.values[3]
+	assert.False(t, hasHeartRate, "invalid values are dropped")
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := FormatOf("workout.csv")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = Parse(FormatGPX, strings.NewReader(`<gpx><trk><trkseg></trkseg></trk></gpx>`))
+	assert.ErrorIs(t, err, ErrInvalidFile, "a track without timestamps")
+
+	_, err = Parse(FormatTCX, strings.NewReader(`not xml`))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "walk.fit"))
+	require.NoError(t, err)
+	corrupt := append([]byte(nil), data...)
+	corrupt[40] ^= 0xFF
+	_, err = Parse(FormatFIT, bytes.NewReader(corrupt))
+	assert.ErrorIs(t, err, ErrInvalidFile, "a CRC mismatch")
+
+	_, err = Parse(FormatFIT, bytes.NewReader(data[:100]))
+	assert.ErrorIs(t, err, ErrInvalidFile, "a truncated file")
+}
@@ -0,0 +1,285 @@
+package activityfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// fitEpoch is the FIT timestamp origin, 1989-12-31T00:00:00Z, in Unix seconds
+const fitEpoch = 631065600
+
+// Global message numbers and field numbers of the FIT profile used here
+const (
+	fitMesgSession = 18
+	fitMesgRecord  = 20
+
+	fitFieldTimestamp = 253
+
+	fitSessionStartTime     = 2
+	fitSessionSport         = 5
+	fitSessionTotalElapsed  = 7
+	fitSessionTotalTimer    = 8
+	fitSessionTotalDistance = 9
+	fitSessionTotalCalories = 11
+
+	fitRecordDistance = 5
+)
+
+// fitSports names the FIT sport enum values that are common in exports
+var fitSports = map[uint64]string{
+	0:  "generic",
+	1:  "running",
+	2:  "cycling",
+	5:  "swimming",
+	10: "training",
+	11: "walking",
+	17: "hiking",
+}
+
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+func fitCRC(crc uint16, data []byte) uint16 {
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[b&0xF]
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+// fitField is a field of a definition message
+type fitField struct {
+	num  byte
+	size int
+}
+
+// fitDefinition describes the layout of the data messages of a local type
+type fitDefinition struct {
+	global   uint16
+	order    binary.ByteOrder
+	fields   []fitField
+	devBytes int // size of the developer fields, which are skipped
+}
+
+// fitMessage is a decoded data message: its valid unsigned field values
+type fitMessage struct {
+	global uint16
+	values map[byte]uint64
+}
+
+// parseFIT reads the session messages of a FIT activity file, summing them
+// for multisport activities. Files without sessions are summarised from their
+// record messages instead.
+func parseFIT(r io.Reader) (*Activity, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := decodeFIT(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	activity := &Activity{}
+	var timer time.Duration
+	var first, last time.Time
+	var recordDistance float64
+	sessions := 0
+	for _, m := range messages {
+		ts, hasTS := fitTime(m.values, fitFieldTimestamp)
+		switch m.global {
+		case fitMesgSession:
+			sessions++
+			start, ok := fitTime(m.values, fitSessionStartTime)
+			if !ok {
+				continue
+			}
+			end := start
+			if elapsed, ok := m.values[fitSessionTotalElapsed]; ok {
+				end = start.Add(time.Duration(elapsed) * time.Millisecond)
+			} else if hasTS {
+				end = ts
+			}
+			if activity.StartTime.IsZero() || start.Before(activity.StartTime) {
+				activity.StartTime = start
+			}
+			if end.After(activity.EndTime) {
+				activity.EndTime = end
+			}
+			if v, ok := m.values[fitSessionTotalTimer]; ok {
+				timer += time.Duration(v) * time.Millisecond
+			}
+			if v, ok := m.values[fitSessionTotalDistance]; ok {
+				activity.Distance += float64(v) / 100
+			}
+			if v, ok := m.values[fitSessionTotalCalories]; ok {
+				activity.Calories += float64(v)
+			}
+			if activity.Sport == "" {
+				activity.Sport = fitSports[m.values[fitSessionSport]]
+			}
+		case fitMesgRecord:
+			if hasTS {
+				if first.IsZero() || ts.Before(first) {
+					first = ts
+				}
+				if ts.After(last) {
+					last = ts
+				}
+			}
+			// Record distance is the running total
+			if v, ok := m.values[fitRecordDistance]; ok && float64(v)/100 > recordDistance {
+				recordDistance = float64(v) / 100
+			}
+		}
+	}
+
+	if sessions == 0 {
+		activity.StartTime, activity.EndTime = first, last
+		activity.Distance = recordDistance
+	}
+	activity.Duration = timer
+	return activity, nil
+}
+
+// decodeFIT checks a FIT file's header and CRC and decodes its data messages
+func decodeFIT(data []byte) ([]fitMessage, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("file too short")
+	}
+	headerSize := int(data[0])
+	if (headerSize != 12 && headerSize != 14) || len(data) < headerSize || !bytes.Equal(data[8:12], []byte(".FIT")) {
+		return nil, fmt.Errorf("not a FIT file")
+	}
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if end+2 > len(data) {
+		return nil, fmt.Errorf("file is truncated")
+	}
+	if fitCRC(0, data[:end+2]) != 0 {
+		return nil, fmt.Errorf("CRC mismatch")
+	}
+
+	definitions := map[byte]*fitDefinition{}
+	var messages []fitMessage
+	var lastTimestamp uint32
+	pos := headerSize
+	need := func(n int) error {
+		if pos+n > end {
+			return fmt.Errorf("message at offset %d runs past the data", pos)
+		}
+		return nil
+	}
+	for pos < end {
+		header := data[pos]
+		pos++
+
+		var local byte
+		var compressedTS bool
+		var offset uint32
+		switch {
+		case header&0x80 != 0:
+			compressedTS = true
+			local = (header >> 5) & 0x03
+			offset = uint32(header & 0x1F)
+		case header&0x40 != 0:
+			if err := need(5); err != nil {
+				return nil, err
+			}
+			def := &fitDefinition{order: binary.LittleEndian}
+			if data[pos+1] == 1 {
+				def.order = binary.BigEndian
+			}
+			def.global = def.order.Uint16(data[pos+2 : pos+4])
+			n := int(data[pos+4])
+			pos += 5
+			if err := need(3 * n); err != nil {
+				return nil, err
+			}
+			for i := 0; i < n; i++ {
+				def.fields = append(def.fields, fitField{num: data[pos], size: int(data[pos+1])})
+				pos += 3
+			}
+			if header&0x20 != 0 {
+				if err := need(1); err != nil {
+					return nil, err
+				}
+				n := int(data[pos])
+				pos++
+				if err := need(3 * n); err != nil {
+					return nil, err
+				}
+				for i := 0; i < n; i++ {
+					def.devBytes += int(data[pos+1])
+					pos += 3
+				}
+			}
+			definitions[header&0x0F] = def
+			continue
+		default:
+			local = header & 0x0F
+		}
+
+		def, ok := definitions[local]
+		if !ok {
+			return nil, fmt.Errorf("data message at offset %d has no definition", pos-1)
+		}
+		m := fitMessage{global: def.global, values: map[byte]uint64{}}
+		for _, f := range def.fields {
+			if err := need(f.size); err != nil {
+				return nil, err
+			}
+			if v, ok := fitUint(data[pos:pos+f.size], def.order); ok {
+				m.values[f.num] = v
+			}
+			pos += f.size
+		}
+		if err := need(def.devBytes); err != nil {
+			return nil, err
+		}
+		pos += def.devBytes
+
+		if ts, ok := m.values[fitFieldTimestamp]; ok {
+			lastTimestamp = uint32(ts)
+		} else if compressedTS {
+			lastTimestamp += (offset - lastTimestamp&0x1F) & 0x1F
+			m.values[fitFieldTimestamp] = uint64(lastTimestamp)
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// fitUint decodes an unsigned field value, reporting false for the FIT
+// invalid value (all bits set) and for sizes that aren't plain integers
+func fitUint(b []byte, order binary.ByteOrder) (uint64, bool) {
+	var v, invalid uint64
+	switch len(b) {
+	case 1:
+		v, invalid = uint64(b[0]), 0xFF
+	case 2:
+		v, invalid = uint64(order.Uint16(b)), 0xFFFF
+	case 4:
+		v, invalid = uint64(order.Uint32(b)), 0xFFFFFFFF
+	default:
+		return 0, false
+	}
+	return v, v != invalid
+}
+
+func fitTime(values map[byte]uint64, field byte) (time.Time, bool) {
+	v, ok := values[field]
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v)+fitEpoch, 0).UTC(), true
+}
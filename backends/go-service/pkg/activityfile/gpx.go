@@ -0,0 +1,63 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type gpxFile struct {
+	Tracks []gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Type     string       `xml:"type"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat  float64   `xml:"lat,attr"`
+	Lon  float64   `xml:"lon,attr"`
+	Time time.Time `xml:"time"`
+}
+
+// parseGPX sums the distance between the track points of every segment. GPX
+// has no notion of calories, and the duration is the time elapsed between the
+// first and last timestamped points.
+func parseGPX(r io.Reader) (*Activity, error) {
+	var file gpxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(file.Tracks) == 0 {
+		return nil, fmt.Errorf("%w: no tracks", ErrInvalidFile)
+	}
+
+	activity := &Activity{Name: file.Tracks[0].Name, Sport: file.Tracks[0].Type}
+	for _, track := range file.Tracks {
+		for _, segment := range track.Segments {
+			for i, p := range segment.Points {
+				if i > 0 {
+					prev := segment.Points[i-1]
+					activity.Distance += haversine(prev.Lat, prev.Lon, p.Lat, p.Lon)
+				}
+				if p.Time.IsZero() {
+					continue
+				}
+				if activity.StartTime.IsZero() || p.Time.Before(activity.StartTime) {
+					activity.StartTime = p.Time
+				}
+				if p.Time.After(activity.EndTime) {
+					activity.EndTime = p.Time
+				}
+			}
+		}
+	}
+	return activity, nil
+}
@@ -0,0 +1,67 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type tcxFile struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	Notes string   `xml:"Notes"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        time.Time  `xml:"StartTime,attr"`
+	TotalTimeSeconds float64    `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64    `xml:"DistanceMeters"`
+	Calories         float64    `xml:"Calories"`
+	Trackpoints      []tcxPoint `xml:"Track>Trackpoint"`
+}
+
+type tcxPoint struct {
+	Time time.Time `xml:"Time"`
+}
+
+// parseTCX sums the laps of the file's first activity. The duration is the
+// laps' timer time, which leaves out pauses; the end time is the last
+// trackpoint, or the last lap's start plus its time when there are none.
+func parseTCX(r io.Reader) (*Activity, error) {
+	var file tcxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(file.Activities) == 0 || len(file.Activities[0].Laps) == 0 {
+		return nil, fmt.Errorf("%w: no laps", ErrInvalidFile)
+	}
+
+	tcx := file.Activities[0]
+	activity := &Activity{Sport: tcx.Sport, Name: tcx.Notes}
+	var seconds float64
+	for _, lap := range tcx.Laps {
+		seconds += lap.TotalTimeSeconds
+		activity.Distance += lap.DistanceMeters
+		activity.Calories += lap.Calories
+
+		if !lap.StartTime.IsZero() && (activity.StartTime.IsZero() || lap.StartTime.Before(activity.StartTime)) {
+			activity.StartTime = lap.StartTime
+		}
+		end := lap.StartTime.Add(time.Duration(lap.TotalTimeSeconds * float64(time.Second)))
+		for _, p := range lap.Trackpoints {
+			if p.Time.After(end) {
+				end = p.Time
+			}
+		}
+		if end.After(activity.EndTime) {
+			activity.EndTime = end
+		}
+	}
+	activity.Duration = time.Duration(seconds * float64(time.Second))
+	return activity, nil
+}
@@ -0,0 +1,196 @@
+// Package applehealth reads the export.xml that the Apple Health app writes
+// into its export.zip. Exports run to hundreds of megabytes, so they are
+// streamed and only daily totals are kept.
+package applehealth
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidExport = errors.New("invalid Apple Health export")
+
+// dateLayout is the format of export.xml dates, e.g. 2024-06-03 08:15:00 +0100
+const dateLayout = "2006-01-02 15:04:05 -0700"
+
+// Metrics collected from quantity records
+const (
+	metricSteps = iota
+	metricDistance
+	metricEnergy
+	metricExercise
+	metricCount
+)
+
+// recordMetrics maps the quantity types that are read to their metric and
+// the conversion of their units to steps, meters, kcal and minutes. Records
+// of other types, or in other units, are skipped.
+var recordMetrics = map[string]struct {
+	metric int
+	units  map[string]float64
+}{
+	"HKQuantityTypeIdentifierStepCount":              {metricSteps, map[string]float64{"count": 1}},
+	"HKQuantityTypeIdentifierDistanceWalkingRunning": {metricDistance, distanceUnits},
+	"HKQuantityTypeIdentifierActiveEnergyBurned":     {metricEnergy, energyUnits},
+	"HKQuantityTypeIdentifierAppleExerciseTime":      {metricExercise, timeUnits},
+}
+
+// Day is the activity of one day. Health keeps overlapping samples from each
+// device, so each total is the one of the device that recorded the most.
+type Day struct {
+	Date          time.Time // midnight in the location passed to Read
+	Steps         int64
+	Distance      float64 // meters
+	Calories      float64 // active energy, kcal
+	ActiveMinutes int     // exercise time, or workout time when that is higher
+	Workouts      int
+}
+
+// dayTotals collects a day's samples per metric and device before they are
+// combined
+type dayTotals struct {
+	metrics        [metricCount]map[string]float64
+	workouts       int
+	workoutMinutes float64
+}
+
+// Read streams an export.xml and returns the days with any activity in
+// order. Samples count towards the day they start on in loc.
+func Read(r io.Reader, loc *time.Location) ([]Day, error) {
+	dec := xml.NewDecoder(r)
+	days := map[time.Time]*dayTotals{}
+	dayOf := func(start time.Time) *dayTotals {
+		y, m, d := start.In(loc).Date()
+		key := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		t, ok := days[key]
+		if !ok {
+			t = &dayTotals{}
+			for i := range t.metrics {
+				t.metrics[i] = map[string]float64{}
+			}
+			days[key] = t
+		}
+		return t
+	}
+
+	sawRoot := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch el.Name.Local {
+		case "HealthData":
+			sawRoot = true
+		case "Record":
+			attrs := attrMap(el.Attr)
+			kind, ok := recordMetrics[attrs["type"]]
+			if !ok {
+				continue
+			}
+			scale, ok := kind.units[attrs["unit"]]
+			if !ok {
+				continue
+			}
+			start, err := time.Parse(dateLayout, attrs["startDate"])
+			if err != nil {
+				continue
+			}
+			value, err := strconv.ParseFloat(attrs["value"], 64)
+			if err != nil || value < 0 {
+				continue
+			}
+			dayOf(start).metrics[kind.metric][attrs["sourceName"]] += value * scale
+		case "Workout":
+			attrs := attrMap(el.Attr)
+			start, err := time.Parse(dateLayout, attrs["startDate"])
+			if err != nil {
+				continue
+			}
+			totals := dayOf(start)
+			totals.workouts++
+			if d, err := strconv.ParseFloat(attrs["duration"], 64); err == nil && d > 0 {
+				if scale, ok := timeUnits[attrs["durationUnit"]]; ok {
+					totals.workoutMinutes += d * scale
+				}
+			}
+		}
+	}
+	if !sawRoot {
+		return nil, fmt.Errorf("%w: no HealthData element", ErrInvalidExport)
+	}
+
+	result := make([]Day, 0, len(days))
+	for date, t := range days {
+		day := Day{
+			Date:          date,
+			Steps:         int64(math.Round(maxValue(t.metrics[metricSteps]))),
+			Distance:      maxValue(t.metrics[metricDistance]),
+			Calories:      maxValue(t.metrics[metricEnergy]),
+			ActiveMinutes: int(math.Round(math.Max(maxValue(t.metrics[metricExercise]), t.workoutMinutes))),
+			Workouts:      t.workouts,
+		}
+		result = append(result, day)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date.Before(result[j].Date) })
+	return result, nil
+}
+
+// ReadZip reads the export.xml inside an export.zip. The archive is read in
+// place, so it can be a file on disk of any size.
+func ReadZip(r io.ReaderAt, size int64, loc *time.Location) ([]Day, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	for _, f := range archive.File {
+		if path.Base(f.Name) != "export.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		defer rc.Close()
+		return Read(rc, loc)
+	}
+	return nil, fmt.Errorf("%w: archive has no export.xml", ErrInvalidExport)
+}
+
+// Unit conversions to meters, kcal and minutes
+var (
+	distanceUnits = map[string]float64{"m": 1, "km": 1000, "cm": 0.01, "mi": 1609.344, "ft": 0.3048, "yd": 0.9144}
+	energyUnits   = map[string]float64{"kcal": 1, "Cal": 1, "cal": 0.001, "kJ": 1 / 4.184, "J": 1 / 4184.0}
+	timeUnits     = map[string]float64{"min": 1, "s": 1.0 / 60, "hr": 60, "h": 60}
+)
+
+func attrMap(attrs []xml.Attr) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		m[a.Name.Local] = a.Value
+	}
+	return m
+}
+
+func maxValue(perSource map[string]float64) float64 {
+	var max float64
+	for _, v := range perSource {
+		max = math.Max(max, v)
+	}
+	return max
+}
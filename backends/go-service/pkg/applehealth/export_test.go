@@ -0,0 +1,80 @@
+package applehealth
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "export.xml"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	days, err := Read(f, london)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+
+	first := days[0]
+	assert.Equal(t, time.Date(2024, 6, 3, 0, 0, 0, 0, london), first.Date)
+	assert.Equal(t, int64(5000), first.Steps, "the phone counted more than the watch")
+	assert.Equal(t, 3500.0, first.Distance)
+	assert.InDelta(t, 200, first.Calories, 0.001)
+	assert.Equal(t, 30, first.ActiveMinutes)
+
+	second := days[1]
+	assert.Equal(t, int64(10000), second.Steps)
+	assert.InDelta(t, 8046.72, second.Distance, 0.001)
+	assert.Equal(t, 40, second.ActiveMinutes, "workout time without exercise time")
+	assert.Equal(t, 1, second.Workouts)
+
+	// In UTC the steps just after midnight belong to the day before
+	_, err = f.Seek(0, 0)
+	require.NoError(t, err)
+	days, err = Read(f, time.UTC)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, int64(5500), days[0].Steps)
+}
+
+func TestReadZip(t *testing.T) {
+	export, err := os.ReadFile(filepath.Join("testdata", "export.xml"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"apple_health_export/export_cda.xml": []byte("<ClinicalDocument/>"),
+		"apple_health_export/export.xml":     export,
+	} {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+
+	days, err := ReadZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()), time.UTC)
+	require.NoError(t, err)
+	assert.Len(t, days, 2)
+
+	_, err = ReadZip(strings.NewReader("not a zip"), 9, time.UTC)
+	assert.ErrorIs(t, err, ErrInvalidExport)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	_, err := Read(strings.NewReader(`<HealthData><Record`), time.UTC)
+	assert.ErrorIs(t, err, ErrInvalidExport)
+
+	_, err = Read(strings.NewReader(`<gpx></gpx>`), time.UTC)
+	assert.ErrorIs(t, err, ErrInvalidExport)
+}
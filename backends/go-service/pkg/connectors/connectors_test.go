@@ -0,0 +1,199 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProvider points the provider called name at a test server that
+// serves api, accepting only the access token "access-1"
+func newTestProvider(t *testing.T, name string, api http.HandlerFunc) (Provider, *httptest.Server) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		clientID := r.PostForm.Get("client_id")
+		if user, _, ok := r.BasicAuth(); ok {
+			clientID = user
+		}
+		if clientID != "client" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		switch {
+		case r.PostForm.Get("grant_type") == "authorization_code" && r.PostForm.Get("code") == "code-1":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "access-1", "refresh_token": "refresh-1", "expires_in": 3600, "scope": "activity",
+			})
+		case r.PostForm.Get("grant_type") == "refresh_token" && r.PostForm.Get("refresh_token") == "refresh-1":
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-1", "expires_in": 3600})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		}
+	})
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		api(w, r)
+	})
+	server := httptest.NewServer(mux)
+
+	provider, err := New(name, Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.example.com/connect",
+		AuthURL:      server.URL + "/authorize",
+		TokenURL:     server.URL + "/token",
+		APIURL:       server.URL + "/api",
+	})
+	require.NoError(t, err)
+	return provider, server
+}
+
+func TestOAuth(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			provider, server := newTestProvider(t, name, func(w http.ResponseWriter, r *http.Request) {})
+			defer server.Close()
+			ctx := context.Background()
+
+			authURL, err := url.Parse(provider.AuthURL("state-1"))
+			require.NoError(t, err)
+			assert.Equal(t, "state-1", authURL.Query().Get("state"))
+			assert.Equal(t, "client", authURL.Query().Get("client_id"))
+			assert.Equal(t, "https://app.example.com/connect", authURL.Query().Get("redirect_uri"))
+
+			token, err := provider.Exchange(ctx, "code-1")
+			require.NoError(t, err)
+			assert.Equal(t, "access-1", token.AccessToken)
+			assert.Equal(t, "refresh-1", token.RefreshToken)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+
+			token, err = provider.Refresh(ctx, "refresh-1")
+			require.NoError(t, err)
+			assert.Equal(t, "refresh-1", token.RefreshToken, "kept when not rotated")
+
+			_, err = provider.Refresh(ctx, "revoked")
+			assert.ErrorIs(t, err, ErrUnauthorized)
+		})
+	}
+
+	_, err := New("garmin", Config{})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestGoogleFit_FetchDaily(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	first := time.Date(2024, 6, 3, 0, 0, 0, 0, loc)
+
+	provider, server := newTestProvider(t, "google_fit", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/users/me/dataset:aggregate", r.URL.Path)
+		var body struct {
+			BucketByTime struct {
+				Period struct {
+					TimeZoneID string `json:"timeZoneId"`
+				} `json:"period"`
+			} `json:"bucketByTime"`
+			StartTimeMillis int64 `json:"startTimeMillis"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "America/New_York", body.BucketByTime.Period.TimeZoneID)
+		assert.Equal(t, first.UnixMilli(), body.StartTimeMillis)
+
+		fmt.Fprintf(w, `{"bucket": [
+			{"startTimeMillis": "%d", "dataset": [
+				{"point": [{"value": [{"intVal": 6000}]}, {"value": [{"intVal": 1500}]}]},
+				{"point": [{"value": [{"fpVal": 5400.5}]}]},
+				{"point": [{"value": [{"fpVal": 2100.25}]}]},
+				{"point": [{"value": [{"intVal": 45}]}]}
+			]},
+			{"startTimeMillis": "%d", "dataset": [{"point": []}, {"point": []}, {"point": []}, {"point": []}]}
+		]}`, first.UnixMilli(), first.AddDate(0, 0, 1).UnixMilli())
+	})
+	defer server.Close()
+
+	days, err := provider.FetchDaily(context.Background(), "access-1", first, first.AddDate(0, 0, 1), loc)
+	require.NoError(t, err)
+	require.Len(t, days, 1, "days without data are left out")
+	assert.Equal(t, DailyTotals{Date: first, Steps: 7500, Distance: 5400.5, Calories: 2100.25, ActiveMinutes: 45}, days[0])
+
+	_, err = provider.FetchDaily(context.Background(), "expired", first, first, loc)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestFitbit_FetchDaily(t *testing.T) {
+	provider, server := newTestProvider(t, "fitbit", func(w http.ResponseWriter, r *http.Request) {
+		series := map[string]string{
+			"steps":               `[{"dateTime": "2024-06-03", "value": "8200"}, {"dateTime": "2024-06-04", "value": "0"}]`,
+			"distance":            `[{"dateTime": "2024-06-03", "value": "6.15"}, {"dateTime": "2024-06-04", "value": "0"}]`,
+			"activityCalories":    `[{"dateTime": "2024-06-03", "value": "410"}, {"dateTime": "2024-06-04", "value": "0"}]`,
+			"minutesFairlyActive": `[{"dateTime": "2024-06-03", "value": "12"}, {"dateTime": "2024-06-04", "value": "0"}]`,
+			"minutesVeryActive":   `[{"dateTime": "2024-06-03", "value": "20"}, {"dateTime": "2024-06-04", "value": "0"}]`,
+		}
+		for resource, values := range series {
+			if r.URL.Path == "/api/1/user/-/activities/"+resource+"/date/2024-06-03/2024-06-04.json" {
+				fmt.Fprintf(w, `{"activities-%s": %s}`, resource, values)
+				return
+			}
+		}
+		t.Errorf("unexpected request %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+
+	first := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	days, err := provider.FetchDaily(context.Background(), "access-1", first, first.AddDate(0, 0, 1), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, days, 1)
+	assert.Equal(t, int64(8200), days[0].Steps)
+	assert.InDelta(t, 6150, days[0].Distance, 0.001)
+	assert.Equal(t, 410.0, days[0].Calories)
+	assert.Equal(t, 32, days[0].ActiveMinutes)
+}
+
+func TestStrava_FetchDaily(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	first := time.Date(2024, 6, 3, 0, 0, 0, 0, loc)
+
+	// One full page and a partial one
+	var activities []map[string]interface{}
+	for i := 0; i < stravaPageSize; i++ {
+		activities = append(activities, map[string]interface{}{
+			"start_date": "2024-06-03T05:00:00Z", "distance": 100.0, "moving_time": 30,
+		})
+	}
+	provider, server := newTestProvider(t, "strava", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/athlete/activities", r.URL.Path)
+		assert.Equal(t, fmt.Sprint(first.Unix()), r.URL.Query().Get("after"))
+		switch r.URL.Query().Get("page") {
+		case "1":
+			json.NewEncoder(w).Encode(activities)
+		default:
+			// 23:30 UTC on June 3 is already June 4 in Berlin
+			fmt.Fprint(w, `[{"start_date": "2024-06-03T23:30:00Z", "distance": 42195, "moving_time": 12600, "kilojoules": 0},
+				{"start_date": "2024-06-04T16:00:00Z", "distance": 30000, "moving_time": 3600, "kilojoules": 850}]`)
+		}
+	})
+	defer server.Close()
+
+	days, err := provider.FetchDaily(context.Background(), "access-1", first, first.AddDate(0, 0, 1), loc)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, DailyTotals{Date: first, Distance: 20000, ActiveMinutes: 100}, days[0])
+	assert.Equal(t, DailyTotals{Date: first.AddDate(0, 0, 1), Distance: 72195, Calories: 850, ActiveMinutes: 270}, days[1])
+}
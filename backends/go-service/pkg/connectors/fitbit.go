@@ -0,0 +1,80 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// fitbit reads daily activity time series from the Fitbit Web API. Fitbit
+// splits days in the time zone of the user's Fitbit profile, not loc.
+type fitbit struct {
+	oauthClient
+}
+
+func newFitbit(cfg Config) *fitbit {
+	cfg.AuthURL = orDefault(cfg.AuthURL, "https://www.fitbit.com/oauth2/authorize")
+	cfg.TokenURL = orDefault(cfg.TokenURL, "https://api.fitbit.com/oauth2/token")
+	cfg.APIURL = orDefault(cfg.APIURL, "https://api.fitbit.com")
+	return &fitbit{oauthClient{cfg: cfg, scopes: "activity", basicAuth: true}}
+}
+
+func (p *fitbit) Name() string {
+	return "fitbit"
+}
+
+// fitbitResources are the time series fetched, one request each
+var fitbitResources = []string{"steps", "distance", "activityCalories", "minutesFairlyActive", "minutesVeryActive"}
+
+// FetchDaily fetches each resource's series for the range. Distances come in
+// kilometers, as no unit system is asked for.
+func (p *fitbit) FetchDaily(ctx context.Context, accessToken string, first, last time.Time, loc *time.Location) ([]DailyTotals, error) {
+	byDate := map[string]*DailyTotals{}
+	for _, resource := range fitbitResources {
+		url := fmt.Sprintf("%s/1/user/-/activities/%s/date/%s/%s.json",
+			p.cfg.APIURL, resource, first.Format("2006-01-02"), last.Format("2006-01-02"))
+		var resp map[string][]struct {
+			DateTime string `json:"dateTime"`
+			Value    string `json:"value"`
+		}
+		if err := apiRequest(ctx, p.cfg.HTTPClient, http.MethodGet, url, accessToken, nil, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, point := range resp["activities-"+resource] {
+			value, err := strconv.ParseFloat(point.Value, 64)
+			if err != nil || value == 0 {
+				continue
+			}
+			date, err := time.ParseInLocation("2006-01-02", point.DateTime, loc)
+			if err != nil {
+				continue
+			}
+			day, ok := byDate[point.DateTime]
+			if !ok {
+				day = &DailyTotals{Date: date}
+				byDate[point.DateTime] = day
+			}
+			switch resource {
+			case "steps":
+				day.Steps = int64(value)
+			case "distance":
+				day.Distance = value * 1000
+			case "activityCalories":
+				day.Calories = value
+			default:
+				day.ActiveMinutes += int(math.Round(value))
+			}
+		}
+	}
+
+	days := make([]DailyTotals, 0, len(byDate))
+	for _, day := range byDate {
+		days = append(days, *day)
+	}
+	sortDays(days)
+	return days, nil
+}
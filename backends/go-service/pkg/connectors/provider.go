@@ -0,0 +1,237 @@
+// Package connectors pulls daily activity from fitness providers' REST APIs
+// on behalf of users who authorized access with OAuth 2.0.
+package connectors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown fitness provider")
+
+	// ErrUnauthorized means the provider rejected the user's tokens, so they
+	// have to authorize again
+	ErrUnauthorized = errors.New("provider authorization rejected")
+)
+
+// Token is a user's OAuth token for a provider
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	Scope        string
+}
+
+// DailyTotals is a day of activity as reported by a provider
+type DailyTotals struct {
+	Date          time.Time // midnight in the location asked for
+	Steps         int64
+	Distance      float64 // meters
+	Calories      float64 // kcal
+	ActiveMinutes int
+}
+
+// Provider is a fitness provider's OAuth flow and activity API.
+// Implementations must be safe for concurrent use.
+type Provider interface {
+	Name() string
+
+	// AuthURL is where users are sent to grant access; state comes back
+	// with the authorization code
+	AuthURL(state string) string
+	Exchange(ctx context.Context, code string) (*Token, error)
+	Refresh(ctx context.Context, refreshToken string) (*Token, error)
+
+	// FetchDaily returns the days from first to last, both midnight in loc,
+	// that have any activity
+	FetchDaily(ctx context.Context, accessToken string, first, last time.Time, loc *time.Location) ([]DailyTotals, error)
+}
+
+// Config holds a provider's client credentials. The URLs default to the
+// provider's own and are only set to point the provider elsewhere, e.g. at a
+// test server.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	AuthURL    string
+	TokenURL   string
+	APIURL     string
+	HTTPClient *http.Client
+}
+
+// New returns the provider configured by name
+func New(name string, cfg Config) (Provider, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	switch name {
+	case "google_fit":
+		return newGoogleFit(cfg), nil
+	case "fitbit":
+		return newFitbit(cfg), nil
+	case "strava":
+		return newStrava(cfg), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+}
+
+// Names lists the providers New knows
+func Names() []string {
+	return []string{"google_fit", "fitbit", "strava"}
+}
+
+// oauthClient implements the authorization code and refresh token grants
+// shared by the providers
+type oauthClient struct {
+	cfg    Config
+	scopes string
+	extra  url.Values // added to the authorization URL
+
+	// basicAuth sends the client credentials in an Authorization header
+	// rather than the form
+	basicAuth bool
+}
+
+func (c *oauthClient) AuthURL(state string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.cfg.ClientID},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"scope":         {c.scopes},
+		"state":         {state},
+	}
+	for k, v := range c.extra {
+		q[k] = v
+	}
+	return c.cfg.AuthURL + "?" + q.Encode()
+}
+
+func (c *oauthClient) Exchange(ctx context.Context, code string) (*Token, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.cfg.RedirectURL},
+	})
+}
+
+// Refresh gets a new access token. Providers that don't rotate refresh tokens
+// leave it out of the response, and the one passed in stays valid.
+func (c *oauthClient) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	token, err := c.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func (c *oauthClient) token(ctx context.Context, form url.Values) (*Token, error) {
+	if !c.basicAuth {
+		form.Set("client_id", c.cfg.ClientID)
+		form.Set("client_secret", c.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.basicAuth {
+		req.SetBasicAuth(c.cfg.ClientID, c.cfg.ClientSecret)
+	}
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		Scope        string `json:"scope"`
+		Error        string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	switch {
+	case body.Error == "invalid_grant" || resp.StatusCode == http.StatusUnauthorized:
+		return nil, fmt.Errorf("%w: %s", ErrUnauthorized, body.Error)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, body.Error)
+	case body.AccessToken == "":
+		return nil, errors.New("token response has no access token")
+	}
+
+	token := &Token{AccessToken: body.AccessToken, RefreshToken: body.RefreshToken, Scope: body.Scope}
+	if body.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// apiRequest calls a provider API with a bearer token and decodes the JSON
+// response into out. body, when not nil, is sent as JSON.
+func apiRequest(ctx context.Context, client *http.Client, method, url, accessToken string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// stravaPageSize is the most activities Strava returns per page
+const stravaPageSize = 200
+
+// strava sums the activities a Strava athlete recorded each day. Strava has
+// no step counts, and only reports energy for rides, in kilojoules, which it
+// treats as equal to kcal burned.
+type strava struct {
+	oauthClient
+}
+
+func newStrava(cfg Config) *strava {
+	cfg.AuthURL = orDefault(cfg.AuthURL, "https://www.strava.com/oauth/authorize")
+	cfg.TokenURL = orDefault(cfg.TokenURL, "https://www.strava.com/oauth/token")
+	cfg.APIURL = orDefault(cfg.APIURL, "https://www.strava.com/api/v3")
+	return &strava{oauthClient{
+		cfg:    cfg,
+		scopes: "activity:read_all",
+		extra:  url.Values{"approval_prompt": {"auto"}},
+	}}
+}
+
+func (p *strava) Name() string {
+	return "strava"
+}
+
+type stravaActivity struct {
+	StartDate  time.Time `json:"start_date"`
+	Distance   float64   `json:"distance"`
+	MovingTime int       `json:"moving_time"`
+	Kilojoules float64   `json:"kilojoules"`
+}
+
+// FetchDaily pages through the athlete's activities in the range and adds
+// each to the day it started on in loc
+func (p *strava) FetchDaily(ctx context.Context, accessToken string, first, last time.Time, loc *time.Location) ([]DailyTotals, error) {
+	byDate := map[time.Time]*DailyTotals{}
+	movingTime := map[time.Time]int{}
+	for page := 1; ; page++ {
+		q := url.Values{
+			"after":    {fmt.Sprint(first.Unix())},
+			"before":   {fmt.Sprint(last.AddDate(0, 0, 1).Unix())},
+			"page":     {fmt.Sprint(page)},
+			"per_page": {fmt.Sprint(stravaPageSize)},
+		}
+		var activities []stravaActivity
+		if err := apiRequest(ctx, p.cfg.HTTPClient, http.MethodGet, p.cfg.APIURL+"/athlete/activities?"+q.Encode(), accessToken, nil, &activities); err != nil {
+			return nil, err
+		}
+
+		for _, a := range activities {
+			y, m, d := a.StartDate.In(loc).Date()
+			date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+			day, ok := byDate[date]
+			if !ok {
+				day = &DailyTotals{Date: date}
+				byDate[date] = day
+			}
+			day.Distance += a.Distance
+			day.Calories += a.Kilojoules
+			movingTime[date] += a.MovingTime
+		}
+		if len(activities) < stravaPageSize {
+			break
+		}
+	}
+
+	days := make([]DailyTotals, 0, len(byDate))
+	for date, day := range byDate {
+		day.ActiveMinutes = (movingTime[date] + 30) / 60
+		days = append(days, *day)
+	}
+	sortDays(days)
+	return days, nil
+}
+
+func sortDays(days []DailyTotals) {
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+}
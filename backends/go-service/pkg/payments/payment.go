@@ -0,0 +1,129 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChargeRequest describes a payment collected from a user
+type ChargeRequest struct {
+	TransactionID string
+	UserID        string
+	CompetitionID string
+	Amount        float64
+	Description   string
+}
+
+// ChargeResult represents a confirmed payment
+type ChargeResult struct {
+	Reference   string
+	Amount      float64
+	Provider    string
+	CompletedAt time.Time
+}
+
+// RefundResult represents a confirmed refund of an earlier charge
+type RefundResult struct {
+	Reference   string
+	Provider    string
+	CompletedAt time.Time
+}
+
+// PaymentProvider collects payments from users and refunds them
+// Implementations must be safe for concurrent use
+type PaymentProvider interface {
+	Name() string
+	Charge(ctx context.Context, req *ChargeRequest) (*ChargeResult, error)
+	Refund(ctx context.Context, chargeRef string, amount float64) (*RefundResult, error)
+}
+
+// NewPaymentProvider returns the payment provider configured by name
+func NewPaymentProvider(name string) (PaymentProvider, error) {
+	switch name {
+	case "", "fake":
+		return NewFakePaymentProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", name)
+	}
+}
+
+// FakePaymentProvider confirms every charge in memory without moving any money.
+// It is used for local development and tests.
+type FakePaymentProvider struct {
+	mu      sync.Mutex
+	charges map[string]ChargeRequest
+	refunds map[string]float64
+	failErr error
+}
+
+// NewFakePaymentProvider creates a new in-memory payment provider
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{
+		charges: make(map[string]ChargeRequest),
+		refunds: make(map[string]float64),
+	}
+}
+
+// Name returns the provider name stored on transactions
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+// Charge records the charge and returns a generated reference
+func (p *FakePaymentProvider) Charge(ctx context.Context, req *ChargeRequest) (*ChargeResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failErr != nil {
+		return nil, p.failErr
+	}
+
+	ref := "fake_charge_" + uuid.New().String()
+	p.charges[ref] = *req
+
+	return &ChargeResult{
+		Reference:   ref,
+		Amount:      req.Amount,
+		Provider:    p.Name(),
+		CompletedAt: time.Now(),
+	}, nil
+}
+
+// Refund refunds part or all of an earlier charge
+func (p *FakePaymentProvider) Refund(ctx context.Context, chargeRef string, amount float64) (*RefundResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, ok := p.charges[chargeRef]
+	if !ok {
+		return nil, fmt.Errorf("charge not found: %s", chargeRef)
+	}
+	if p.refunds[chargeRef]+amount > charge.Amount {
+		return nil, fmt.Errorf("refund exceeds charged amount")
+	}
+	p.refunds[chargeRef] += amount
+
+	return &RefundResult{
+		Reference:   "fake_refund_" + uuid.New().String(),
+		Provider:    p.Name(),
+		CompletedAt: time.Now(),
+	}, nil
+}
+
+// FailWith makes subsequent charges fail with err (nil restores success)
+func (p *FakePaymentProvider) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failErr = err
+}
+
+// Refunded returns the total amount refunded against a charge
+func (p *FakePaymentProvider) Refunded(chargeRef string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refunds[chargeRef]
+}
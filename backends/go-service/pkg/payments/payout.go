@@ -0,0 +1,102 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PayoutRequest describes a transfer of funds to a user's destination account
+type PayoutRequest struct {
+	WithdrawalID    string
+	UserID          string
+	Amount          float64
+	DestinationType string // bank_account, upi, paypal, etc.
+	Destination     string
+}
+
+// PayoutResult represents the outcome reported by the payout provider
+type PayoutResult struct {
+	Reference   string
+	Provider    string
+	CompletedAt time.Time
+}
+
+// PayoutProvider sends money out to users
+// Implementations must be safe for concurrent use, and SendPayout must be
+// idempotent per WithdrawalID: sending a withdrawal again returns the
+// original result without paying twice
+type PayoutProvider interface {
+	Name() string
+	SendPayout(ctx context.Context, req *PayoutRequest) (*PayoutResult, error)
+}
+
+// NewPayoutProvider returns the payout provider configured by name
+func NewPayoutProvider(name string) (PayoutProvider, error) {
+	switch name {
+	case "", "fake":
+		return NewFakePayoutProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payout provider: %s", name)
+	}
+}
+
+// FakePayoutProvider records payouts in memory without moving any money.
+// It is used for local development and tests.
+type FakePayoutProvider struct {
+	mu      sync.Mutex
+	payouts []PayoutRequest
+	results map[string]*PayoutResult
+	failErr error
+}
+
+// NewFakePayoutProvider creates a new in-memory payout provider
+func NewFakePayoutProvider() *FakePayoutProvider {
+	return &FakePayoutProvider{results: make(map[string]*PayoutResult)}
+}
+
+// Name returns the provider name stored on transactions
+func (p *FakePayoutProvider) Name() string {
+	return "fake"
+}
+
+// SendPayout records the payout and returns a generated reference. A
+// withdrawal that was already paid returns its original result.
+func (p *FakePayoutProvider) SendPayout(ctx context.Context, req *PayoutRequest) (*PayoutResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.results[req.WithdrawalID]; ok {
+		return result, nil
+	}
+	if p.failErr != nil {
+		return nil, p.failErr
+	}
+
+	p.payouts = append(p.payouts, *req)
+
+	result := &PayoutResult{
+		Reference:   "fake_payout_" + uuid.New().String(),
+		Provider:    p.Name(),
+		CompletedAt: time.Now(),
+	}
+	p.results[req.WithdrawalID] = result
+	return result, nil
+}
+
+// FailWith makes subsequent payouts fail with err (nil restores success)
+func (p *FakePayoutProvider) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failErr = err
+}
+
+// Payouts returns a copy of all payouts sent so far
+func (p *FakePayoutProvider) Payouts() []PayoutRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PayoutRequest(nil), p.payouts...)
+}
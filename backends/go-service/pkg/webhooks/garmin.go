@@ -0,0 +1,61 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// garmin decodes Garmin Health API "dailies" pushes, signed with a hex
+// HMAC-SHA256 of the body in X-Garmin-Signature
+type garmin struct {
+	secret []byte
+}
+
+func (v *garmin) Name() string {
+	return "garmin"
+}
+
+func (v *garmin) Verify(header http.Header, body []byte) error {
+	return verifyHexSHA256(v.secret, body, header.Get("X-Garmin-Signature"))
+}
+
+type garminDaily struct {
+	UserID                             string  `json:"userId"`
+	CalendarDate                       string  `json:"calendarDate"`
+	Steps                              int64   `json:"steps"`
+	DistanceInMeters                   float64 `json:"distanceInMeters"`
+	ActiveKilocalories                 float64 `json:"activeKilocalories"`
+	ModerateIntensityDurationInSeconds int     `json:"moderateIntensityDurationInSeconds"`
+	VigorousIntensityDurationInSeconds int     `json:"vigorousIntensityDurationInSeconds"`
+}
+
+// Decode reads the summaries in "dailies"; other summary types are ignored
+func (v *garmin) Decode(body []byte) ([]Event, error) {
+	var payload struct {
+		Dailies []garminDaily `json:"dailies"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	events := make([]Event, 0, len(payload.Dailies))
+	for i, d := range payload.Dailies {
+		if d.UserID == "" {
+			return nil, fmt.Errorf("%w: daily %d has no userId", ErrInvalidPayload, i)
+		}
+		if _, err := time.Parse("2006-01-02", d.CalendarDate); err != nil {
+			return nil, fmt.Errorf("%w: daily %d has calendarDate %q", ErrInvalidPayload, i, d.CalendarDate)
+		}
+		events = append(events, Event{
+			VendorUserID:  d.UserID,
+			Date:          d.CalendarDate,
+			Steps:         d.Steps,
+			Distance:      d.DistanceInMeters,
+			Calories:      d.ActiveKilocalories,
+			ActiveMinutes: (d.ModerateIntensityDurationInSeconds + d.VigorousIntensityDurationInSeconds + 30) / 60,
+		})
+	}
+	return events, nil
+}
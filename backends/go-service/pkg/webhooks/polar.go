@@ -0,0 +1,89 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// polar decodes Polar AccessLink activity summary events, signed with a hex
+// HMAC-SHA256 of the body in Polar-Webhook-Signature. The PING sent when the
+// webhook is created, and events for other data, carry no activity.
+type polar struct {
+	secret []byte
+}
+
+func (v *polar) Name() string {
+	return "polar"
+}
+
+func (v *polar) Verify(header http.Header, body []byte) error {
+	return verifyHexSHA256(v.secret, body, header.Get("Polar-Webhook-Signature"))
+}
+
+type polarEvent struct {
+	Event    string `json:"event"`
+	UserID   int64  `json:"user_id"`
+	Activity *struct {
+		Date           string  `json:"date"`
+		ActiveSteps    int64   `json:"active-steps"`
+		ActiveCalories float64 `json:"active-calories"`
+		Duration       string  `json:"duration"` // ISO 8601, e.g. PT2H44M
+	} `json:"activity"`
+}
+
+func (v *polar) Decode(body []byte) ([]Event, error) {
+	var e polarEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if e.Event != "ACTIVITY_SUMMARY" {
+		return nil, nil
+	}
+	if e.UserID == 0 || e.Activity == nil {
+		return nil, fmt.Errorf("%w: activity summary without user or activity", ErrInvalidPayload)
+	}
+	if _, err := time.Parse("2006-01-02", e.Activity.Date); err != nil {
+		return nil, fmt.Errorf("%w: activity date %q", ErrInvalidPayload, e.Activity.Date)
+	}
+	duration, err := parseISODuration(e.Activity.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	return []Event{{
+		VendorUserID:  strconv.FormatInt(e.UserID, 10),
+		Date:          e.Activity.Date,
+		Steps:         e.Activity.ActiveSteps,
+		Calories:      e.Activity.ActiveCalories,
+		ActiveMinutes: int(duration.Round(time.Minute) / time.Minute),
+	}}, nil
+}
+
+var isoDuration = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+
+// parseISODuration parses the time part of an ISO 8601 duration; "" is zero
+func parseISODuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	m := isoDuration.FindStringSubmatch(s)
+	if m == nil || s == "PT" {
+		return 0, fmt.Errorf("duration %q", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("duration %q", s)
+		}
+		d += time.Duration(v * float64(unit))
+	}
+	return d, nil
+}
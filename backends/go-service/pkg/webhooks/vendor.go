@@ -0,0 +1,75 @@
+// Package webhooks verifies and decodes the daily activity summaries that
+// wearable vendors push to us.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrUnknownVendor    = errors.New("unknown webhook vendor")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidPayload   = errors.New("invalid webhook payload")
+)
+
+// Event is one day of activity for a vendor's user
+type Event struct {
+	VendorUserID  string  `json:"vendor_user_id"`
+	Date          string  `json:"date"` // calendar day, YYYY-MM-DD, as the vendor split it
+	Steps         int64   `json:"steps"`
+	Distance      float64 `json:"distance"` // meters
+	Calories      float64 `json:"calories"` // active kcal
+	ActiveMinutes int     `json:"active_minutes"`
+}
+
+// Vendor checks and decodes one vendor's push notifications.
+// Implementations must be safe for concurrent use.
+type Vendor interface {
+	Name() string
+	Verify(header http.Header, body []byte) error
+
+	// Decode returns the activity a verified payload carries. Notifications
+	// of other kinds decode to no events.
+	Decode(body []byte) ([]Event, error)
+}
+
+// New returns the vendor called name, verifying with its signing secret
+func New(name, secret string) (Vendor, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("no signing secret for webhook vendor %s", name)
+	}
+	switch name {
+	case "garmin":
+		return &garmin{secret: []byte(secret)}, nil
+	case "polar":
+		return &polar{secret: []byte(secret)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVendor, name)
+	}
+}
+
+// Names lists the vendors New knows
+func Names() []string {
+	return []string{"garmin", "polar"}
+}
+
+// verifyHexSHA256 checks a hex HMAC-SHA256 of body, optionally prefixed with
+// "sha256=", in constant time
+func verifyHexSHA256(secret, body []byte, signature string) error {
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil || len(got) == 0 {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"dailies": []}`)
+	tests := []struct {
+		vendor, header string
+	}{
+		{"garmin", "X-Garmin-Signature"},
+		{"polar", "Polar-Webhook-Signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vendor, func(t *testing.T) {
+			vendor, err := New(tt.vendor, "secret")
+			require.NoError(t, err)
+
+			header := http.Header{}
+			header.Set(tt.header, sign("secret", body))
+			assert.NoError(t, vendor.Verify(header, body))
+
+			header.Set(tt.header, "sha256="+sign("secret", body))
+			assert.NoError(t, vendor.Verify(header, body))
+
+			header.Set(tt.header, sign("other secret", body))
+			assert.ErrorIs(t, vendor.Verify(header, body), ErrInvalidSignature)
+
+			header.Set(tt.header, sign("secret", body))
+			assert.ErrorIs(t, vendor.Verify(header, []byte(`{"dailies": [{}]}`)), ErrInvalidSignature, "tampered body")
+
+			assert.ErrorIs(t, vendor.Verify(http.Header{}, body), ErrInvalidSignature, "unsigned")
+		})
+	}
+
+	_, err := New("acme", "secret")
+	assert.ErrorIs(t, err, ErrUnknownVendor)
+	_, err = New("garmin", "")
+	assert.Error(t, err)
+}
+
+func TestGarmin_Decode(t *testing.T) {
+	vendor, err := New("garmin", "secret")
+	require.NoError(t, err)
+
+	events, err := vendor.Decode([]byte(`{"dailies": [{
+		"userId": "4aacafe8-2e5e-4f9a-a1b2-0c3d4e5f6a7b",
+		"userAccessToken": "token",
+		"summaryId": "x153a9f3-5a9478d4-6",
+		"calendarDate": "2024-06-03",
+		"steps": 11452,
+		"distanceInMeters": 8621.5,
+		"activeKilocalories": 512,
+		"moderateIntensityDurationInSeconds": 1500,
+		"vigorousIntensityDurationInSeconds": 1230
+	}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Event{{
+		VendorUserID:  "4aacafe8-2e5e-4f9a-a1b2-0c3d4e5f6a7b",
+		Date:          "2024-06-03",
+		Steps:         11452,
+		Distance:      8621.5,
+		Calories:      512,
+		ActiveMinutes: 46,
+	}}, events)
+
+	_, err = vendor.Decode([]byte(`{"dailies": [{"userId": "u", "calendarDate": "June 3"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+	_, err = vendor.Decode([]byte(`[`))
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestPolar_Decode(t *testing.T) {
+	vendor, err := New("polar", "secret")
+	require.NoError(t, err)
+
+	events, err := vendor.Decode([]byte(`{
+		"event": "ACTIVITY_SUMMARY",
+		"user_id": 475,
+		"timestamp": "2024-06-03T21:04:13.000Z",
+		"activity": {"date": "2024-06-03", "active-steps": 9120, "active-calories": 430, "duration": "PT1H12M30S"}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Event{{VendorUserID: "475", Date: "2024-06-03", Steps: 9120, Calories: 430, ActiveMinutes: 73}}, events)
+
+	events, err = vendor.Decode([]byte(`{"event": "PING", "timestamp": "2024-06-03T21:04:13.000Z"}`))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = vendor.Decode([]byte(`{"event": "ACTIVITY_SUMMARY", "user_id": 475, "activity": {"date": "2024-06-03", "duration": "1h"}}`))
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestParseISODuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"":         0,
+		"PT45M":    45 * time.Minute,
+		"PT2H":     2 * time.Hour,
+		"PT1H2M3S": time.Hour + 2*time.Minute + 3*time.Second,
+		"PT90.5S":  90*time.Second + 500*time.Millisecond,
+	} {
+		d, err := parseISODuration(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, d, s)
+	}
+	for _, s := range []string{"PT", "P1D", "45M"} {
+		_, err := parseISODuration(s)
+		assert.Error(t, err, s)
+	}
+}
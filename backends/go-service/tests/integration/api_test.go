@@ -42,13 +42,13 @@ func setupTestServer(t *testing.T) *TestServer {
 	// Initialize services
 	cacheService := services.NewCacheService(client)
 	leaderboardService := services.NewLeaderboardService(cacheService, client)
-	fitnessService := services.NewFitnessService(cacheService, "http://localhost:54321")
+	fitnessService := services.NewFitnessService(nil, cacheService, leaderboardService, "http://localhost:54321")
 
 	// Initialize logger
 	logger := utils.NewLogger("debug")
 
 	// Initialize handlers
-	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService, logger)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService, nil, logger)
 	fitnessHandler := handlers.NewFitnessHandler(fitnessService, logger)
 
 	// Setup router
@@ -65,7 +65,6 @@ func setupTestServer(t *testing.T) *TestServer {
 
 	// Leaderboard routes
 	api.HandleFunc("/leaderboard/{competitionId}", leaderboardHandler.GetLeaderboard).Methods("GET")
-	api.HandleFunc("/leaderboard/update", leaderboardHandler.UpdateScore).Methods("POST")
 
 	// Fitness routes
 	api.HandleFunc("/fitness/sync", fitnessHandler.SyncFitnessData).Methods("POST")
@@ -95,35 +94,46 @@ func (ts *TestServer) generateToken(userID string) string {
 	return tokenString
 }
 
-func TestAPI_LeaderboardUpdate(t *testing.T) {
+func TestAPI_FitnessSyncUpdatesLeaderboard(t *testing.T) {
 	ts := setupTestServer(t)
 	defer ts.Close()
 
 	userID := "test-user-1"
 	token := ts.generateToken(userID)
 
-	req := models.ScoreUpdateRequest{
-		UserID:        userID,
+	// The body can't sync for someone else; the data is the caller's
+	req := models.FitnessSyncRequest{
+		UserID:        "test-user-2",
 		CompetitionID: "comp-1",
 		Steps:         10000,
 		Distance:      8.5,
 		Calories:      500,
+		Source:        "google_fit",
 	}
 
 	body, _ := json.Marshal(req)
-	httpReq := httptest.NewRequest("POST", "/api/v1/leaderboard/update", bytes.NewBuffer(body))
+	httpReq := httptest.NewRequest("POST", "/api/v1/fitness/sync", bytes.NewBuffer(body))
 	httpReq.Header.Set("Authorization", "Bearer "+token)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	ts.router.ServeHTTP(w, httpReq)
+	require.Equal(t, http.StatusOK, w.Code)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	httpReq = httptest.NewRequest("GET", "/api/v1/leaderboard/comp-1", nil)
+	httpReq.Header.Set("Authorization", "Bearer "+token)
 
-	var response models.SuccessResponse
-	err := json.NewDecoder(w.Body).Decode(&response)
-	assert.NoError(t, err)
-	assert.True(t, response.Success)
+	w = httptest.NewRecorder()
+	ts.router.ServeHTTP(w, httpReq)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data models.Leaderboard `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Len(t, response.Data.Entries, 1)
+	assert.Equal(t, userID, response.Data.Entries[0].UserID)
+	assert.Equal(t, int64(10000), response.Data.Entries[0].Steps)
 }
 
 func TestAPI_GetLeaderboard(t *testing.T) {
@@ -244,14 +254,15 @@ func TestAPI_ConcurrentRequests(t *testing.T) {
 			userID := "user-" + string(rune(index))
 			token := ts.generateToken(userID)
 
-			req := models.ScoreUpdateRequest{
+			req := models.FitnessSyncRequest{
 				UserID:        userID,
 				CompetitionID: competitionID,
 				Steps:         int64(index * 1000),
+				Source:        "google_fit",
 			}
 
 			body, _ := json.Marshal(req)
-			httpReq := httptest.NewRequest("POST", "/api/v1/leaderboard/update", bytes.NewBuffer(body))
+			httpReq := httptest.NewRequest("POST", "/api/v1/fitness/sync", bytes.NewBuffer(body))
 			httpReq.Header.Set("Authorization", "Bearer "+token)
 			httpReq.Header.Set("Content-Type", "application/json")
 
@@ -279,7 +290,7 @@ func TestAPI_ConcurrentRequests(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
-func BenchmarkAPI_LeaderboardUpdate(b *testing.B) {
+func BenchmarkAPI_FitnessSync(b *testing.B) {
 	ts := setupTestServer(&testing.T{})
 	defer ts.Close()
 
@@ -288,14 +299,15 @@ func BenchmarkAPI_LeaderboardUpdate(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		req := models.ScoreUpdateRequest{
+		req := models.FitnessSyncRequest{
 			UserID:        userID,
 			CompetitionID: "bench-comp",
 			Steps:         int64(i * 100),
+			Source:        "google_fit",
 		}
 
 		body, _ := json.Marshal(req)
-		httpReq := httptest.NewRequest("POST", "/api/v1/leaderboard/update", bytes.NewBuffer(body))
+		httpReq := httptest.NewRequest("POST", "/api/v1/fitness/sync", bytes.NewBuffer(body))
 		httpReq.Header.Set("Authorization", "Bearer "+token)
 		httpReq.Header.Set("Content-Type", "application/json")
 
@@ -133,12 +133,13 @@ func (lt *LoadTester) Run() (*LoadTestResult, error) {
 func (lt *LoadTester) makeRequest(userIndex, requestIndex int) (bool, time.Duration) {
 	userID := fmt.Sprintf("load-test-user-%d", userIndex)
 
-	req := models.ScoreUpdateRequest{
+	req := models.FitnessSyncRequest{
 		UserID:        userID,
 		CompetitionID: lt.config.CompetitionID,
 		Steps:         int64((userIndex + requestIndex) * 1000),
 		Distance:      float64(userIndex+requestIndex) * 0.8,
 		Calories:      float64((userIndex + requestIndex) * 50),
+		Source:        "google_fit",
 	}
 
 	body, err := json.Marshal(req)
@@ -146,7 +147,7 @@ func (lt *LoadTester) makeRequest(userIndex, requestIndex int) (bool, time.Durat
 		return false, 0
 	}
 
-	url := lt.config.BaseURL + "/api/v1/leaderboard/update"
+	url := lt.config.BaseURL + "/api/v1/fitness/sync"
 	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return false, 0
@@ -6,13 +6,29 @@
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 -- Drop existing tables if they exist (in correct order)
+DROP TABLE IF EXISTS public.notifications CASCADE;
+DROP TABLE IF EXISTS public.competition_template_subscriptions CASCADE;
+DROP TABLE IF EXISTS public.competition_invites CASCADE;
+DROP TABLE IF EXISTS public.competition_waitlist CASCADE;
+DROP TABLE IF EXISTS public.withdrawal_limits CASCADE;
+DROP TABLE IF EXISTS public.withdrawal_requests CASCADE;
+DROP TABLE IF EXISTS public.division_history CASCADE;
+DROP TABLE IF EXISTS public.challenges CASCADE;
+DROP TABLE IF EXISTS public.ledger_entries CASCADE;
 DROP TABLE IF EXISTS public.transactions CASCADE;
 DROP TABLE IF EXISTS public.prizes CASCADE;
 DROP TABLE IF EXISTS public.leaderboard_entries CASCADE;
 DROP TABLE IF EXISTS public.activity_logs CASCADE;
+DROP TABLE IF EXISTS public.wearable_accounts CASCADE;
+DROP TABLE IF EXISTS public.fitness_connections CASCADE;
+DROP TABLE IF EXISTS public.fitness_anomalies CASCADE;
+DROP TABLE IF EXISTS public.fitness_source_settings CASCADE;
+DROP TABLE IF EXISTS public.fitness_daily CASCADE;
+DROP TABLE IF EXISTS public.fitness_syncs CASCADE;
 DROP TABLE IF EXISTS public.fitness_data CASCADE;
 DROP TABLE IF EXISTS public.competition_participants CASCADE;
 DROP TABLE IF EXISTS public.competitions CASCADE;
+DROP TABLE IF EXISTS public.competition_templates CASCADE;
 DROP TABLE IF EXISTS public.users CASCADE;
 
 -- Drop existing triggers
@@ -26,10 +42,41 @@ CREATE TABLE public.users (
     avatar TEXT,
     bio TEXT,
     country VARCHAR(100),
+    profile_visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (profile_visibility IN ('public', 'private')),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Recurring competition templates; the scheduler creates each instance
+CREATE TABLE public.competition_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    entry_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    prize_pool DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'private')),
+    max_participants INTEGER CHECK (max_participants > 0),
+    recurrence_frequency VARCHAR(20) NOT NULL CHECK (recurrence_frequency IN ('daily', 'weekly', 'monthly')),
+    recurrence_interval INTEGER NOT NULL DEFAULT 1 CHECK (recurrence_interval > 0),
+    first_start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    create_ahead_hours INTEGER NOT NULL DEFAULT 24 CHECK (create_ahead_hours > 0),
+    creator_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    active BOOLEAN NOT NULL DEFAULT true,
+    mode VARCHAR(20) NOT NULL DEFAULT 'score' CHECK (mode IN ('score', 'goal')),
+    goal_metric VARCHAR(20) CHECK (goal_metric IN ('steps', 'distance', 'calories')),
+    goal_target DECIMAL(12, 2) CHECK (goal_target > 0),
+    goal_end_after_finishers INTEGER CHECK (goal_end_after_finishers > 0),
+    division_count INTEGER CHECK (division_count BETWEEN 2 AND 10),
+    division_promote INTEGER NOT NULL DEFAULT 0 CHECK (division_promote >= 0),
+    division_relegate INTEGER NOT NULL DEFAULT 0 CHECK (division_relegate >= 0),
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA zone that day boundaries are counted in
+    eligible_sources TEXT[], -- NULL accepts data from any source
+    eligible_countries TEXT[], -- NULL accepts participants from any country
+    min_active_days INTEGER NOT NULL DEFAULT 0 CHECK (min_active_days >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (mode <> 'goal' OR (goal_metric IS NOT NULL AND goal_target IS NOT NULL))
+);
+
 -- Competitions table
 CREATE TABLE public.competitions (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -39,11 +86,29 @@ CREATE TABLE public.competitions (
     prize_pool DECIMAL(10, 2) NOT NULL DEFAULT 0,
     start_date TIMESTAMP WITH TIME ZONE NOT NULL,
     end_date TIMESTAMP WITH TIME ZONE NOT NULL,
-    status VARCHAR(20) NOT NULL CHECK (status IN ('upcoming', 'active', 'completed')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('upcoming', 'active', 'completed', 'cancelled')),
     type VARCHAR(50) NOT NULL,
     creator_id UUID REFERENCES public.users(id) ON DELETE SET NULL,
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'private')),
+    max_participants INTEGER CHECK (max_participants > 0),
+    template_id UUID REFERENCES public.competition_templates(id) ON DELETE SET NULL,
+    enrolled_at TIMESTAMP WITH TIME ZONE, -- when a template instance's subscribers were enrolled
+    mode VARCHAR(20) NOT NULL DEFAULT 'score' CHECK (mode IN ('score', 'goal')),
+    goal_metric VARCHAR(20) CHECK (goal_metric IN ('steps', 'distance', 'calories')),
+    goal_target DECIMAL(12, 2) CHECK (goal_target > 0),
+    goal_end_after_finishers INTEGER CHECK (goal_end_after_finishers > 0),
+    division_count INTEGER CHECK (division_count BETWEEN 2 AND 10),
+    division_promote INTEGER NOT NULL DEFAULT 0 CHECK (division_promote >= 0),
+    division_relegate INTEGER NOT NULL DEFAULT 0 CHECK (division_relegate >= 0),
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA zone that day boundaries are counted in
+    eligible_sources TEXT[], -- NULL accepts data from any source
+    eligible_countries TEXT[], -- NULL accepts participants from any country
+    min_active_days INTEGER NOT NULL DEFAULT 0 CHECK (min_active_days >= 0),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(template_id, start_date),
+    -- Goal-mode competitions must define their target
+    CHECK (mode <> 'goal' OR (goal_metric IS NOT NULL AND goal_target IS NOT NULL))
 );
 
 -- Competition participants junction table
@@ -52,9 +117,41 @@ CREATE TABLE public.competition_participants (
     competition_id UUID NOT NULL REFERENCES public.competitions(id) ON DELETE CASCADE,
     user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
     joined_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    entry_transaction_id UUID,
+    division INTEGER CHECK (division > 0), -- 1 is the top division; NULL until assigned
+    UNIQUE(competition_id, user_id)
+);
+
+-- Waitlist for full competitions, served in created_at order
+CREATE TABLE public.competition_waitlist (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    competition_id UUID NOT NULL REFERENCES public.competitions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     UNIQUE(competition_id, user_id)
 );
 
+-- Users who opted in to be entered into every instance of a template
+CREATE TABLE public.competition_template_subscriptions (
+    template_id UUID NOT NULL REFERENCES public.competition_templates(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (template_id, user_id)
+);
+
+-- Invite codes for joining private competitions
+CREATE TABLE public.competition_invites (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    competition_id UUID NOT NULL REFERENCES public.competitions(id) ON DELETE CASCADE,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    created_by UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    max_uses INTEGER CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Fitness data tracking
 CREATE TABLE public.fitness_data (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -68,9 +165,99 @@ CREATE TABLE public.fitness_data (
     date DATE NOT NULL,
     synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    -- One row per day and source; each sync replaces its values
+    UNIQUE(user_id, competition_id, date, source)
+);
+
+-- Each day's sources merged into one record, naming the source of each metric
+CREATE TABLE public.fitness_daily (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    competition_id UUID REFERENCES public.competitions(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    steps BIGINT NOT NULL DEFAULT 0,
+    distance DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    calories DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    active_minutes INTEGER NOT NULL DEFAULT 0,
+    steps_source VARCHAR(50),
+    distance_source VARCHAR(50),
+    calories_source VARCHAR(50),
+    active_minutes_source VARCHAR(50),
+    merge_policy VARCHAR(20) NOT NULL,
+    merged_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     UNIQUE(user_id, competition_id, date)
 );
 
+-- How a user's sources are merged; users without a row get the max policy
+CREATE TABLE public.fitness_source_settings (
+    user_id UUID PRIMARY KEY REFERENCES public.users(id) ON DELETE CASCADE,
+    merge_policy VARCHAR(20) NOT NULL DEFAULT 'max' CHECK (merge_policy IN ('max', 'priority', 'per_metric')),
+    source_priority TEXT[] NOT NULL DEFAULT '{}',
+    steps_source VARCHAR(50),
+    distance_source VARCHAR(50),
+    calories_source VARCHAR(50),
+    active_minutes_source VARCHAR(50),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Client-supplied sync IDs already applied, so retried syncs are no-ops
+CREATE TABLE public.fitness_syncs (
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    sync_id VARCHAR(100) NOT NULL,
+    competition_id UUID REFERENCES public.competitions(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, sync_id)
+);
+
+-- Synced records that tripped anti-cheat rules, with the values as submitted
+CREATE TABLE public.fitness_anomalies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    competition_id UUID REFERENCES public.competitions(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    steps BIGINT NOT NULL DEFAULT 0,
+    distance DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    calories DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    active_minutes INTEGER NOT NULL DEFAULT 0,
+    rules TEXT[] NOT NULL,
+    details TEXT[] NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('flag', 'clamp', 'quarantine')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewed_by UUID REFERENCES public.users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    reviewed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- OAuth connections to fitness providers that daily totals are pulled from.
+-- Holds tokens, so it is only read by the service (no policies).
+CREATE TABLE public.fitness_connections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    access_token TEXT NOT NULL,
+    refresh_token TEXT NOT NULL DEFAULT '',
+    token_expires_at TIMESTAMP WITH TIME ZONE,
+    scope TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'reauthorize')),
+    next_sync_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, provider)
+);
+
+-- Maps the user IDs wearable vendors push webhooks for to our users
+CREATE TABLE public.wearable_accounts (
+    vendor VARCHAR(50) NOT NULL,
+    vendor_user_id VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY(vendor, vendor_user_id)
+);
+
 -- Activity logs for recent activity display
 CREATE TABLE public.activity_logs (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -81,7 +268,14 @@ CREATE TABLE public.activity_logs (
     calories DECIMAL(10, 2) NOT NULL DEFAULT 0,
     distance DECIMAL(10, 2) NOT NULL DEFAULT 0,
     duration INTEGER NOT NULL DEFAULT 0,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    -- Imported workouts: the competition day they count towards and the
+    -- SHA-256 of the uploaded file, so a file is only imported once
+    competition_id UUID REFERENCES public.competitions(id) ON DELETE CASCADE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    activity_date DATE,
+    external_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, competition_id, external_id)
 );
 
 -- Leaderboard entries (cached/computed)
@@ -106,10 +300,12 @@ CREATE TABLE public.prizes (
     competition_id UUID NOT NULL REFERENCES public.competitions(id) ON DELETE CASCADE,
     user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
     rank INTEGER NOT NULL,
+    division INTEGER NOT NULL DEFAULT 0, -- 0 for prizes across the whole competition
     amount DECIMAL(10, 2) NOT NULL,
     status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'distributed', 'failed')),
     distributed_at TIMESTAMP WITH TIME ZONE,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(competition_id, division, rank)
 );
 
 -- Financial transactions
@@ -117,16 +313,110 @@ CREATE TABLE public.transactions (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
     competition_id UUID REFERENCES public.competitions(id) ON DELETE SET NULL,
-    type VARCHAR(20) NOT NULL CHECK (type IN ('entry_fee', 'prize', 'refund')),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('entry_fee', 'prize', 'refund', 'withdrawal')),
     amount DECIMAL(10, 2) NOT NULL,
     status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'completed', 'failed')),
     description TEXT,
     payment_method VARCHAR(50),
     transaction_ref VARCHAR(255),
+    parent_transaction_id UUID REFERENCES public.transactions(id) ON DELETE SET NULL,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     completed_at TIMESTAMP WITH TIME ZONE
 );
 
+-- Entry fee paid to join (transactions is created after participants)
+ALTER TABLE public.competition_participants
+    ADD CONSTRAINT fk_comp_participants_entry_transaction
+    FOREIGN KEY (entry_transaction_id) REFERENCES public.transactions(id) ON DELETE SET NULL;
+
+-- Balance ledger (positive = credit, negative = debit)
+CREATE TABLE public.ledger_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL,
+    type VARCHAR(30) NOT NULL CHECK (type IN (
+        'prize', 'withdrawal_hold', 'withdrawal_release',
+        'challenge_stake', 'challenge_release', 'challenge_payout'
+    )),
+    reference_id UUID,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Withdrawal requests awaiting admin review and payout
+CREATE TABLE public.withdrawal_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    destination_type VARCHAR(30) NOT NULL,
+    destination TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'approved', 'rejected', 'paid', 'failed')),
+    transaction_id UUID NOT NULL REFERENCES public.transactions(id),
+    reviewed_by UUID REFERENCES public.users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    payout_ref VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Per-user overrides of the default withdrawal limits
+CREATE TABLE public.withdrawal_limits (
+    user_id UUID PRIMARY KEY REFERENCES public.users(id) ON DELETE CASCADE,
+    daily_limit DECIMAL(10, 2),
+    monthly_limit DECIMAL(10, 2),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- One-on-one challenges; stakes are held on the ledger until resolution
+CREATE TABLE public.challenges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    challenger_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    opponent_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    metric VARCHAR(20) NOT NULL CHECK (metric IN ('steps', 'distance', 'calories')),
+    duration_days INTEGER NOT NULL CHECK (duration_days BETWEEN 1 AND 30),
+    stake DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (stake >= 0),
+    message TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'active', 'declined', 'expired', 'cancelled', 'completed')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    start_date TIMESTAMP WITH TIME ZONE,
+    end_date TIMESTAMP WITH TIME ZONE,
+    winner_id UUID REFERENCES public.users(id) ON DELETE SET NULL,
+    challenger_score DECIMAL(12, 2),
+    opponent_score DECIMAL(12, 2),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (challenger_id <> opponent_id)
+);
+
+-- Division finishes per season; next_division places the user in the
+-- template's next instance
+CREATE TABLE public.division_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    competition_id UUID NOT NULL REFERENCES public.competitions(id) ON DELETE CASCADE,
+    template_id UUID REFERENCES public.competition_templates(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    division INTEGER NOT NULL CHECK (division > 0),
+    rank INTEGER NOT NULL,
+    movement VARCHAR(20) NOT NULL CHECK (movement IN ('promoted', 'relegated', 'stayed')),
+    next_division INTEGER NOT NULL CHECK (next_division > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(competition_id, user_id)
+);
+
+-- In-app notifications
+CREATE TABLE public.notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    competition_id UUID REFERENCES public.competitions(id) ON DELETE CASCADE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Indexes for performance optimization
 CREATE INDEX idx_competitions_status ON public.competitions(status);
 CREATE INDEX idx_competitions_dates ON public.competitions(start_date, end_date);
@@ -136,13 +426,42 @@ CREATE INDEX idx_comp_participants_comp ON public.competition_participants(compe
 CREATE INDEX idx_fitness_data_user_date ON public.fitness_data(user_id, date DESC);
 CREATE INDEX idx_fitness_data_comp ON public.fitness_data(competition_id);
 CREATE INDEX idx_fitness_data_date ON public.fitness_data(date DESC);
+CREATE INDEX idx_fitness_daily_user_date ON public.fitness_daily(user_id, date DESC);
 CREATE INDEX idx_activity_logs_user ON public.activity_logs(user_id, created_at DESC);
+CREATE INDEX idx_activity_logs_imported ON public.activity_logs(user_id, competition_id, activity_date) WHERE external_id IS NOT NULL;
 CREATE INDEX idx_leaderboard_comp_rank ON public.leaderboard_entries(competition_id, rank);
 CREATE INDEX idx_leaderboard_user ON public.leaderboard_entries(user_id);
 CREATE INDEX idx_prizes_comp ON public.prizes(competition_id);
 CREATE INDEX idx_prizes_user ON public.prizes(user_id);
 CREATE INDEX idx_transactions_user ON public.transactions(user_id, created_at DESC);
 CREATE INDEX idx_transactions_comp ON public.transactions(competition_id);
+CREATE INDEX idx_competitions_status_start ON public.competitions(status, start_date);
+CREATE INDEX idx_competitions_status_end ON public.competitions(status, end_date);
+CREATE INDEX idx_competitions_unenrolled ON public.competitions(created_at) WHERE template_id IS NOT NULL AND enrolled_at IS NULL;
+CREATE INDEX idx_notifications_user ON public.notifications(user_id, created_at DESC);
+CREATE INDEX idx_ledger_entries_user ON public.ledger_entries(user_id, created_at DESC);
+CREATE INDEX idx_withdrawals_user ON public.withdrawal_requests(user_id, created_at DESC);
+CREATE INDEX idx_withdrawals_status ON public.withdrawal_requests(status, created_at);
+CREATE INDEX idx_fitness_anomalies_status ON public.fitness_anomalies(status, created_at);
+CREATE INDEX idx_fitness_anomalies_user ON public.fitness_anomalies(user_id, competition_id) WHERE status = 'pending';
+CREATE INDEX idx_fitness_connections_due ON public.fitness_connections(next_sync_at) WHERE status = 'active';
+CREATE INDEX idx_comp_invites_comp ON public.competition_invites(competition_id, created_at DESC);
+CREATE INDEX idx_competitions_visibility ON public.competitions(visibility);
+CREATE INDEX idx_competitions_type ON public.competitions(type);
+CREATE INDEX idx_competitions_entry_fee ON public.competitions(entry_fee);
+CREATE INDEX idx_competitions_prize_pool ON public.competitions(prize_pool DESC);
+-- Must match competitionSearchDocument in the Go service
+CREATE INDEX idx_competitions_search ON public.competitions
+    USING GIN (to_tsvector('english', name || ' ' || COALESCE(description, '')));
+CREATE INDEX idx_template_subscriptions_user ON public.competition_template_subscriptions(user_id);
+CREATE INDEX idx_comp_waitlist_order ON public.competition_waitlist(competition_id, created_at, id);
+CREATE INDEX idx_challenges_challenger ON public.challenges(challenger_id, created_at DESC);
+CREATE INDEX idx_challenges_opponent ON public.challenges(opponent_id, created_at DESC);
+CREATE INDEX idx_challenges_pending_expiry ON public.challenges(expires_at) WHERE status = 'pending';
+CREATE INDEX idx_challenges_active_end ON public.challenges(end_date) WHERE status = 'active';
+CREATE INDEX idx_comp_participants_division ON public.competition_participants(competition_id, division);
+CREATE INDEX idx_division_history_user ON public.division_history(user_id, created_at DESC);
+CREATE INDEX idx_division_history_template ON public.division_history(template_id, user_id, created_at DESC);
 
 -- Functions for automatic timestamp updates
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -193,10 +512,26 @@ ALTER TABLE public.users ENABLE ROW LEVEL SECURITY;
 ALTER TABLE public.competitions ENABLE ROW LEVEL SECURITY;
 ALTER TABLE public.competition_participants ENABLE ROW LEVEL SECURITY;
 ALTER TABLE public.fitness_data ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.fitness_daily ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.fitness_source_settings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.fitness_syncs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.fitness_anomalies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.fitness_connections ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.wearable_accounts ENABLE ROW LEVEL SECURITY;
 ALTER TABLE public.activity_logs ENABLE ROW LEVEL SECURITY;
 ALTER TABLE public.leaderboard_entries ENABLE ROW LEVEL SECURITY;
 ALTER TABLE public.prizes ENABLE ROW LEVEL SECURITY;
 ALTER TABLE public.transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.ledger_entries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.withdrawal_requests ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.withdrawal_limits ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.notifications ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.competition_waitlist ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.competition_invites ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.competition_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.competition_template_subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.challenges ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.division_history ENABLE ROW LEVEL SECURITY;
 
 -- Drop existing policies if they exist
 DROP POLICY IF EXISTS "Public profiles are viewable by everyone" ON public.users;
@@ -207,11 +542,23 @@ DROP POLICY IF EXISTS "Participants viewable by everyone" ON public.competition_
 DROP POLICY IF EXISTS "Users can join competitions" ON public.competition_participants;
 DROP POLICY IF EXISTS "Users can view own fitness data" ON public.fitness_data;
 DROP POLICY IF EXISTS "Users can insert own fitness data" ON public.fitness_data;
+DROP POLICY IF EXISTS "Users can view own merged fitness data" ON public.fitness_daily;
+DROP POLICY IF EXISTS "Users can view own source settings" ON public.fitness_source_settings;
+DROP POLICY IF EXISTS "Users can view own fitness syncs" ON public.fitness_syncs;
+DROP POLICY IF EXISTS "Users can view own fitness anomalies" ON public.fitness_anomalies;
 DROP POLICY IF EXISTS "Users can view own activity logs" ON public.activity_logs;
 DROP POLICY IF EXISTS "Users can create own activity logs" ON public.activity_logs;
 DROP POLICY IF EXISTS "Leaderboards are viewable by everyone" ON public.leaderboard_entries;
 DROP POLICY IF EXISTS "Prizes are viewable by everyone" ON public.prizes;
 DROP POLICY IF EXISTS "Users can view own transactions" ON public.transactions;
+DROP POLICY IF EXISTS "Users can view own ledger entries" ON public.ledger_entries;
+DROP POLICY IF EXISTS "Users can view own withdrawals" ON public.withdrawal_requests;
+DROP POLICY IF EXISTS "Users can view own notifications" ON public.notifications;
+DROP POLICY IF EXISTS "Users can view own waitlist entries" ON public.competition_waitlist;
+DROP POLICY IF EXISTS "Public templates are viewable by everyone" ON public.competition_templates;
+DROP POLICY IF EXISTS "Users can view own template subscriptions" ON public.competition_template_subscriptions;
+DROP POLICY IF EXISTS "Users can view own challenges" ON public.challenges;
+DROP POLICY IF EXISTS "Division history viewable by everyone" ON public.division_history;
 
 -- Create policies
 CREATE POLICY "Public profiles are viewable by everyone" ON public.users
@@ -220,8 +567,16 @@ CREATE POLICY "Public profiles are viewable by everyone" ON public.users
 CREATE POLICY "Users can update own profile" ON public.users
     FOR UPDATE USING (auth.uid() = id);
 
+-- Private competitions are only visible to their creator and participants
 CREATE POLICY "Competitions are viewable by everyone" ON public.competitions
-    FOR SELECT USING (true);
+    FOR SELECT USING (
+        visibility <> 'private'
+        OR auth.uid() = creator_id
+        OR EXISTS (
+            SELECT 1 FROM public.competition_participants cp
+            WHERE cp.competition_id = id AND cp.user_id = auth.uid()
+        )
+    );
 
 CREATE POLICY "Authenticated users can create competitions" ON public.competitions
     FOR INSERT WITH CHECK (auth.uid() = creator_id);
@@ -238,6 +593,18 @@ CREATE POLICY "Users can view own fitness data" ON public.fitness_data
 CREATE POLICY "Users can insert own fitness data" ON public.fitness_data
     FOR INSERT WITH CHECK (auth.uid() = user_id);
 
+CREATE POLICY "Users can view own merged fitness data" ON public.fitness_daily
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view own source settings" ON public.fitness_source_settings
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view own fitness syncs" ON public.fitness_syncs
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view own fitness anomalies" ON public.fitness_anomalies
+    FOR SELECT USING (auth.uid() = user_id);
+
 CREATE POLICY "Users can view own activity logs" ON public.activity_logs
     FOR SELECT USING (auth.uid() = user_id);
 
@@ -253,6 +620,30 @@ CREATE POLICY "Prizes are viewable by everyone" ON public.prizes
 CREATE POLICY "Users can view own transactions" ON public.transactions
     FOR SELECT USING (auth.uid() = user_id);
 
+CREATE POLICY "Users can view own ledger entries" ON public.ledger_entries
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view own withdrawals" ON public.withdrawal_requests
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view own notifications" ON public.notifications
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view own waitlist entries" ON public.competition_waitlist
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Public templates are viewable by everyone" ON public.competition_templates
+    FOR SELECT USING (visibility = 'public' OR auth.uid() = creator_id);
+
+CREATE POLICY "Users can view own template subscriptions" ON public.competition_template_subscriptions
+    FOR SELECT USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can view own challenges" ON public.challenges
+    FOR SELECT USING (auth.uid() = challenger_id OR auth.uid() = opponent_id);
+
+CREATE POLICY "Division history viewable by everyone" ON public.division_history
+    FOR SELECT USING (true);
+
 -- Views for common queries
 CREATE OR REPLACE VIEW user_stats AS
 SELECT 
@@ -351,7 +742,23 @@ COMMENT ON TABLE public.users IS 'User profile information extending Supabase Au
 COMMENT ON TABLE public.competitions IS 'Fitness competitions with entry fees and prize pools';
 COMMENT ON TABLE public.competition_participants IS 'Junction table for user competition participation';
 COMMENT ON TABLE public.fitness_data IS 'Daily fitness tracking data from mobile apps';
+COMMENT ON TABLE public.fitness_daily IS 'One merged record per user, competition and day, built from fitness_data by the user''s merge settings';
+COMMENT ON TABLE public.fitness_source_settings IS 'Per-user source priority and merge policy for fitness data';
+COMMENT ON TABLE public.fitness_syncs IS 'Applied client sync IDs that make retried syncs idempotent';
+COMMENT ON TABLE public.fitness_anomalies IS 'Anti-cheat findings on synced records, awaiting or after admin review';
+COMMENT ON TABLE public.fitness_connections IS 'Per-user OAuth tokens for fitness providers polled for daily totals';
+COMMENT ON TABLE public.wearable_accounts IS 'Vendor user IDs whose pushed webhooks are synced to a user';
 COMMENT ON TABLE public.activity_logs IS 'Individual activity sessions for display';
 COMMENT ON TABLE public.leaderboard_entries IS 'Cached leaderboard rankings per competition';
 COMMENT ON TABLE public.prizes IS 'Prize distribution records';
 COMMENT ON TABLE public.transactions IS 'Financial transactions for entry fees and prizes';
+COMMENT ON TABLE public.ledger_entries IS 'Append-only balance ledger; a user balance is the sum of their entries';
+COMMENT ON TABLE public.withdrawal_requests IS 'Withdrawal requests with admin review and payout status';
+COMMENT ON TABLE public.withdrawal_limits IS 'Per-user overrides of the default withdrawal limits';
+COMMENT ON TABLE public.notifications IS 'In-app notifications such as competition start and end';
+COMMENT ON TABLE public.competition_waitlist IS 'Users waiting for a spot in a full competition';
+COMMENT ON TABLE public.competition_templates IS 'Recurring competition definitions; instances reference them via competitions.template_id';
+COMMENT ON TABLE public.competition_template_subscriptions IS 'Users auto-enrolled in every new instance of a template';
+COMMENT ON TABLE public.competition_invites IS 'Invite codes for private competitions with optional expiry and usage limits';
+COMMENT ON TABLE public.division_history IS 'Per-season division finishes with promotion and relegation';
+COMMENT ON TABLE public.challenges IS 'Head-to-head challenges between two users, separate from public competitions';